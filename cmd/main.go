@@ -1,28 +1,147 @@
 package main
 
 import (
+	"errors"
+	"flag"
 	"fmt"
+	"net"
 	"os"
+	"os/signal"
 
-	"github.com/vsinha/vm/internal/assembler"
+	"github.com/vsinha/vm/internal/cartridge"
+	"github.com/vsinha/vm/internal/model"
+	"github.com/vsinha/vm/internal/printer"
+	"github.com/vsinha/vm/internal/serial"
 	"github.com/vsinha/vm/internal/vm"
 )
 
 func main() {
-	instructions := []interface{}{
-		vm.Nop,
-		vm.Halt,
+	if len(os.Args) > 1 && os.Args[1] == "record" {
+		if err := record(os.Args[2:]); err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+		}
+		return
 	}
 
-	mem, err := assembler.Assemble(instructions)
+	flag.Usage = func() {
+		fmt.Fprintf(flag.CommandLine.Output(), "usage: %s [flags] rom.gb\n", os.Args[0])
+		fmt.Fprintf(flag.CommandLine.Output(), "       %s record [flags] rom.gb\n", os.Args[0])
+		flag.PrintDefaults()
+	}
+	listen := flag.String("listen", "", "wait for another emulator to connect a link cable at `addr`, such as localhost:5432")
+	connect := flag.String("connect", "", "connect a link cable to another emulator listening at `addr`")
+	printTo := flag.String("printer", "", "connect a Game Boy Printer that writes its pages to `dir` as PNGs")
+	boot := flag.String("boot", "", "run the DMG or CGB boot ROM in `file` before the game")
+	hw := flag.String("model", "", "emulate `model`: DMG0, DMG, MGB, SGB, CGB or AGB (default CGB for colour games, DMG otherwise)")
+	trace := flag.Bool("trace", false, "print every instruction as it runs")
+	flag.Parse()
+
+	if flag.NArg() != 1 {
+		flag.Usage()
+		return
+	}
+	if *printTo != "" && (*listen != "" || *connect != "") {
+		fmt.Println("-printer can't be used with a link cable")
+		os.Exit(1)
+	}
+
+	c, err := cartridge.Load(flag.Arg(0))
 	if err != nil {
-		fmt.Printf("unable to assemble error: %v", err)
+		fmt.Printf("unable to load cartridge error: %v\n", err)
+		os.Exit(1)
+	}
+	fmt.Printf("Loaded %q (%v, %d KiB ROM, %d KiB RAM)\n", c.Title, c.Type, c.ROMSize/1024, c.RAMSize/1024)
+	if err := c.Warning(); err != nil {
+		fmt.Printf("warning: %v\n", err)
+	}
+
+	opts, err := bootOptions(*boot, *hw)
+	if err != nil {
+		fmt.Println(err)
+		os.Exit(1)
+	}
+	if *trace {
+		opts = append(opts, vm.WithTrace())
+	}
+	v := vm.New(c, opts...)
+	if err := link(v, *listen, *connect); err != nil {
+		fmt.Printf("unable to connect link cable: %v\n", err)
 		os.Exit(1)
 	}
+	p := plugPrinter(v, *printTo)
 
-	v := vm.New(mem)
-	if err := v.Run(); err != nil {
-		fmt.Printf("virtual machine error: %v", err)
+	// Run until interrupted, then save the cartridge RAM on the way out.
+	stop := make(chan struct{})
+	sigs := make(chan os.Signal, 1)
+	signal.Notify(sigs, os.Interrupt)
+	go func() {
+		<-sigs
+		close(stop)
+	}()
+	runErr := v.Run(stop)
+	if err := v.Close(); err != nil {
+		fmt.Printf("unable to save cartridge RAM: %v\n", err)
+	}
+	if p != nil && p.Err() != nil {
+		fmt.Printf("unable to save printed page: %v\n", p.Err())
+	}
+	if runErr != nil {
+		fmt.Printf("virtual machine error: %v\n%v\n", runErr, v.Reg())
 		os.Exit(1)
 	}
 }
+
+// link plugs a TCP link cable into v's serial port, either waiting for another
+// emulator to connect or connecting to one. With neither address, the port is
+// left disconnected.
+func link(v *vm.VM, listen, connect string) error {
+	switch {
+	case listen != "" && connect != "":
+		return errors.New("-listen and -connect can't be used together")
+	case listen != "":
+		ln, err := net.Listen("tcp", listen)
+		if err != nil {
+			return err
+		}
+		defer ln.Close()
+		fmt.Printf("Waiting for a link cable at %v\n", ln.Addr())
+		_, err = serial.Accept(ln, v.Serial())
+		return err
+	case connect != "":
+		_, err := serial.Dial(connect, v.Serial())
+		return err
+	}
+	return nil
+}
+
+// plugPrinter plugs a printer into v's serial port that saves its pages to
+// dir, and returns it. With no dir it returns nil and leaves the port alone.
+func plugPrinter(v *vm.VM, dir string) *printer.Printer {
+	if dir == "" {
+		return nil
+	}
+	p := printer.New(printer.WithOutputDir(dir))
+	v.Serial().Connect(p)
+	return p
+}
+
+// bootOptions returns the VM options for the -boot and -model flags.
+func bootOptions(boot, hw string) ([]vm.Option, error) {
+	var opts []vm.Option
+	if boot != "" {
+		rom, err := vm.LoadBootROM(boot)
+		if err != nil {
+			return nil, err
+		}
+		opts = append(opts, vm.WithBootROM(rom))
+	}
+	if hw != "" {
+		m, ok := model.Parse(hw)
+		if !ok {
+			return nil, fmt.Errorf("unknown model %q", hw)
+		}
+		opts = append(opts, vm.WithModel(m))
+	}
+	return opts, nil
+}
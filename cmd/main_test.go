@@ -1,9 +1,132 @@
 package main
 
 import (
+	"io/ioutil"
+	"net"
+	"os"
+	"path/filepath"
 	"testing"
+	"time"
+
+	"github.com/vsinha/vm/internal/interrupts"
+	"github.com/vsinha/vm/internal/memory"
+	"github.com/vsinha/vm/internal/serial"
+	"github.com/vsinha/vm/internal/vm"
 )
 
 func TestInit(t *testing.T) {
 	main()
 }
+
+func TestLink(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	addr := ln.Addr().String()
+	ln.Close()
+
+	a := vm.New(memory.Memory{0x18, 0xFE}) // JR -2
+	b := vm.New(memory.Memory{0x18, 0xFE})
+	listened := make(chan error, 1)
+	go func() {
+		listened <- link(a, addr, "")
+	}()
+	// Keep trying until the other side is listening.
+	for i := 0; ; i++ {
+		if err = link(b, "", addr); err == nil || i == 100 {
+			break
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	if err != nil {
+		t.Fatalf("link() with -connect error: %v", err)
+	}
+	if err := <-listened; err != nil {
+		t.Fatalf("link() with -listen error: %v", err)
+	}
+
+	// b waits for a byte from a, which drives the clock.
+	b.Mem().Write8(serial.SB, 0x34)
+	b.Mem().Write8(serial.SC, 0x80)
+	a.Mem().Write8(serial.SB, 0x12)
+	a.Mem().Write8(serial.SC, 0x81)
+
+	// Run both the way the command does, for long enough to transfer the
+	// byte many times over.
+	stop := make(chan struct{})
+	done := make(chan error, 2)
+	for _, v := range []*vm.VM{a, b} {
+		v := v
+		go func() {
+			done <- v.Run(stop)
+		}()
+	}
+	time.Sleep(200 * time.Millisecond)
+	close(stop)
+	for i := 0; i < 2; i++ {
+		if err := <-done; err != nil {
+			t.Fatalf("Run() error: %v", err)
+		}
+	}
+
+	for _, tc := range []struct {
+		name string
+		v    *vm.VM
+		want uint8
+	}{
+		{"listening", a, 0x34},
+		{"connecting", b, 0x12},
+	} {
+		if got := tc.v.Mem().Read8(serial.SB); got != tc.want {
+			t.Errorf("%s: SB = %02X, want %02X", tc.name, got, tc.want)
+		}
+		if got := tc.v.Mem().Read8(interrupts.FlagAddr); got&uint8(interrupts.Serial) == 0 {
+			t.Errorf("%s: IF = %02X, want the serial interrupt requested", tc.name, got)
+		}
+	}
+}
+
+// packet encodes a printer packet, including the two bytes sent for the
+// replies.
+func packet(cmd uint8, data []byte) []byte {
+	body := append([]byte{cmd, 0x00, uint8(len(data)), uint8(len(data) >> 8)}, data...)
+	var sum uint16
+	for _, b := range body {
+		sum += uint16(b)
+	}
+	p := append([]byte{0x88, 0x33}, body...)
+	return append(p, uint8(sum), uint8(sum>>8), 0x00, 0x00)
+}
+
+func TestPrinter(t *testing.T) {
+	dir, err := ioutil.TempDir("", "printer")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.RemoveAll(dir) })
+
+	if p := plugPrinter(vm.New(nil), ""); p != nil {
+		t.Errorf("plugPrinter() with no dir = %v, want nil", p)
+	}
+
+	v := vm.New(memory.Memory{0x18, 0xFE}) // JR -2
+	p := plugPrinter(v, dir)
+	// Two rows of tiles, then print them with no margins.
+	pkts := packet(0x04, make([]byte, 0x280))
+	pkts = append(pkts, packet(0x02, []byte{0x01, 0x00, 0xE4, 0x40})...)
+	for _, b := range pkts {
+		v.Mem().Write8(serial.SB, b)
+		v.Mem().Write8(serial.SC, 0x81)
+		if err := v.RunUntil(v.Cycles() + 8*512); err != nil {
+			t.Fatalf("RunUntil() error: %v", err)
+		}
+	}
+
+	if err := p.Err(); err != nil {
+		t.Fatalf("printer Err() = %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "print001.png")); err != nil {
+		t.Errorf("printed page not saved: %v", err)
+	}
+}
@@ -0,0 +1,204 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/vsinha/vm/internal/apu"
+	"github.com/vsinha/vm/internal/cartridge"
+	"github.com/vsinha/vm/internal/joypad"
+	"github.com/vsinha/vm/internal/vm"
+	"github.com/vsinha/vm/internal/wav"
+)
+
+// recording is a WAV file being written from one of the APU's outputs.
+type recording struct {
+	f   *os.File
+	w   *wav.Writer
+	src io.Reader
+}
+
+func createRecording(path string, rate int, src io.Reader) (*recording, error) {
+	f, err := os.Create(path)
+	if err != nil {
+		return nil, err
+	}
+	w, err := wav.NewWriter(f, rate, 2)
+	if err != nil {
+		f.Close()
+		return nil, err
+	}
+	return &recording{f: f, w: w, src: src}, nil
+}
+
+// drain copies the audio waiting in the APU to the file.
+func (r *recording) drain(buf []byte) error {
+	for {
+		n, err := r.src.Read(buf)
+		if n == 0 || err != nil {
+			return err
+		}
+		if _, err := r.w.Write(buf[:n]); err != nil {
+			return err
+		}
+	}
+}
+
+func (r *recording) close() error {
+	err := r.w.Close()
+	if cerr := r.f.Close(); err == nil {
+		err = cerr
+	}
+	return err
+}
+
+// channelPath returns the path of the file recording a single channel next to
+// the mixed recording at path: out.wav becomes out.ch1.wav.
+func channelPath(path string, channel int) string {
+	ext := filepath.Ext(path)
+	return fmt.Sprintf("%s.ch%d%s", strings.TrimSuffix(path, ext), channel, ext)
+}
+
+// recordingTime is what the cartridge's real-time clock always reads while
+// recording.
+var recordingTime = time.Unix(0, 0)
+
+// record runs a cartridge without a display or sound device for a set number
+// of frames or cycles, writing the audio it plays to WAV files. An input
+// script can play the buttons, and a printer can be plugged in. So that every
+// run of a game records the same audio, its .sav file is neither read nor
+// written and its clock is stopped.
+func record(args []string) error {
+	fs := flag.NewFlagSet("record", flag.ContinueOnError)
+	out := fs.String("o", "out.wav", "`file` to write the mixed audio to")
+	channels := fs.Bool("channels", false, "also write each channel to its own file, out.ch1.wav to out.ch4.wav")
+	frames := fs.Uint64("frames", 0, "stop after `n` frames of 70224 cycles")
+	cycles := fs.Uint64("cycles", 0, "stop after `n` cycles")
+	input := fs.String("input", "", "input script `file` of frame numbers and the buttons held from then on")
+	printTo := fs.String("printer", "", "connect a Game Boy Printer that writes its pages to `dir` as PNGs")
+	boot := fs.String("boot", "", "run the DMG or CGB boot ROM in `file` before the game")
+	hw := fs.String("model", "", "emulate `model`: DMG0, DMG, MGB, SGB, CGB or AGB (default CGB for colour games, DMG otherwise)")
+	trace := fs.Bool("trace", false, "print every instruction as it runs")
+	rate := fs.Int("rate", apu.DefaultSampleRate, "sample `rate` in Hz")
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "usage: %s record [flags] rom.gb\n", os.Args[0])
+		fs.PrintDefaults()
+	}
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() != 1 || (*frames == 0 && *cycles == 0) {
+		fs.Usage()
+		return errors.New("record needs a ROM and a -frames or -cycles limit")
+	}
+
+	limit := *cycles
+	if f := *frames * vm.CyclesPerFrame; *frames > 0 && (limit == 0 || f < limit) {
+		limit = f
+	}
+
+	var script joypad.Script
+	if *input != "" {
+		f, err := os.Open(*input)
+		if err != nil {
+			return err
+		}
+		script, err = joypad.ParseScript(f)
+		f.Close()
+		if err != nil {
+			return fmt.Errorf("reading %q: %w", *input, err)
+		}
+	}
+
+	c, err := cartridge.Load(fs.Arg(0),
+		cartridge.WithSavePath(""),
+		cartridge.WithClock(func() time.Time { return recordingTime }))
+	if err != nil {
+		return fmt.Errorf("unable to load cartridge: %v", err)
+	}
+	if err := c.Warning(); err != nil {
+		fmt.Printf("warning: %v\n", err)
+	}
+	opts, err := bootOptions(*boot, *hw)
+	if err != nil {
+		return err
+	}
+	opts = append(opts, vm.WithSampleRate(*rate))
+	if *channels {
+		opts = append(opts, vm.WithChannelOutputs())
+	}
+	if *trace {
+		opts = append(opts, vm.WithTrace())
+	}
+	v := vm.New(c, opts...)
+	running := true
+	defer func() {
+		if running {
+			v.Close()
+		}
+	}()
+	p := plugPrinter(v, *printTo)
+
+	mixed, err := createRecording(*out, *rate, v.APU())
+	if err != nil {
+		return err
+	}
+	recordings := []*recording{mixed}
+	if *channels {
+		for i := 1; i <= 4; i++ {
+			r, err := createRecording(channelPath(*out, i), *rate, v.APU().Channel(i))
+			if err != nil {
+				return err
+			}
+			recordings = append(recordings, r)
+		}
+	}
+	defer func() {
+		for _, r := range recordings {
+			r.close()
+		}
+	}()
+
+	// Drain the audio every frame, well before the APU starts dropping it.
+	buf := make([]byte, 4096)
+	var runErr error
+	for frame, next := uint64(0), uint64(0); next < limit && runErr == nil; frame++ {
+		if script != nil {
+			v.Joypad().Set(script.Buttons(frame))
+		}
+		next += vm.CyclesPerFrame
+		if next > limit {
+			next = limit
+		}
+		runErr = v.RunUntil(next)
+		for _, r := range recordings {
+			if err := r.drain(buf); err != nil {
+				return err
+			}
+		}
+	}
+	if runErr != nil {
+		return fmt.Errorf("virtual machine error: %v\n%v", runErr, v.Reg())
+	}
+
+	for _, r := range recordings {
+		if err := r.close(); err != nil {
+			return err
+		}
+	}
+	recordings = nil
+	running = false
+	if err := v.Close(); err != nil {
+		return err
+	}
+	if p != nil {
+		return p.Err()
+	}
+	return nil
+}
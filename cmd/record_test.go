@@ -0,0 +1,94 @@
+package main
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// clockROM returns an MBC3 cartridge with a clock and battery-backed RAM. It
+// enables its RAM, writes to it and then loops forever.
+func clockROM() []byte {
+	rom := make([]byte, 0x8000)
+	copy(rom[0x0100:], []byte{
+		0x31, 0x00, 0x02, // LD SP, 0200
+		0xC1,             // POP BC
+		0x31, 0x02, 0x00, // LD SP, 0002
+		0xC5,             // PUSH BC, enabling RAM
+		0x31, 0x02, 0xA0, // LD SP, A002
+		0xC5,       // PUSH BC, writing RAM
+		0x18, 0xFE, // JR -2
+	})
+	rom[0x0200], rom[0x0201] = 0x0A, 0x0A // Popped into BC.
+	copy(rom[0x0134:], "CLOCK")
+	rom[0x0147] = 0x10 // MBC3+TIMER+RAM+BATTERY
+	rom[0x0149] = 0x02 // 8 KiB RAM
+
+	var x uint8
+	for _, b := range rom[0x0134:0x014D] {
+		x = x - b - 1
+	}
+	rom[0x014D] = x
+	var sum uint16
+	for _, b := range rom {
+		sum += uint16(b)
+	}
+	rom[0x014E], rom[0x014F] = byte(sum>>8), byte(sum)
+	return rom
+}
+
+func TestRecordIsReproducible(t *testing.T) {
+	dir, err := ioutil.TempDir("", "record")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.RemoveAll(dir) })
+
+	romPath := filepath.Join(dir, "game.gb")
+	savPath := filepath.Join(dir, "game.sav")
+	if err := ioutil.WriteFile(romPath, clockROM(), 0644); err != nil {
+		t.Fatal(err)
+	}
+	save := bytes.Repeat([]byte{0x42}, 8*1024)
+	if err := ioutil.WriteFile(savPath, save, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	var wavs [2][]byte
+	for i := range wavs {
+		out := filepath.Join(dir, "out.wav")
+		if err := record([]string{"-frames", "2", "-o", out, romPath}); err != nil {
+			t.Fatalf("record() error: %v", err)
+		}
+		if wavs[i], err = ioutil.ReadFile(out); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.Remove(out); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if !bytes.Equal(wavs[0], wavs[1]) {
+		t.Errorf("two recordings of the same ROM differ")
+	}
+
+	got, err := ioutil.ReadFile(savPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(got, save) {
+		t.Errorf("recording changed the .sav file")
+	}
+	files, err := ioutil.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(files) != 2 {
+		var names []string
+		for _, f := range files {
+			names = append(names, f.Name())
+		}
+		t.Errorf("files next to the ROM = %q, want only the ROM and its save", names)
+	}
+}
@@ -0,0 +1,424 @@
+// Package apu implements the Game Boy's audio processing unit: two square wave
+// channels, one with a frequency sweep, a wave channel playing samples from
+// wave RAM, and a noise channel, mixed down to stereo.
+//
+// Audio comes out as 16-bit signed little-endian stereo PCM, read through
+// the APU's Read method.
+package apu
+
+import (
+	"io"
+	"math"
+
+	"github.com/vsinha/vm/internal/model"
+)
+
+// Register addresses.
+const (
+	NR10 = 0xFF10 // Channel 1 sweep.
+	NR11 = 0xFF11 // Channel 1 duty and length.
+	NR12 = 0xFF12 // Channel 1 envelope.
+	NR13 = 0xFF13 // Channel 1 frequency, low bits.
+	NR14 = 0xFF14 // Channel 1 frequency, high bits, length enable and trigger.
+	NR21 = 0xFF16 // Channel 2 duty and length.
+	NR22 = 0xFF17 // Channel 2 envelope.
+	NR23 = 0xFF18 // Channel 2 frequency, low bits.
+	NR24 = 0xFF19 // Channel 2 frequency, high bits, length enable and trigger.
+	NR30 = 0xFF1A // Channel 3 DAC enable.
+	NR31 = 0xFF1B // Channel 3 length.
+	NR32 = 0xFF1C // Channel 3 volume.
+	NR33 = 0xFF1D // Channel 3 frequency, low bits.
+	NR34 = 0xFF1E // Channel 3 frequency, high bits, length enable and trigger.
+	NR41 = 0xFF20 // Channel 4 length.
+	NR42 = 0xFF21 // Channel 4 envelope.
+	NR43 = 0xFF22 // Channel 4 LFSR frequency and width.
+	NR44 = 0xFF23 // Channel 4 length enable and trigger.
+	NR50 = 0xFF24 // Master volume.
+	NR51 = 0xFF25 // Panning.
+	NR52 = 0xFF26 // Power and channel status.
+
+	WaveRAMStart = 0xFF30
+	WaveRAMEnd   = 0xFF3F
+
+	PCM12 = 0xFF76 // CGB only: digital outputs of channels 1 and 2.
+	PCM34 = 0xFF77 // CGB only: digital outputs of channels 3 and 4.
+)
+
+// Clock is the rate at which the APU is ticked, in cycles per second.
+const Clock = 4194304
+
+// DefaultSampleRate is the sample rate used unless WithSampleRate says
+// otherwise.
+const DefaultSampleRate = 44100
+
+// frameSequencerCycles is the period of the 512 Hz frame sequencer, which
+// clocks the length counters, envelopes and sweep.
+const frameSequencerCycles = Clock / 512
+
+// readMasks are ORed into the registers from NR10 to NR52 when they're read.
+// Write only bits and unused registers read as 1s.
+var readMasks = [NR52 - NR10 + 1]uint8{
+	0x80, 0x3F, 0x00, 0xFF, 0xBF, // NR10-NR14
+	0xFF, 0x3F, 0x00, 0xFF, 0xBF, // Unused, NR21-NR24
+	0x7F, 0xFF, 0x9F, 0xFF, 0xBF, // NR30-NR34
+	0xFF, 0xFF, 0x00, 0x00, 0xBF, // Unused, NR41-NR44
+	0x00, 0x00, 0x70, // NR50-NR52
+}
+
+// APU is the audio processing unit. It implements memory.Device and should be
+// attached to NR10 through WaveRAMEnd, and on a CGB to PCM12 and PCM34.
+type APU struct {
+	model model.Model
+	regs  [NR52 - NR10 + 1]uint8
+	on    bool
+
+	ch1, ch2 square
+	ch3      wave
+	ch4      noise
+
+	// sequencer counts cycles towards the next frame sequencer step.
+	sequencer int
+	step      int
+
+	rate int
+	// t is the time of the current cycle, in output samples after the first
+	// sample not yet taken from the resamplers.
+	t        float64
+	highPass float64
+	mixed    stream
+	// channels holds the output of each channel on its own, when enabled by
+	// WithChannelOutputs.
+	channels []*stream
+}
+
+// Option changes how New sets up an APU.
+type Option func(*APU)
+
+// WithSampleRate sets the rate of the audio read from the APU, in samples per
+// second.
+func WithSampleRate(rate int) Option {
+	return func(a *APU) {
+		a.rate = rate
+	}
+}
+
+// WithModel sets the hardware the APU behaves like. The default is a DMG,
+// whose length counters keep working while the APU is powered off.
+func WithModel(m model.Model) Option {
+	return func(a *APU) {
+		a.model = m
+	}
+}
+
+// WithChannelOutputs keeps a separate output for each channel, read with
+// Channel, alongside the mixed one.
+func WithChannelOutputs() Option {
+	return func(a *APU) {
+		a.channels = []*stream{{}, {}, {}, {}}
+	}
+}
+
+// New returns an APU that is powered off.
+func New(opts ...Option) *APU {
+	a := &APU{rate: DefaultSampleRate}
+	for _, opt := range opts {
+		opt(a)
+	}
+	a.reset()
+	// The charge factor is per APU cycle on real hardware.
+	a.highPass = math.Pow(0.999958, float64(Clock)/float64(a.rate))
+	return a
+}
+
+// SampleRate returns the rate of the audio read from the APU.
+func (a *APU) SampleRate() int {
+	return a.rate
+}
+
+// reset clears the registers and channels, as powering off does. Wave RAM is
+// left alone, and so are the length counters on a DMG.
+func (a *APU) reset() {
+	a.regs = [len(a.regs)]uint8{}
+	ram := a.ch3.ram
+	lengths := [4]lengthCounter{{max: 64}, {max: 64}, {max: 256}, {max: 64}}
+	if !a.model.CGB() {
+		lengths = [4]lengthCounter{a.ch1.length, a.ch2.length, a.ch3.length, a.ch4.length}
+		for i, max := range []int{64, 64, 256, 64} {
+			lengths[i].max = max
+			lengths[i].enabled = false
+		}
+	}
+	a.ch1 = square{hasSweep: true, length: lengths[0]}
+	a.ch2 = square{length: lengths[1]}
+	a.ch3 = wave{length: lengths[2], ram: ram}
+	a.ch4 = noise{length: lengths[3]}
+}
+
+// Read8 implements memory.Device.
+func (a *APU) Read8(addr uint16) uint8 {
+	switch addr {
+	case PCM12:
+		return a.ch2.output()<<4 | a.ch1.output()
+	case PCM34:
+		return a.ch4.output()<<4 | a.ch3.output()
+	}
+	if addr >= WaveRAMStart {
+		return a.ch3.ram[addr-WaveRAMStart]
+	}
+	if addr > NR52 {
+		return 0xFF
+	}
+	if addr == NR52 {
+		val := readMasks[NR52-NR10]
+		if a.on {
+			val |= 0x80
+		}
+		for i, on := range []bool{a.ch1.on, a.ch2.on, a.ch3.on, a.ch4.on} {
+			if on {
+				val |= 1 << uint(i)
+			}
+		}
+		return val
+	}
+	return a.regs[addr-NR10] | readMasks[addr-NR10]
+}
+
+// Write8 implements memory.Device.
+func (a *APU) Write8(addr uint16, val uint8) {
+	switch {
+	case addr == PCM12 || addr == PCM34:
+		return
+	case addr >= WaveRAMStart:
+		a.ch3.ram[addr-WaveRAMStart] = val
+		return
+	case addr > NR52:
+		return
+	case addr == NR52:
+		a.setPower(val&0x80 != 0)
+		return
+	case !a.on:
+		// Everything but NR52 and wave RAM is read only while powered off,
+		// except on a DMG, where the length counters can still be loaded.
+		if !a.model.CGB() {
+			a.loadLength(addr, val)
+		}
+		return
+	}
+
+	a.regs[addr-NR10] = val
+	switch addr {
+	case NR10:
+		a.ch1.sweep.period = val >> 4 & 0x07
+		a.ch1.sweep.negate = val&0x08 != 0
+		a.ch1.sweep.shift = val & 0x07
+	case NR11, NR21:
+		a.square(addr).duty = val >> 6
+		a.loadLength(addr, val)
+	case NR12, NR22:
+		ch := a.square(addr)
+		ch.env.write(val)
+		ch.dac = val&0xF8 != 0
+		ch.on = ch.on && ch.dac
+	case NR13, NR23:
+		ch := a.square(addr)
+		ch.freq = ch.freq&0x700 | uint16(val)
+	case NR14, NR24:
+		ch := a.square(addr)
+		ch.freq = ch.freq&0xFF | uint16(val&0x07)<<8
+		ch.length.enabled = val&0x40 != 0
+		if val&0x80 != 0 {
+			ch.trigger()
+		}
+	case NR30:
+		a.ch3.dac = val&0x80 != 0
+		a.ch3.on = a.ch3.on && a.ch3.dac
+	case NR31:
+		a.loadLength(addr, val)
+	case NR32:
+		a.ch3.volume = val >> 5 & 0x03
+	case NR33:
+		a.ch3.freq = a.ch3.freq&0x700 | uint16(val)
+	case NR34:
+		a.ch3.freq = a.ch3.freq&0xFF | uint16(val&0x07)<<8
+		a.ch3.length.enabled = val&0x40 != 0
+		if val&0x80 != 0 {
+			a.ch3.trigger()
+		}
+	case NR41:
+		a.loadLength(addr, val)
+	case NR42:
+		a.ch4.env.write(val)
+		a.ch4.dac = val&0xF8 != 0
+		a.ch4.on = a.ch4.on && a.ch4.dac
+	case NR43:
+		a.ch4.shift = val >> 4
+		a.ch4.narrow = val&0x08 != 0
+		a.ch4.divisor = val & 0x07
+	case NR44:
+		a.ch4.length.enabled = val&0x40 != 0
+		if val&0x80 != 0 {
+			a.ch4.trigger()
+		}
+	}
+}
+
+// loadLength loads a channel's length counter from a write to its NRx1
+// register. Writes to any other register are ignored.
+func (a *APU) loadLength(addr uint16, val uint8) {
+	switch addr {
+	case NR11:
+		a.ch1.length.load(int(val & 0x3F))
+	case NR21:
+		a.ch2.length.load(int(val & 0x3F))
+	case NR31:
+		a.ch3.length.load(int(val))
+	case NR41:
+		a.ch4.length.load(int(val & 0x3F))
+	}
+}
+
+// square returns the square channel that the register at addr belongs to.
+func (a *APU) square(addr uint16) *square {
+	if addr < NR21 {
+		return &a.ch1
+	}
+	return &a.ch2
+}
+
+func (a *APU) setPower(on bool) {
+	switch {
+	case a.on && !on:
+		a.reset()
+	case !a.on && on:
+		a.sequencer = 0
+		a.step = 0
+	}
+	a.on = on
+}
+
+// Tick advances the APU by the given number of cycles and adds the audio they
+// produced to what's waiting to be read.
+func (a *APU) Tick(cycles uint) {
+	dt := float64(a.rate) / Clock
+	for ; cycles > 0; cycles-- {
+		if a.on {
+			a.sequencer++
+			if a.sequencer == frameSequencerCycles {
+				a.sequencer = 0
+				a.clockSequencer()
+			}
+			a.ch1.step()
+			a.ch2.step()
+			a.ch3.step()
+			a.ch4.step()
+		}
+
+		levels := a.mix()
+		var l, r float64
+		for i, lr := range levels {
+			l += lr[0]
+			r += lr[1]
+			if a.channels != nil {
+				a.channels[i].step(a.t, lr[0], lr[1])
+			}
+		}
+		a.mixed.step(a.t, l, r)
+		a.t += dt
+	}
+
+	n := int(a.t)
+	a.t -= float64(n)
+	max := a.rate * 4 * maxBuffered
+	a.mixed.flush(n, a.highPass, max)
+	for _, s := range a.channels {
+		s.flush(n, a.highPass, max)
+	}
+}
+
+// clockSequencer runs one step of the frame sequencer. Length counters are
+// clocked at 256 Hz, the sweep at 128 Hz and envelopes at 64 Hz.
+func (a *APU) clockSequencer() {
+	if a.step%2 == 0 {
+		if a.ch1.length.clock() {
+			a.ch1.on = false
+		}
+		if a.ch2.length.clock() {
+			a.ch2.on = false
+		}
+		if a.ch3.length.clock() {
+			a.ch3.on = false
+		}
+		if a.ch4.length.clock() {
+			a.ch4.on = false
+		}
+	}
+	if a.step == 2 || a.step == 6 {
+		a.ch1.clockSweep()
+	}
+	if a.step == 7 {
+		a.ch1.env.clock()
+		a.ch2.env.clock()
+		a.ch4.env.clock()
+	}
+	a.step = (a.step + 1) % 8
+}
+
+// dac converts a channel's digital output to an analog level between -1 and
+// 1. A channel whose DAC is off contributes nothing.
+func dac(on bool, digital uint8) float64 {
+	if !on {
+		return 0
+	}
+	return float64(digital)/7.5 - 1
+}
+
+// mix returns the left and right output of each channel, after panning and
+// the master volume. The mixed output is their sum, between -1 and 1.
+func (a *APU) mix() [4][2]float64 {
+	var levels [4][2]float64
+	if !a.on {
+		return levels
+	}
+	channels := [4]float64{
+		dac(a.ch1.dac, a.ch1.output()),
+		dac(a.ch2.dac, a.ch2.output()),
+		dac(a.ch3.dac, a.ch3.output()),
+		dac(a.ch4.dac, a.ch4.output()),
+	}
+
+	nr50 := a.regs[NR50-NR10]
+	nr51 := a.regs[NR51-NR10]
+	left := float64(nr50>>4&0x07+1) / 8 / 4
+	right := float64(nr50&0x07+1) / 8 / 4
+	for i, c := range channels {
+		if nr51&(0x10<<uint(i)) != 0 {
+			levels[i][0] = c * left
+		}
+		if nr51&(0x01<<uint(i)) != 0 {
+			levels[i][1] = c * right
+		}
+	}
+	return levels
+}
+
+// Read implements io.Reader, reading 16-bit signed little-endian stereo PCM
+// samples, left first. It never blocks: it returns whatever audio has been
+// produced since the last Read, which may be none.
+func (a *APU) Read(p []byte) (int, error) {
+	return a.mixed.Read(p)
+}
+
+// Channel returns a reader for the output of channel i, numbered 1 to 4, on
+// its own, in the same format as Read. The channel outputs add up to the
+// mixed one. It returns nil unless the APU was created WithChannelOutputs.
+func (a *APU) Channel(i int) io.Reader {
+	if a.channels == nil || i < 1 || i > len(a.channels) {
+		return nil
+	}
+	return a.channels[i-1]
+}
+
+// Buffered returns the number of bytes waiting to be read.
+func (a *APU) Buffered() int {
+	return len(a.mixed.out)
+}
@@ -0,0 +1,314 @@
+package apu_test
+
+import (
+	"encoding/binary"
+	"io"
+	"io/ioutil"
+	"testing"
+
+	"github.com/vsinha/vm/internal/apu"
+	"github.com/vsinha/vm/internal/model"
+)
+
+// powered returns an APU that has been switched on, with every channel at full
+// volume in both ears.
+func powered(opts ...apu.Option) *apu.APU {
+	a := apu.New(opts...)
+	a.Write8(apu.NR52, 0x80)
+	a.Write8(apu.NR50, 0x77)
+	a.Write8(apu.NR51, 0xFF)
+	return a
+}
+
+// readAll reads all the audio waiting in a and returns the left channel.
+func readAll(t *testing.T, a *apu.APU) []int16 {
+	t.Helper()
+	b, err := ioutil.ReadAll(limited{a})
+	if err != nil {
+		t.Fatalf("reading audio: %v", err)
+	}
+	var left []int16
+	for i := 0; i+4 <= len(b); i += 4 {
+		left = append(left, int16(binary.LittleEndian.Uint16(b[i:])))
+	}
+	return left
+}
+
+// limited turns the APU's never ending reader into one that stops at the end
+// of the audio produced so far.
+type limited struct {
+	a *apu.APU
+}
+
+func (l limited) Read(p []byte) (int, error) {
+	if l.a.Buffered() == 0 {
+		return 0, io.EOF
+	}
+	return l.a.Read(p)
+}
+
+func TestRegisters(t *testing.T) {
+	a := apu.New()
+	if got := a.Read8(apu.NR52); got != 0x70 {
+		t.Errorf("NR52 = %02X powered off, want 70", got)
+	}
+	a.Write8(apu.NR11, 0xFF)
+	if got := a.Read8(apu.NR11); got != 0x3F {
+		t.Errorf("NR11 = %02X after writing while powered off, want 3F", got)
+	}
+
+	a.Write8(apu.NR52, 0x80)
+	if got := a.Read8(apu.NR52); got != 0xF0 {
+		t.Errorf("NR52 = %02X powered on, want F0", got)
+	}
+
+	tests := []struct {
+		addr       uint16
+		write, got uint8
+	}{
+		{apu.NR10, 0x00, 0x80},
+		{apu.NR11, 0x80, 0xBF},
+		{apu.NR12, 0xF3, 0xF3},
+		{apu.NR13, 0x12, 0xFF},
+		{apu.NR14, 0x40, 0xFF},
+		{apu.NR30, 0x00, 0x7F},
+		{apu.NR32, 0x60, 0xFF},
+		{apu.NR43, 0x5A, 0x5A},
+		{apu.NR50, 0x35, 0x35},
+		{apu.NR51, 0xA5, 0xA5},
+		{0xFF27, 0x00, 0xFF},
+	}
+	for _, test := range tests {
+		a.Write8(test.addr, test.write)
+		if got := a.Read8(test.addr); got != test.got {
+			t.Errorf("Read8(%04X) after writing %02X = %02X, want %02X", test.addr, test.write, got, test.got)
+		}
+	}
+
+	// Powering off clears everything but wave RAM.
+	a.Write8(apu.WaveRAMStart, 0x42)
+	a.Write8(apu.NR52, 0x00)
+	if got := a.Read8(apu.NR50); got != 0x00 {
+		t.Errorf("NR50 = %02X after powering off, want 00", got)
+	}
+	if got := a.Read8(apu.WaveRAMStart); got != 0x42 {
+		t.Errorf("wave RAM = %02X after powering off, want 42", got)
+	}
+}
+
+func TestChannelStatus(t *testing.T) {
+	tests := []struct {
+		name   string
+		writes [][2]uint16
+		cycles uint
+		want   uint8 // Bottom bits of NR52.
+	}{
+		{"trigger 1", [][2]uint16{{apu.NR12, 0xF0}, {apu.NR14, 0x80}}, 0, 0x01},
+		{"trigger 2", [][2]uint16{{apu.NR22, 0xF0}, {apu.NR24, 0x80}}, 0, 0x02},
+		{"trigger 3", [][2]uint16{{apu.NR30, 0x80}, {apu.NR34, 0x80}}, 0, 0x04},
+		{"trigger 4", [][2]uint16{{apu.NR42, 0xF0}, {apu.NR44, 0x80}}, 0, 0x08},
+		{"DAC off", [][2]uint16{{apu.NR12, 0x00}, {apu.NR14, 0x80}}, 0, 0x00},
+		{"DAC turned off", [][2]uint16{{apu.NR22, 0xF0}, {apu.NR24, 0x80}, {apu.NR22, 0x07}}, 0, 0x00},
+		{"length runs out", [][2]uint16{{apu.NR21, 0x3F}, {apu.NR22, 0xF0}, {apu.NR24, 0xC0}}, 8192, 0x00},
+		{"length disabled", [][2]uint16{{apu.NR21, 0x3F}, {apu.NR22, 0xF0}, {apu.NR24, 0x80}}, 8192, 0x02},
+		{"wave length", [][2]uint16{{apu.NR30, 0x80}, {apu.NR31, 0xFE}, {apu.NR34, 0xC0}}, 8192 * 2, 0x04},
+		{"wave length runs out", [][2]uint16{{apu.NR30, 0x80}, {apu.NR31, 0xFE}, {apu.NR34, 0xC0}}, 8192 * 3, 0x00},
+		{"sweep overflow on trigger", [][2]uint16{{apu.NR10, 0x01}, {apu.NR12, 0xF0}, {apu.NR13, 0xFF}, {apu.NR14, 0x87}}, 0, 0x00},
+		{"sweep overflow", [][2]uint16{{apu.NR10, 0x11}, {apu.NR12, 0xF0}, {apu.NR13, 0x00}, {apu.NR14, 0x86}}, 8192 * 8, 0x00},
+		{"sweep down", [][2]uint16{{apu.NR10, 0x19}, {apu.NR12, 0xF0}, {apu.NR13, 0x00}, {apu.NR14, 0x86}}, 8192 * 8, 0x01},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			a := powered()
+			for _, w := range test.writes {
+				a.Write8(w[0], uint8(w[1]))
+			}
+			a.Tick(test.cycles)
+			if got := a.Read8(apu.NR52) & 0x0F; got != test.want {
+				t.Errorf("NR52 channels = %X, want %X", got, test.want)
+			}
+		})
+	}
+}
+
+func TestModels(t *testing.T) {
+	tests := []struct {
+		model model.Model
+		want  uint8 // Bottom bits of NR52.
+	}{
+		// A DMG loads the length while powered off, so the channel stops at
+		// the first length clock. A CGB ignores the write and plays on.
+		{model.DMG, 0x00},
+		{model.CGB, 0x02},
+	}
+	for _, test := range tests {
+		t.Run(test.model.String(), func(t *testing.T) {
+			a := apu.New(apu.WithModel(test.model))
+			a.Write8(apu.NR21, 0x3F)
+			a.Write8(apu.NR52, 0x80)
+			a.Write8(apu.NR22, 0xF0)
+			a.Write8(apu.NR24, 0xC0)
+			a.Tick(8192)
+			if got := a.Read8(apu.NR52) & 0x0F; got != test.want {
+				t.Errorf("NR52 channels = %X, want %X", got, test.want)
+			}
+		})
+	}
+}
+
+func TestPCM(t *testing.T) {
+	a := powered(apu.WithModel(model.CGB))
+	// Channel 2 at full volume with a 75% duty cycle, and channel 3 playing
+	// 0xA from wave RAM.
+	a.Write8(apu.NR21, 0xC0)
+	a.Write8(apu.NR22, 0xF0)
+	a.Write8(apu.NR23, 0xFF)
+	a.Write8(apu.NR24, 0x87)
+	for addr := uint16(apu.WaveRAMStart); addr <= apu.WaveRAMEnd; addr++ {
+		a.Write8(addr, 0xAA)
+	}
+	a.Write8(apu.NR30, 0x80)
+	a.Write8(apu.NR32, 0x20)
+	a.Write8(apu.NR34, 0x80)
+
+	seen := map[uint8]bool{}
+	for i := 0; i < 64; i++ {
+		a.Tick(4)
+		seen[a.Read8(apu.PCM12)] = true
+		if got := a.Read8(apu.PCM34); got != 0x0A && got != 0x00 {
+			t.Fatalf("PCM34 = %02X, want 0A or 00", got)
+		}
+	}
+	if !seen[0xF0] {
+		t.Errorf("PCM12 never read F0 with channel 2 high")
+	}
+}
+
+// crossings counts how many times samples goes from negative to positive.
+func crossings(samples []int16) int {
+	n := 0
+	for i := 1; i < len(samples); i++ {
+		if samples[i-1] < 0 && samples[i] >= 0 {
+			n++
+		}
+	}
+	return n
+}
+
+func TestSquareTone(t *testing.T) {
+	for _, rate := range []int{44100, 22050} {
+		a := powered(apu.WithSampleRate(rate))
+		// 131072 / (2048 - 1798) = 524.288 Hz.
+		a.Write8(apu.NR21, 0x80)
+		a.Write8(apu.NR22, 0xF0)
+		a.Write8(apu.NR23, 0x06)
+		a.Write8(apu.NR24, 0x87)
+		a.Tick(apu.Clock)
+
+		samples := readAll(t, a)
+		if len(samples) < rate-1 || len(samples) > rate {
+			t.Errorf("%d Hz: got %d samples for a second of audio", rate, len(samples))
+		}
+		if got := crossings(samples); got < 520 || got > 528 {
+			t.Errorf("%d Hz: got %d cycles of a 524 Hz tone", rate, got)
+		}
+	}
+}
+
+func TestBandLimited(t *testing.T) {
+	a := powered()
+	// 131072 / (2048 - 2047) = 131072 Hz, far above what 44100 Hz can hold.
+	// Without band limiting it would alias into audible noise.
+	a.Write8(apu.NR21, 0x80)
+	a.Write8(apu.NR22, 0xF0)
+	a.Write8(apu.NR23, 0xFF)
+	a.Write8(apu.NR24, 0x87)
+	a.Tick(apu.Clock)
+
+	samples := readAll(t, a)
+	for i, s := range samples[len(samples)/2:] {
+		if s > 1000 || s < -1000 {
+			t.Fatalf("sample %d = %d, want silence", len(samples)/2+i, s)
+		}
+	}
+}
+
+func TestReadWholeFrames(t *testing.T) {
+	a := powered()
+	a.Tick(apu.Clock / 100)
+	buf := make([]byte, 7)
+	if n, err := a.Read(buf); n != 4 || err != nil {
+		t.Errorf("Read() into 7 bytes = %d, %v, want 4, nil", n, err)
+	}
+}
+
+// pcm decodes 16-bit little-endian stereo PCM into left and right samples.
+func pcm(b []byte) (left, right []int16) {
+	for i := 0; i+4 <= len(b); i += 4 {
+		left = append(left, int16(binary.LittleEndian.Uint16(b[i:])))
+		right = append(right, int16(binary.LittleEndian.Uint16(b[i+2:])))
+	}
+	return left, right
+}
+
+func TestChannelOutputs(t *testing.T) {
+	if apu.New().Channel(1) != nil {
+		t.Errorf("Channel(1) without WithChannelOutputs is not nil")
+	}
+
+	a := powered(apu.WithChannelOutputs())
+	// Channel 1 on the left only, channel 2 on the right only.
+	a.Write8(apu.NR51, 0x12)
+	a.Write8(apu.NR11, 0x80)
+	a.Write8(apu.NR12, 0xF0)
+	a.Write8(apu.NR13, 0x06)
+	a.Write8(apu.NR14, 0x87)
+	a.Write8(apu.NR21, 0x80)
+	a.Write8(apu.NR22, 0xF0)
+	a.Write8(apu.NR23, 0x83)
+	a.Write8(apu.NR24, 0x87)
+	a.Tick(apu.Clock / 4)
+
+	var outputs [5][2][]int16
+	for i := 0; i <= 4; i++ {
+		r := io.Reader(a)
+		if i > 0 {
+			r = a.Channel(i)
+		}
+		buf := make([]byte, apu.DefaultSampleRate*4)
+		n, _ := r.Read(buf)
+		outputs[i][0], outputs[i][1] = pcm(buf[:n])
+	}
+
+	for _, tc := range []struct {
+		channel, side int
+		crossings     int
+	}{
+		{1, 0, 131}, // 524 Hz.
+		{1, 1, 0},
+		{2, 0, 0},
+		{2, 1, 262}, // 1049 Hz.
+		{3, 0, 0},
+		{4, 1, 0},
+	} {
+		got := crossings(outputs[tc.channel][tc.side])
+		if got < tc.crossings-4 || got > tc.crossings+4 {
+			t.Errorf("channel %d side %d: got %d crossings, want about %d", tc.channel, tc.side, got, tc.crossings)
+		}
+	}
+
+	// The channels add up to the mixed output.
+	mixed := outputs[0]
+	for side := 0; side < 2; side++ {
+		for i := range mixed[side] {
+			sum := 0
+			for ch := 1; ch <= 4; ch++ {
+				sum += int(outputs[ch][side][i])
+			}
+			if d := sum - int(mixed[side][i]); d > 4 || d < -4 {
+				t.Fatalf("side %d sample %d: channels add up to %d, mixed is %d", side, i, sum, mixed[side][i])
+			}
+		}
+	}
+}
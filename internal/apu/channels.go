@@ -0,0 +1,266 @@
+package apu
+
+// lengthCounter is a channel's length counter, which turns the channel off when it
+// runs out if it's enabled.
+type lengthCounter struct {
+	counter int
+	max     int // 64, or 256 for the wave channel.
+	enabled bool
+}
+
+// load sets the counter from the length register value.
+func (l *lengthCounter) load(val int) {
+	l.counter = l.max - val
+}
+
+// trigger refills an empty counter.
+func (l *lengthCounter) trigger() {
+	if l.counter == 0 {
+		l.counter = l.max
+	}
+}
+
+// clock counts down, returning true when the counter runs out.
+func (l *lengthCounter) clock() bool {
+	if !l.enabled || l.counter == 0 {
+		return false
+	}
+	l.counter--
+	return l.counter == 0
+}
+
+// envelope fades a channel's volume up or down.
+type envelope struct {
+	initial  uint8
+	increase bool
+	period   uint8
+	volume   uint8
+	timer    uint8
+}
+
+// write sets the envelope from NRx2.
+func (e *envelope) write(val uint8) {
+	e.initial = val >> 4
+	e.increase = val&0x08 != 0
+	e.period = val & 0x07
+}
+
+func (e *envelope) trigger() {
+	e.volume = e.initial
+	e.timer = e.period
+}
+
+func (e *envelope) clock() {
+	if e.period == 0 {
+		return
+	}
+	e.timer--
+	if e.timer > 0 {
+		return
+	}
+	e.timer = e.period
+	switch {
+	case e.increase && e.volume < 15:
+		e.volume++
+	case !e.increase && e.volume > 0:
+		e.volume--
+	}
+}
+
+// dutyPatterns are the waveforms of the square channels, one bit per step.
+var dutyPatterns = [4]uint8{
+	0x01, // 12.5%
+	0x81, // 25%
+	0x87, // 50%
+	0x7E, // 75%
+}
+
+// square is one of the two square wave channels. Channel 1 also has a
+// frequency sweep.
+type square struct {
+	on   bool
+	dac  bool
+	duty uint8
+	freq uint16 // 11 bits.
+	// timer counts down the cycles until the next step of the waveform.
+	timer  int
+	pos    uint8
+	length lengthCounter
+	env    envelope
+
+	hasSweep bool
+	sweep    sweep
+}
+
+// sweep periodically raises or lowers channel 1's frequency.
+type sweep struct {
+	period  uint8
+	negate  bool
+	shift   uint8
+	timer   uint8
+	shadow  uint16
+	enabled bool
+}
+
+func (s *square) trigger() {
+	s.on = s.dac
+	s.length.trigger()
+	s.timer = (2048 - int(s.freq)) * 4
+	s.env.trigger()
+
+	if s.hasSweep {
+		sw := &s.sweep
+		sw.shadow = s.freq
+		sw.timer = sw.period
+		if sw.timer == 0 {
+			sw.timer = 8
+		}
+		sw.enabled = sw.period != 0 || sw.shift != 0
+		if sw.shift != 0 {
+			s.sweepFrequency()
+		}
+	}
+}
+
+// sweepFrequency computes the next frequency of the sweep, turning the channel
+// off if it overflows 11 bits.
+func (s *square) sweepFrequency() uint16 {
+	sw := &s.sweep
+	delta := sw.shadow >> sw.shift
+	f := sw.shadow + delta
+	if sw.negate {
+		f = sw.shadow - delta
+	}
+	if f > 2047 {
+		s.on = false
+	}
+	return f
+}
+
+func (s *square) clockSweep() {
+	sw := &s.sweep
+	sw.timer--
+	if sw.timer > 0 {
+		return
+	}
+	sw.timer = sw.period
+	if sw.timer == 0 {
+		sw.timer = 8
+	}
+	if !sw.enabled || sw.period == 0 {
+		return
+	}
+
+	f := s.sweepFrequency()
+	if f <= 2047 && sw.shift != 0 {
+		sw.shadow = f
+		s.freq = f
+		// The new frequency is checked for overflow straight away too.
+		s.sweepFrequency()
+	}
+}
+
+func (s *square) step() {
+	s.timer--
+	if s.timer <= 0 {
+		s.timer = (2048 - int(s.freq)) * 4
+		s.pos = (s.pos + 1) & 7
+	}
+}
+
+// output returns the channel's digital output, 0-15.
+func (s *square) output() uint8 {
+	if !s.on || dutyPatterns[s.duty]>>(7-s.pos)&1 == 0 {
+		return 0
+	}
+	return s.env.volume
+}
+
+// wave is the channel that plays 32 4-bit samples from wave RAM.
+type wave struct {
+	on     bool
+	dac    bool
+	volume uint8 // The NR32 volume code: mute, 100%, 50% or 25%.
+	freq   uint16
+	timer  int
+	pos    uint8
+	length lengthCounter
+	ram    [16]uint8
+}
+
+func (w *wave) trigger() {
+	w.on = w.dac
+	w.length.trigger()
+	w.timer = (2048 - int(w.freq)) * 2
+	w.pos = 0
+}
+
+func (w *wave) step() {
+	w.timer--
+	if w.timer <= 0 {
+		w.timer = (2048 - int(w.freq)) * 2
+		w.pos = (w.pos + 1) & 31
+	}
+}
+
+func (w *wave) output() uint8 {
+	if !w.on || w.volume == 0 {
+		return 0
+	}
+	sample := w.ram[w.pos/2]
+	if w.pos%2 == 0 {
+		sample >>= 4
+	}
+	return (sample & 0x0F) >> (w.volume - 1)
+}
+
+// noiseDivisors are the base periods of the noise channel, selected by the
+// bottom bits of NR43.
+var noiseDivisors = [8]int{8, 16, 32, 48, 64, 80, 96, 112}
+
+// noise is the channel that plays pseudo-random noise from a linear feedback
+// shift register.
+type noise struct {
+	on      bool
+	dac     bool
+	shift   uint8
+	narrow  bool // 7-bit LFSR instead of 15-bit.
+	divisor uint8
+	timer   int
+	lfsr    uint16
+	length  lengthCounter
+	env     envelope
+}
+
+func (n *noise) period() int {
+	return noiseDivisors[n.divisor] << n.shift
+}
+
+func (n *noise) trigger() {
+	n.on = n.dac
+	n.length.trigger()
+	n.timer = n.period()
+	n.env.trigger()
+	n.lfsr = 0x7FFF
+}
+
+func (n *noise) step() {
+	n.timer--
+	if n.timer > 0 {
+		return
+	}
+	n.timer = n.period()
+
+	bit := (n.lfsr ^ n.lfsr>>1) & 1
+	n.lfsr = n.lfsr>>1 | bit<<14
+	if n.narrow {
+		n.lfsr = n.lfsr&^(1<<6) | bit<<6
+	}
+}
+
+func (n *noise) output() uint8 {
+	if !n.on || n.lfsr&1 != 0 {
+		return 0
+	}
+	return n.env.volume
+}
@@ -0,0 +1,54 @@
+package apu
+
+import "math"
+
+// maxBuffered is how much audio, in seconds, is kept for reading. Beyond that
+// the oldest audio is dropped.
+const maxBuffered = 1
+
+// stream turns the analog levels of an output into PCM for reading: it
+// resamples them, removes the DC offset and queues the result.
+type stream struct {
+	left, right resampler
+	// capacitor holds the charge of the high-pass filter on each side, which
+	// removes the DC offset the DACs add.
+	capacitor [2]float64
+	out       []byte
+	samples   []float64 // Scratch space for taking samples.
+}
+
+// step sets the left and right levels from time t, in output samples.
+func (s *stream) step(t, l, r float64) {
+	s.left.step(t, l)
+	s.right.step(t, r)
+}
+
+// flush takes n samples from the resamplers, high-pass filters them with the
+// given charge factor, converts them to 16-bit PCM and queues them. At most
+// max bytes are kept.
+func (s *stream) flush(n int, highPass float64, max int) {
+	s.samples = s.left.take(s.samples[:0], n)
+	s.samples = s.right.take(s.samples, n)
+	left, right := s.samples[:n], s.samples[n:]
+	for i := range left {
+		for ch, v := range [2]float64{left[i], right[i]} {
+			out := v - s.capacitor[ch]
+			s.capacitor[ch] = v - out*highPass
+			pcm := int16(math.Max(-1, math.Min(1, out)) * math.MaxInt16)
+			s.out = append(s.out, uint8(pcm), uint8(uint16(pcm)>>8))
+		}
+	}
+
+	if len(s.out) > max {
+		s.out = append(s.out[:0], s.out[len(s.out)-max:]...)
+	}
+}
+
+// Read implements io.Reader, reading 16-bit signed little-endian stereo PCM
+// samples, left first. It never blocks.
+func (s *stream) Read(p []byte) (int, error) {
+	// Only hand out whole frames of both sides.
+	n := copy(p[:len(p)&^3], s.out)
+	s.out = s.out[:copy(s.out, s.out[n:])]
+	return n, nil
+}
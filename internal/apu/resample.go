@@ -0,0 +1,91 @@
+package apu
+
+import "math"
+
+// The band-limited step kernel is kernelWidth output samples wide, and
+// precomputed at kernelPhases sub-sample offsets.
+const (
+	kernelWidth  = 16
+	kernelPhases = 64
+	// kernelCutoff is the cutoff of the kernel's low-pass filter, as a
+	// fraction of the output sample rate. Just under Nyquist.
+	kernelCutoff = 0.45
+)
+
+// kernel holds, for each phase, the band-limited impulse that is summed into
+// the output to make a band-limited step.
+var kernel = makeKernel()
+
+// makeKernel builds a Blackman windowed sinc for every phase, each normalized
+// to sum to 1 so steps keep their height.
+func makeKernel() [kernelPhases][kernelWidth]float64 {
+	var k [kernelPhases][kernelWidth]float64
+	for p := range k {
+		frac := float64(p) / kernelPhases
+		sum := 0.0
+		for i := range k[p] {
+			// Distance from the step, which sits between taps
+			// kernelWidth/2-1 and kernelWidth/2.
+			x := float64(i-(kernelWidth/2-1)) - frac
+			sinc := 1.0
+			if x != 0 {
+				sinc = math.Sin(2*math.Pi*kernelCutoff*x) / (2 * math.Pi * kernelCutoff * x)
+			}
+			// Blackman window over the width of the kernel.
+			n := (x + kernelWidth/2) / kernelWidth
+			window := 0.42 - 0.5*math.Cos(2*math.Pi*n) + 0.08*math.Cos(4*math.Pi*n)
+			k[p][i] = sinc * window
+			sum += k[p][i]
+		}
+		for i := range k[p] {
+			k[p][i] /= sum
+		}
+	}
+	return k
+}
+
+// resampler turns a signal that is constant between steps at arbitrary times
+// into samples at the output rate without aliasing. Rather than sampling the
+// signal, each change in it is added to the output as a band-limited step.
+//
+// Changes are buffered as impulses and integrated when samples are taken, so
+// adding one costs kernelWidth multiply-adds however many samples apart the
+// steps are.
+type resampler struct {
+	impulses []float64
+	level    float64 // The signal at the end of the last step.
+	sum      float64 // The integral of the impulses taken so far.
+}
+
+// step changes the signal to level at time t, in output samples from the
+// first sample that hasn't been taken.
+func (r *resampler) step(t float64, level float64) {
+	delta := level - r.level
+	if delta == 0 {
+		return
+	}
+	r.level = level
+
+	i := int(t)
+	phase := int((t - float64(i)) * kernelPhases)
+	if need := i + kernelWidth; need > len(r.impulses) {
+		r.impulses = append(r.impulses, make([]float64, need-len(r.impulses))...)
+	}
+	for k, v := range kernel[phase] {
+		r.impulses[i+k] += delta * v
+	}
+}
+
+// take appends n samples to out and drops them from the buffer. Steps are
+// delayed by kernelWidth/2 samples, to leave room for the start of the kernel.
+func (r *resampler) take(out []float64, n int) []float64 {
+	if n > len(r.impulses) {
+		r.impulses = append(r.impulses, make([]float64, n-len(r.impulses))...)
+	}
+	for _, v := range r.impulses[:n] {
+		r.sum += v
+		out = append(out, r.sum)
+	}
+	r.impulses = append(r.impulses[:0], r.impulses[n:]...)
+	return out
+}
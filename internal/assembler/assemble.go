@@ -3,21 +3,20 @@ package assembler
 import (
 	"fmt"
 
-	"github.com/vsinha/vm/internal/vm"
+	"github.com/vsinha/vm/internal/memory"
 )
 
-// Assemble assembles
-func Assemble(instructions []interface{}) (vm.Memory, error) {
-	empty := make(vm.Memory, 256)
+// Assemble assembles a program given as opcode and operand bytes into memory
+// that can be run by the VM.
+func Assemble(instructions []interface{}) (memory.Memory, error) {
+	empty := make(memory.Memory, 256)
 
 	for i, inst := range instructions {
 		switch v := inst.(type) {
-		case vm.Op:
-			empty[i] = int(v)
-		case int:
+		case byte:
 			empty[i] = v
-		case vm.Reg:
-			empty[i] = int(v)
+		case int:
+			empty[i] = byte(v)
 		default:
 			return nil, fmt.Errorf("Unknown type %T", inst)
 		}
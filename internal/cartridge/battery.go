@@ -0,0 +1,179 @@
+package cartridge
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// HasBattery reports whether cartridges of type t keep their RAM, and clock if
+// they have one, powered while the Game Boy is switched off.
+func (t Type) HasBattery() bool {
+	switch t {
+	case MBC1RAMBattery, MBC2Battery, ROMRAMBattery, MMM01RAMBattery,
+		MBC3TimerBattery, MBC3TimerRAMBattery, MBC3RAMBattery,
+		MBC5RAMBattery, MBC5RumbleRAMBattery, MBC7SensorRumbleRAMBattery,
+		HuC1RAMBattery:
+		return true
+	}
+	return false
+}
+
+// SavePath returns the .sav file the cartridge's RAM is saved to. It's empty
+// for cartridges without a battery, or that weren't loaded from disk.
+func (c *Cartridge) SavePath() string {
+	return c.savePath
+}
+
+// savePathFor returns the .sav file next to the ROM at romPath.
+func savePathFor(romPath string) string {
+	return strings.TrimSuffix(romPath, filepath.Ext(romPath)) + ".sav"
+}
+
+// loadSave restores the cartridge from its .sav file, if there is one yet.
+func (c *Cartridge) loadSave() error {
+	f, err := os.Open(c.savePath)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	if _, err := c.ReadFrom(f); err != nil {
+		return fmt.Errorf("reading %q: %w", c.savePath, err)
+	}
+	return nil
+}
+
+// Save writes the cartridge's RAM to its .sav file. It does nothing if the
+// cartridge has no save path.
+func (c *Cartridge) Save() error {
+	if c.savePath == "" {
+		return nil
+	}
+
+	// Write to a temporary file and rename it over the old save, so a crash
+	// half way through never leaves a truncated save behind.
+	f, err := ioutil.TempFile(filepath.Dir(c.savePath), filepath.Base(c.savePath)+".*")
+	if err != nil {
+		return err
+	}
+	// TempFile creates the file readable only by its owner. Give the save
+	// the old file's mode, or the usual 0644 for a new one.
+	mode := os.FileMode(0644)
+	if fi, err := os.Stat(c.savePath); err == nil {
+		mode = fi.Mode().Perm()
+	}
+	if err := f.Chmod(mode); err != nil {
+		f.Close()
+		os.Remove(f.Name())
+		return err
+	}
+	if _, err := c.WriteTo(f); err != nil {
+		f.Close()
+		os.Remove(f.Name())
+		return err
+	}
+	if err := f.Close(); err != nil {
+		os.Remove(f.Name())
+		return err
+	}
+	if err := os.Rename(f.Name(), c.savePath); err != nil {
+		os.Remove(f.Name())
+		return err
+	}
+
+	c.dirty = false
+	return nil
+}
+
+// Flush saves the cartridge's RAM if it has been written since it was last
+// saved, and returns any error from an earlier autosave. Call it when the
+// emulator shuts down.
+func (c *Cartridge) Flush() error {
+	err := c.autosaveErr
+	c.autosaveErr = nil
+	if c.dirty {
+		if saveErr := c.Save(); saveErr != nil {
+			err = saveErr
+		}
+	}
+	return err
+}
+
+// autosave is called when a game enables or disables its RAM. Games disable
+// RAM once they finish writing to it, so this is a good point to save what
+// they wrote. Write8 has no way to return errors, so they're kept for Flush.
+func (c *Cartridge) autosave() {
+	if !c.dirty {
+		return
+	}
+	if err := c.Save(); err != nil {
+		c.autosaveErr = err
+	}
+}
+
+// isRAMEnable reports whether a write to addr goes to the mapper's RAM enable
+// register.
+func (c *Cartridge) isRAMEnable(addr uint16) bool {
+	switch c.mapper.(type) {
+	case *romOnly:
+		return false
+	case *mbc2:
+		return addr < romBankSize && addr&0x0100 == 0
+	default:
+		return addr < 0x2000
+	}
+}
+
+// storesRAM reports whether a write to the external RAM region reaches the
+// cartridge's RAM, or its clock, rather than being dropped because the RAM is
+// disabled or missing.
+func (c *Cartridge) storesRAM() bool {
+	switch m := c.mapper.(type) {
+	case *romOnly:
+		return len(c.ram) > 0
+	case *mbc1:
+		return m.ramEnabled && len(c.ram) > 0
+	case *mbc2:
+		return m.ramEnabled && len(c.ram) > 0
+	case *mbc3:
+		if !m.ramEnabled {
+			return false
+		}
+		if m.ramBank >= rtcSeconds && m.ramBank <= rtcDaysHi {
+			return m.rtc != nil
+		}
+		return m.ramBank <= 0x03 && len(c.ram) > 0
+	case *mbc5:
+		return m.ramEnabled && len(c.ram) > 0
+	}
+	return false
+}
+
+// WriteTo implements io.WriterTo, writing the cartridge's RAM in .sav format.
+func (c *Cartridge) WriteTo(w io.Writer) (int64, error) {
+	b, err := c.MarshalBinary()
+	if err != nil {
+		return 0, err
+	}
+	n, err := w.Write(b)
+	return int64(n), err
+}
+
+// ReadFrom implements io.ReaderFrom, restoring the cartridge's RAM from .sav
+// data written by WriteTo or another emulator.
+func (c *Cartridge) ReadFrom(r io.Reader) (int64, error) {
+	var buf bytes.Buffer
+	n, err := buf.ReadFrom(r)
+	if err != nil {
+		return n, err
+	}
+	return n, c.UnmarshalBinary(buf.Bytes())
+}
@@ -0,0 +1,172 @@
+package cartridge_test
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/vsinha/vm/internal/cartridge"
+)
+
+// writeROM writes rom to a temporary directory and returns its path.
+func writeROM(t *testing.T, rom []byte) string {
+	t.Helper()
+	dir, err := ioutil.TempDir("", "cartridge")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.RemoveAll(dir) })
+
+	path := filepath.Join(dir, "game.gb")
+	if err := ioutil.WriteFile(path, rom, 0644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestBatterySave(t *testing.T) {
+	path := writeROM(t, makeROM("BATTERY", cartridge.MBC1RAMBattery, 0, 2, nil))
+	savePath := filepath.Join(filepath.Dir(path), "game.sav")
+
+	c, err := cartridge.Load(path)
+	if err != nil {
+		t.Fatalf("cartridge.Load() error: %v", err)
+	}
+	if got := c.SavePath(); got != savePath {
+		t.Errorf("SavePath() = %q, want %q", got, savePath)
+	}
+
+	// Nothing is saved until the game writes to RAM and then toggles it.
+	c.Write8(0x0000, 0x0A)
+	if _, err := os.Stat(savePath); !os.IsNotExist(err) {
+		t.Fatalf("save file exists before anything was written to RAM")
+	}
+	c.Write8(0xA000, 0x42)
+	c.Write8(0x0000, 0x00)
+
+	save, err := ioutil.ReadFile(savePath)
+	if err != nil {
+		t.Fatalf("reading the autosave: %v", err)
+	}
+	if len(save) != 8*1024 || save[0] != 0x42 {
+		t.Errorf("autosave is %d bytes starting %02X, want 8192 bytes starting 42", len(save), save[0])
+	}
+
+	// Writes after the last toggle are saved by Flush.
+	c.Write8(0x0000, 0x0A)
+	c.Write8(0xA001, 0x43)
+	if err := c.Flush(); err != nil {
+		t.Fatalf("Flush() error: %v", err)
+	}
+
+	// Loading the ROM again picks the save up.
+	c, err = cartridge.Load(path)
+	if err != nil {
+		t.Fatalf("cartridge.Load() error: %v", err)
+	}
+	c.Write8(0x0000, 0x0A)
+	if got := []uint8{c.Read8(0xA000), c.Read8(0xA001)}; !bytes.Equal(got, []uint8{0x42, 0x43}) {
+		t.Errorf("RAM after reloading = % X, want 42 43", got)
+	}
+}
+
+func TestDisabledRAMNotSaved(t *testing.T) {
+	path := writeROM(t, makeROM("BATTERY", cartridge.MBC1RAMBattery, 0, 2, nil))
+	c, err := cartridge.Load(path)
+	if err != nil {
+		t.Fatalf("cartridge.Load() error: %v", err)
+	}
+
+	// The write is dropped with RAM disabled, so toggling RAM and flushing
+	// have nothing to save.
+	c.Write8(0xA000, 0x42)
+	c.Write8(0x0000, 0x0A)
+	c.Write8(0x0000, 0x00)
+	if err := c.Flush(); err != nil {
+		t.Fatalf("Flush() error: %v", err)
+	}
+	if _, err := os.Stat(c.SavePath()); !os.IsNotExist(err) {
+		t.Errorf("save file written after a write to disabled RAM")
+	}
+}
+
+func TestSaveMode(t *testing.T) {
+	path := writeROM(t, makeROM("BATTERY", cartridge.MBC1RAMBattery, 0, 2, nil))
+	c, err := cartridge.Load(path)
+	if err != nil {
+		t.Fatalf("cartridge.Load() error: %v", err)
+	}
+
+	for _, want := range []os.FileMode{0644, 0640} {
+		if err := c.Save(); err != nil {
+			t.Fatalf("Save() error: %v", err)
+		}
+		fi, err := os.Stat(c.SavePath())
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := fi.Mode().Perm(); got != want {
+			t.Errorf("save file mode = %v, want %v", got, want)
+		}
+		// The next save keeps a mode the user chose.
+		if err := os.Chmod(c.SavePath(), 0640); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestNoBattery(t *testing.T) {
+	path := writeROM(t, makeROM("NO BATTERY", cartridge.MBC1RAM, 0, 2, nil))
+
+	c, err := cartridge.Load(path)
+	if err != nil {
+		t.Fatalf("cartridge.Load() error: %v", err)
+	}
+	if got := c.SavePath(); got != "" {
+		t.Errorf("SavePath() = %q, want \"\"", got)
+	}
+
+	c.Write8(0x0000, 0x0A)
+	c.Write8(0xA000, 0x42)
+	c.Write8(0x0000, 0x00)
+	if err := c.Flush(); err != nil {
+		t.Fatalf("Flush() error: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(filepath.Dir(path), "game.sav")); !os.IsNotExist(err) {
+		t.Errorf("save file written for a cartridge without a battery")
+	}
+}
+
+func TestWriteToReadFrom(t *testing.T) {
+	c, err := cartridge.New(makeROM("BATTERY", cartridge.MBC5RAMBattery, 0, 3, nil))
+	if err != nil {
+		t.Fatalf("cartridge.New() error: %v", err)
+	}
+	c.Write8(0x0000, 0x0A)
+	c.Write8(0x4000, 0x03)
+	c.Write8(0xBFFF, 0x42)
+
+	var buf bytes.Buffer
+	if n, err := c.WriteTo(&buf); err != nil || n != 32*1024 {
+		t.Fatalf("WriteTo() = %d, %v, want %d, nil", n, err, 32*1024)
+	}
+
+	restored, err := cartridge.New(makeROM("BATTERY", cartridge.MBC5RAMBattery, 0, 3, nil))
+	if err != nil {
+		t.Fatalf("cartridge.New() error: %v", err)
+	}
+	if n, err := restored.ReadFrom(&buf); err != nil || n != 32*1024 {
+		t.Fatalf("ReadFrom() = %d, %v, want %d, nil", n, err, 32*1024)
+	}
+	restored.Write8(0x0000, 0x0A)
+	restored.Write8(0x4000, 0x03)
+	if got := restored.Read8(0xBFFF); got != 0x42 {
+		t.Errorf("Read8(BFFF) after ReadFrom = %02X, want 42", got)
+	}
+
+	if _, err := restored.ReadFrom(bytes.NewReader(make([]byte, 100))); err == nil {
+		t.Errorf("ReadFrom() of a short save succeeded, want an error")
+	}
+}
@@ -0,0 +1,203 @@
+// Package cartridge loads Game Boy ROM images and emulates the hardware on
+// the cartridge board that sits behind the ROM and external RAM regions of the
+// memory bus.
+package cartridge
+
+import (
+	"fmt"
+	"io/ioutil"
+	"time"
+
+	"github.com/vsinha/vm/internal/memory"
+)
+
+// Cartridge is a loaded ROM image. It implements memory.Device and should be
+// attached to both the ROM and the external RAM regions of the bus.
+type Cartridge struct {
+	Header
+
+	rom    []byte
+	ram    []byte
+	rtc    *rtc // Only set for MBC3 cartridges with a clock.
+	mapper Mapper
+
+	warning error // Returned by Warning.
+
+	savePath    string
+	dirty       bool  // Whether RAM has been written since it was saved.
+	autosaveErr error // The last error from an autosave, returned by Flush.
+}
+
+// options are the settings that can be changed with an Option.
+type options struct {
+	now      func() time.Time
+	savePath *string
+}
+
+// Option changes how New sets up a cartridge.
+type Option func(*options)
+
+// WithClock sets the source of the current time used by cartridges with a
+// real-time clock. It defaults to time.Now; tests can use it to control the
+// passage of time.
+func WithClock(now func() time.Time) Option {
+	return func(o *options) {
+		o.now = now
+	}
+}
+
+// WithSavePath sets the .sav file that battery-backed RAM is loaded from and
+// saved to. Load defaults to a .sav file next to the ROM; an empty path turns
+// saving off.
+func WithSavePath(path string) Option {
+	return func(o *options) {
+		o.savePath = &path
+	}
+}
+
+// New parses and verifies the header of rom and returns a cartridge ready to
+// be attached to a memory bus.
+func New(rom []byte, opts ...Option) (*Cartridge, error) {
+	o := options{now: time.Now}
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	h, err := ParseHeader(rom)
+	if err != nil {
+		return nil, err
+	}
+
+	if got := headerChecksum(rom); got != h.HeaderChecksum {
+		return nil, fmt.Errorf("computed %02X, header says %02X: %w", got, h.HeaderChecksum, ErrHeaderChecksum)
+	}
+	if len(rom) < h.ROMSize {
+		return nil, fmt.Errorf("header says %d bytes but the ROM is %d bytes: %w", h.ROMSize, len(rom), ErrTooSmall)
+	}
+
+	c := &Cartridge{
+		Header: h,
+		rom:    rom,
+		ram:    make([]byte, h.RAMSize),
+	}
+	if got := globalChecksum(rom); got != h.GlobalChecksum {
+		c.warning = fmt.Errorf("computed %04X, header says %04X: %w", got, h.GlobalChecksum, ErrGlobalChecksum)
+	}
+
+	switch h.Type {
+	case ROMOnly, ROMRAM, ROMRAMBattery:
+		c.mapper = &romOnly{rom: c.rom, ram: c.ram}
+	case MBC1, MBC1RAM, MBC1RAMBattery:
+		c.mapper = newMBC1(c.rom, c.ram)
+	case MBC2, MBC2Battery:
+		// The header always says 0 for MBC2, the RAM is inside the mapper.
+		c.ram = make([]byte, mbc2RAMSize)
+		c.mapper = newMBC2(c.rom, c.ram)
+	case MBC3, MBC3RAM, MBC3RAMBattery:
+		c.mapper = newMBC3(c.rom, c.ram, nil)
+	case MBC3TimerBattery, MBC3TimerRAMBattery:
+		c.rtc = newRTC(o.now)
+		c.mapper = newMBC3(c.rom, c.ram, c.rtc)
+	case MBC5, MBC5RAM, MBC5RAMBattery:
+		c.mapper = newMBC5(c.rom, c.ram, false)
+	case MBC5Rumble, MBC5RumbleRAM, MBC5RumbleRAMBattery:
+		c.mapper = newMBC5(c.rom, c.ram, true)
+	default:
+		return nil, &UnsupportedTypeError{Type: h.Type}
+	}
+
+	if o.savePath != nil && h.Type.HasBattery() {
+		c.savePath = *o.savePath
+	}
+
+	return c, nil
+}
+
+// Load reads a .gb or .gbc file from disk and calls New with its contents. If
+// the cartridge has a battery its RAM is restored from, and later saved to, a
+// .sav file next to the ROM.
+func Load(path string, opts ...Option) (*Cartridge, error) {
+	rom, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	opts = append([]Option{WithSavePath(savePathFor(path))}, opts...)
+	c, err := New(rom, opts...)
+	if err != nil {
+		return nil, fmt.Errorf("loading %q: %w", path, err)
+	}
+	if c.savePath != "" {
+		if err := c.loadSave(); err != nil {
+			return nil, err
+		}
+	}
+	return c, nil
+}
+
+// Warning returns a problem with the ROM that real hardware ignores, such as a
+// global checksum mismatch, or nil if there is none.
+func (c *Cartridge) Warning() error {
+	return c.warning
+}
+
+// Read8 implements memory.Device.
+func (c *Cartridge) Read8(addr uint16) uint8 {
+	return c.mapper.Read8(addr)
+}
+
+// Write8 implements memory.Device.
+func (c *Cartridge) Write8(addr uint16, val uint8) {
+	if addr >= memory.ExtRAMStart && c.storesRAM() {
+		c.dirty = true
+	}
+	c.mapper.Write8(addr, val)
+
+	if addr < memory.ExtRAMStart && c.savePath != "" && c.isRAMEnable(addr) {
+		c.autosave()
+	}
+}
+
+// Rumble reports whether the rumble motor is switched on. It's always false for
+// cartridges without one.
+func (c *Cartridge) Rumble() bool {
+	if m, ok := c.mapper.(*mbc5); ok {
+		return m.rumble
+	}
+	return false
+}
+
+// MarshalBinary returns the cartridge's RAM, followed by the 48-byte RTC
+// trailer for cartridges with a clock. This is the contents of a .sav file.
+func (c *Cartridge) MarshalBinary() ([]byte, error) {
+	b := append([]byte(nil), c.ram...)
+	if c.rtc != nil {
+		trailer, err := c.rtc.MarshalBinary()
+		if err != nil {
+			return nil, err
+		}
+		b = append(b, trailer...)
+	}
+	return b, nil
+}
+
+// UnmarshalBinary restores the cartridge's RAM, and clock if it has one, from
+// data written by MarshalBinary. Saves without an RTC trailer are accepted for
+// cartridges with a clock, leaving the clock as it is.
+func (c *Cartridge) UnmarshalBinary(b []byte) error {
+	if len(b) < len(c.ram) {
+		return fmt.Errorf("save data is %d bytes, want at least %d", len(b), len(c.ram))
+	}
+	trailer := b[len(c.ram):]
+	if len(trailer) > 0 && c.rtc == nil {
+		return fmt.Errorf("save data is %d bytes, want %d", len(b), len(c.ram))
+	}
+	if len(trailer) > 0 {
+		if err := c.rtc.UnmarshalBinary(trailer); err != nil {
+			return err
+		}
+	}
+
+	copy(c.ram, b)
+	return nil
+}
@@ -0,0 +1,174 @@
+package cartridge_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/vsinha/vm/internal/cartridge"
+)
+
+// makeROM builds a ROM image of the size given by romSize with a valid header
+// and checksums. edit, if not nil, is run over the header before the checksums
+// are computed.
+func makeROM(title string, typ cartridge.Type, romSize, ramSize uint8, edit func(rom []byte)) []byte {
+	rom := make([]byte, 32*1024<<romSize)
+	// Entry point: NOP; JP $0150
+	copy(rom[0x0100:], []byte{0x00, 0xC3, 0x50, 0x01})
+	copy(rom[0x0134:0x0144], title)
+	rom[0x0147] = byte(typ)
+	rom[0x0148] = romSize
+	rom[0x0149] = ramSize
+	rom[0x014B] = 0x33
+	copy(rom[0x0144:], "01")
+	if edit != nil {
+		edit(rom)
+	}
+	fixChecksums(rom)
+	return rom
+}
+
+// fixChecksums fills in the header and global checksums the way rgbfix does.
+func fixChecksums(rom []byte) {
+	var x uint8
+	for _, b := range rom[0x0134:0x014D] {
+		x = x - b - 1
+	}
+	rom[0x014D] = x
+
+	var sum uint16
+	for i, b := range rom {
+		if i != 0x014E && i != 0x014F {
+			sum += uint16(b)
+		}
+	}
+	rom[0x014E], rom[0x014F] = byte(sum>>8), byte(sum)
+}
+
+func TestParseHeader(t *testing.T) {
+	tests := []struct {
+		name string
+		rom  []byte
+		want cartridge.Header
+	}{
+		{
+			"DMG",
+			makeROM("TETRIS", cartridge.ROMOnly, 0, 0, func(rom []byte) {
+				rom[0x014B] = 0x01
+				rom[0x014C] = 0x01
+			}),
+			cartridge.Header{Title: "TETRIS", Licensee: "01", Type: cartridge.ROMOnly, ROMSize: 32 * 1024, Version: 1},
+		},
+		{
+			"CGB with manufacturer code",
+			makeROM("POKEMON_SLVAAXE", cartridge.ROMRAMBattery, 1, 3, func(rom []byte) {
+				rom[0x0143] = byte(cartridge.CGBSupported)
+				rom[0x0146] = 0x03
+			}),
+			cartridge.Header{
+				Title: "POKEMON_SLV", ManufacturerCode: "AAXE", CGBFlag: cartridge.CGBSupported, SGBFlag: true,
+				Licensee: "01", Type: cartridge.ROMRAMBattery, ROMSize: 64 * 1024, RAMSize: 32 * 1024,
+			},
+		},
+		{
+			"CGB only without manufacturer code",
+			makeROM("LONG CGB TITLE\x00", cartridge.ROMRAM, 0, 2, func(rom []byte) {
+				rom[0x0143] = byte(cartridge.CGBOnly)
+			}),
+			cartridge.Header{
+				Title: "LONG CGB TITLE", CGBFlag: cartridge.CGBOnly, Licensee: "01",
+				Type: cartridge.ROMRAM, ROMSize: 32 * 1024, RAMSize: 8 * 1024,
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			c, err := cartridge.New(test.rom)
+			if err != nil {
+				t.Fatalf("cartridge.New() error: %v", err)
+			}
+			got := c.Header
+			got.HeaderChecksum, got.GlobalChecksum = 0, 0
+			if diff := cmp.Diff(test.want, got); diff != "" {
+				t.Errorf("Header difference (-want,+got): %v", diff)
+			}
+		})
+	}
+}
+
+func TestNewErrors(t *testing.T) {
+	badHeader := makeROM("GAME", cartridge.ROMOnly, 0, 0, nil)
+	badHeader[0x014D]++
+
+	truncated := makeROM("GAME", cartridge.ROMOnly, 1, 0, nil)[:32*1024]
+
+	tests := []struct {
+		name string
+		rom  []byte
+		want error
+	}{
+		{"no header", make([]byte, 0x100), cartridge.ErrTooSmall},
+		{"header checksum", badHeader, cartridge.ErrHeaderChecksum},
+		{"truncated", truncated, cartridge.ErrTooSmall},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			_, err := cartridge.New(test.rom)
+			if !errors.Is(err, test.want) {
+				t.Errorf("cartridge.New() error = %v, want %v", err, test.want)
+			}
+		})
+	}
+}
+
+func TestGlobalChecksumWarning(t *testing.T) {
+	c, err := cartridge.New(makeROM("GAME", cartridge.ROMOnly, 0, 0, nil))
+	if err != nil {
+		t.Fatalf("cartridge.New() error: %v", err)
+	}
+	if err := c.Warning(); err != nil {
+		t.Errorf("Warning() = %v, want nil", err)
+	}
+
+	rom := makeROM("GAME", cartridge.ROMOnly, 0, 0, nil)
+	rom[0x4000]++
+	c, err = cartridge.New(rom)
+	if err != nil {
+		t.Fatalf("cartridge.New() with a bad global checksum error: %v", err)
+	}
+	if err := c.Warning(); !errors.Is(err, cartridge.ErrGlobalChecksum) {
+		t.Errorf("Warning() = %v, want %v", err, cartridge.ErrGlobalChecksum)
+	}
+}
+
+func TestUnsupportedType(t *testing.T) {
+	_, err := cartridge.New(makeROM("CAMERA", cartridge.PocketCamera, 0, 0, nil))
+	var typeErr *cartridge.UnsupportedTypeError
+	if !errors.As(err, &typeErr) {
+		t.Fatalf("cartridge.New() error = %v, want an UnsupportedTypeError", err)
+	}
+	if typeErr.Type != cartridge.PocketCamera {
+		t.Errorf("UnsupportedTypeError.Type = %v, want %v", typeErr.Type, cartridge.PocketCamera)
+	}
+}
+
+func TestReadWrite(t *testing.T) {
+	c, err := cartridge.New(makeROM("GAME", cartridge.ROMRAM, 0, 2, nil))
+	if err != nil {
+		t.Fatalf("cartridge.New() error: %v", err)
+	}
+
+	if got, want := c.Read8(0x0101), uint8(0xC3); got != want {
+		t.Errorf("Read8(0101) = %02X, want %02X", got, want)
+	}
+	c.Write8(0x0101, 0x00)
+	if got, want := c.Read8(0x0101), uint8(0xC3); got != want {
+		t.Errorf("Read8(0101) after writing to ROM = %02X, want %02X", got, want)
+	}
+	c.Write8(0xA123, 0x42)
+	if got, want := c.Read8(0xA123), uint8(0x42); got != want {
+		t.Errorf("Read8(A123) = %02X, want %02X", got, want)
+	}
+}
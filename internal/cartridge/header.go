@@ -0,0 +1,243 @@
+package cartridge
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// ErrHeaderChecksum is returned when the checksum over 0x0134-0x014C doesn't
+// match the one stored at 0x014D. Real hardware refuses to boot these.
+var ErrHeaderChecksum = errors.New("header checksum mismatch")
+
+// ErrGlobalChecksum is reported by Cartridge.Warning when the sum of every
+// byte in the ROM doesn't match the one stored at 0x014E-0x014F. Real hardware
+// never checks it, so the cartridge still runs.
+var ErrGlobalChecksum = errors.New("global checksum mismatch")
+
+// ErrTooSmall is returned when the ROM is too small to hold the header, or
+// smaller than the size the header says it is.
+var ErrTooSmall = errors.New("ROM is too small")
+
+// Header addresses.
+const (
+	titleStart          = 0x0134
+	manufacturerStart   = 0x013F
+	cgbFlagAddr         = 0x0143
+	newLicenseeStart    = 0x0144
+	sgbFlagAddr         = 0x0146
+	typeAddr            = 0x0147
+	romSizeAddr         = 0x0148
+	ramSizeAddr         = 0x0149
+	oldLicenseeAddr     = 0x014B
+	versionAddr         = 0x014C
+	headerChecksumAddr  = 0x014D
+	globalChecksumStart = 0x014E
+	headerEnd           = 0x014F
+)
+
+// CGBFlag is the byte at 0x0143 telling a Game Boy Color how to run the game.
+type CGBFlag uint8
+
+// Values of the CGB flag. Anything without bit 7 set is a DMG game.
+const (
+	CGBNone      CGBFlag = 0x00 // Monochrome only.
+	CGBSupported CGBFlag = 0x80 // Colour enhanced, still runs on a DMG.
+	CGBOnly      CGBFlag = 0xC0 // Game Boy Color only.
+)
+
+// Type is the cartridge type byte at 0x0147, which says which memory bank
+// controller and extra hardware are on the board.
+type Type uint8
+
+// Cartridge types.
+const (
+	ROMOnly                    Type = 0x00
+	MBC1                       Type = 0x01
+	MBC1RAM                    Type = 0x02
+	MBC1RAMBattery             Type = 0x03
+	MBC2                       Type = 0x05
+	MBC2Battery                Type = 0x06
+	ROMRAM                     Type = 0x08
+	ROMRAMBattery              Type = 0x09
+	MMM01                      Type = 0x0B
+	MMM01RAM                   Type = 0x0C
+	MMM01RAMBattery            Type = 0x0D
+	MBC3TimerBattery           Type = 0x0F
+	MBC3TimerRAMBattery        Type = 0x10
+	MBC3                       Type = 0x11
+	MBC3RAM                    Type = 0x12
+	MBC3RAMBattery             Type = 0x13
+	MBC5                       Type = 0x19
+	MBC5RAM                    Type = 0x1A
+	MBC5RAMBattery             Type = 0x1B
+	MBC5Rumble                 Type = 0x1C
+	MBC5RumbleRAM              Type = 0x1D
+	MBC5RumbleRAMBattery       Type = 0x1E
+	MBC6                       Type = 0x20
+	MBC7SensorRumbleRAMBattery Type = 0x22
+	PocketCamera               Type = 0xFC
+	BandaiTAMA5                Type = 0xFD
+	HuC3                       Type = 0xFE
+	HuC1RAMBattery             Type = 0xFF
+)
+
+var typeNames = map[Type]string{
+	ROMOnly:                    "ROM ONLY",
+	MBC1:                       "MBC1",
+	MBC1RAM:                    "MBC1+RAM",
+	MBC1RAMBattery:             "MBC1+RAM+BATTERY",
+	MBC2:                       "MBC2",
+	MBC2Battery:                "MBC2+BATTERY",
+	ROMRAM:                     "ROM+RAM",
+	ROMRAMBattery:              "ROM+RAM+BATTERY",
+	MMM01:                      "MMM01",
+	MMM01RAM:                   "MMM01+RAM",
+	MMM01RAMBattery:            "MMM01+RAM+BATTERY",
+	MBC3TimerBattery:           "MBC3+TIMER+BATTERY",
+	MBC3TimerRAMBattery:        "MBC3+TIMER+RAM+BATTERY",
+	MBC3:                       "MBC3",
+	MBC3RAM:                    "MBC3+RAM",
+	MBC3RAMBattery:             "MBC3+RAM+BATTERY",
+	MBC5:                       "MBC5",
+	MBC5RAM:                    "MBC5+RAM",
+	MBC5RAMBattery:             "MBC5+RAM+BATTERY",
+	MBC5Rumble:                 "MBC5+RUMBLE",
+	MBC5RumbleRAM:              "MBC5+RUMBLE+RAM",
+	MBC5RumbleRAMBattery:       "MBC5+RUMBLE+RAM+BATTERY",
+	MBC6:                       "MBC6",
+	MBC7SensorRumbleRAMBattery: "MBC7+SENSOR+RUMBLE+RAM+BATTERY",
+	PocketCamera:               "POCKET CAMERA",
+	BandaiTAMA5:                "BANDAI TAMA5",
+	HuC3:                       "HuC3",
+	HuC1RAMBattery:             "HuC1+RAM+BATTERY",
+}
+
+func (t Type) String() string {
+	if name, ok := typeNames[t]; ok {
+		return name
+	}
+	return fmt.Sprintf("unknown cartridge type %02X", uint8(t))
+}
+
+// UnsupportedTypeError is returned when a cartridge uses a memory bank
+// controller this emulator doesn't implement.
+type UnsupportedTypeError struct {
+	Type Type
+}
+
+func (e *UnsupportedTypeError) Error() string {
+	return fmt.Sprintf("unsupported cartridge type %02X (%v)", uint8(e.Type), e.Type)
+}
+
+// ramSizes maps the RAM size byte at 0x0149 to a size in bytes.
+var ramSizes = map[uint8]int{
+	0x00: 0,
+	0x01: 2 * 1024, // Listed in some docs, never used by a released game.
+	0x02: 8 * 1024,
+	0x03: 32 * 1024,
+	0x04: 128 * 1024,
+	0x05: 64 * 1024,
+}
+
+// Header is the cartridge header found at 0x0100-0x014F of every ROM.
+type Header struct {
+	Title            string
+	ManufacturerCode string // Only present on later CGB games.
+	CGBFlag          CGBFlag
+	SGBFlag          bool   // Whether the game uses Super Game Boy functions.
+	Licensee         string // The new licensee code, or the old one in hex.
+	Type             Type
+	ROMSize          int // In bytes.
+	RAMSize          int // In bytes.
+	Version          uint8
+	HeaderChecksum   uint8
+	GlobalChecksum   uint16
+}
+
+// ParseHeader reads the header out of rom. It doesn't verify the checksums.
+func ParseHeader(rom []byte) (Header, error) {
+	if len(rom) <= headerEnd {
+		return Header{}, fmt.Errorf("%d bytes can't hold a header: %w", len(rom), ErrTooSmall)
+	}
+
+	h := Header{
+		CGBFlag:        CGBFlag(rom[cgbFlagAddr]),
+		SGBFlag:        rom[sgbFlagAddr] == 0x03,
+		Type:           Type(rom[typeAddr]),
+		Version:        rom[versionAddr],
+		HeaderChecksum: rom[headerChecksumAddr],
+		// Unlike everything else, the global checksum is big-endian.
+		GlobalChecksum: uint16(rom[globalChecksumStart])<<8 | uint16(rom[globalChecksumStart+1]),
+	}
+
+	// The title used to be 16 bytes. CGB games took the last byte for the CGB
+	// flag, and later ones the four before it for a manufacturer code.
+	title := rom[titleStart : cgbFlagAddr+1]
+	if h.CGBFlag&0x80 != 0 {
+		title = title[:cgbFlagAddr-titleStart]
+		if code := rom[manufacturerStart:cgbFlagAddr]; isManufacturerCode(code) {
+			h.ManufacturerCode = string(code)
+			title = title[:manufacturerStart-titleStart]
+		}
+	}
+	if i := bytes.IndexByte(title, 0); i >= 0 {
+		title = title[:i]
+	}
+	h.Title = strings.TrimRight(string(title), " ")
+
+	// An old licensee code of 0x33 means the new code is used instead.
+	if old := rom[oldLicenseeAddr]; old == 0x33 {
+		h.Licensee = string(rom[newLicenseeStart : newLicenseeStart+2])
+	} else {
+		h.Licensee = fmt.Sprintf("%02X", old)
+	}
+
+	romSize := rom[romSizeAddr]
+	if romSize > 0x08 {
+		return Header{}, fmt.Errorf("unknown ROM size code %02X", romSize)
+	}
+	h.ROMSize = 32 * 1024 << romSize
+
+	ramSize, ok := ramSizes[rom[ramSizeAddr]]
+	if !ok {
+		return Header{}, fmt.Errorf("unknown RAM size code %02X", rom[ramSizeAddr])
+	}
+	h.RAMSize = ramSize
+
+	return h, nil
+}
+
+// isManufacturerCode reports whether code looks like a four character
+// manufacturer code rather than the tail of a title.
+func isManufacturerCode(code []byte) bool {
+	for _, c := range code {
+		if (c < 'A' || c > 'Z') && (c < '0' || c > '9') {
+			return false
+		}
+	}
+	return true
+}
+
+// headerChecksum computes the checksum the boot ROM checks before starting a
+// game.
+func headerChecksum(rom []byte) uint8 {
+	var x uint8
+	for _, b := range rom[titleStart:headerChecksumAddr] {
+		x = x - b - 1
+	}
+	return x
+}
+
+// globalChecksum sums every byte of the ROM except the checksum itself.
+func globalChecksum(rom []byte) uint16 {
+	var x uint16
+	for i, b := range rom {
+		if i == globalChecksumStart || i == globalChecksumStart+1 {
+			continue
+		}
+		x += uint16(b)
+	}
+	return x
+}
@@ -0,0 +1,68 @@
+package cartridge
+
+import "github.com/vsinha/vm/internal/memory"
+
+// Sizes of the banks the mappers switch between.
+const (
+	romBankSize = 0x4000
+	ramBankSize = 0x2000
+)
+
+// Mapper is a memory bank controller, the chip on the cartridge board that
+// decides which bank of ROM and RAM the CPU sees through the ROM and external
+// RAM regions of the bus. Writes to the ROM region program its registers.
+type Mapper interface {
+	memory.Device
+}
+
+// romBank reads addr, an offset into a 16 KiB bank, out of the given bank of
+// rom. Bank numbers past the end of the ROM wrap around, as the unused upper
+// bits of the bank number aren't wired to anything.
+func romBank(rom []byte, bank int, addr uint16) uint8 {
+	if len(rom) == 0 {
+		return 0xFF
+	}
+	banks := len(rom) / romBankSize
+	if banks == 0 {
+		banks = 1
+	}
+	return rom[((bank%banks)*romBankSize+int(addr&(romBankSize-1)))%len(rom)]
+}
+
+// ramOffset returns the index into ram of addr, an address in the external RAM
+// region, in the given bank. ok is false if there is no RAM at all.
+func ramOffset(ram []byte, bank int, addr uint16) (i int, ok bool) {
+	if len(ram) == 0 {
+		return 0, false
+	}
+	return (bank*ramBankSize + int(addr-memory.ExtRAMStart)) % len(ram), true
+}
+
+// romOnly is a cartridge without a mapper: 32 KiB of ROM and optionally up to
+// 8 KiB of RAM, wired straight to the bus.
+type romOnly struct {
+	rom []byte
+	ram []byte
+}
+
+func (m *romOnly) Read8(addr uint16) uint8 {
+	if addr <= memory.ROMEnd {
+		if int(addr) < len(m.rom) {
+			return m.rom[addr]
+		}
+		return 0xFF
+	}
+	if i, ok := ramOffset(m.ram, 0, addr); ok {
+		return m.ram[i]
+	}
+	return 0xFF
+}
+
+func (m *romOnly) Write8(addr uint16, val uint8) {
+	if addr <= memory.ROMEnd {
+		return
+	}
+	if i, ok := ramOffset(m.ram, 0, addr); ok {
+		m.ram[i] = val
+	}
+}
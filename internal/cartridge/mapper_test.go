@@ -0,0 +1,176 @@
+package cartridge_test
+
+import (
+	"testing"
+
+	"github.com/vsinha/vm/internal/cartridge"
+)
+
+// nintendoLogo is the logo every licensed ROM carries at 0x0104.
+var nintendoLogo = []byte{
+	0xCE, 0xED, 0x66, 0x66, 0xCC, 0x0D, 0x00, 0x0B, 0x03, 0x73, 0x00, 0x83, 0x00, 0x0C, 0x00, 0x0D,
+	0x00, 0x08, 0x11, 0x1F, 0x88, 0x89, 0x00, 0x0E, 0xDC, 0xCC, 0x6E, 0xE6, 0xDD, 0xDD, 0xD9, 0x99,
+	0xBB, 0xBB, 0x67, 0x63, 0x6E, 0x0E, 0xEC, 0xCC, 0xDD, 0xDC, 0x99, 0x9F, 0xBB, 0xB9, 0x33, 0x3E,
+}
+
+// markBanks writes each ROM bank's number, little-endian, 0x2000 bytes into
+// the bank, so reading 0x2000 or 0x6000 says which bank is mapped in.
+func markBanks(rom []byte) {
+	for bank := 0; bank*0x4000 < len(rom); bank++ {
+		rom[bank*0x4000+0x2000] = byte(bank)
+		rom[bank*0x4000+0x2001] = byte(bank >> 8)
+	}
+}
+
+type access struct {
+	addr uint16
+	val  uint8
+}
+
+func TestMappers(t *testing.T) {
+	tests := []struct {
+		name    string
+		typ     cartridge.Type
+		romSize uint8
+		ramSize uint8
+		edit    func(rom []byte)
+		writes  []access
+		reads   []access // Expected values read back after the writes.
+	}{
+		// MBC1
+		{"MBC1 power on", cartridge.MBC1, 5, 0, nil, nil,
+			[]access{{0x2000, 0x00}, {0x6000, 0x01}}},
+		{"MBC1 bank 0 reads as 1", cartridge.MBC1, 5, 0, nil,
+			[]access{{0x2000, 0x00}},
+			[]access{{0x6000, 0x01}}},
+		{"MBC1 5-bit bank", cartridge.MBC1, 5, 0, nil,
+			[]access{{0x2000, 0xFF}},
+			[]access{{0x6000, 0x1F}}},
+		{"MBC1 upper bits", cartridge.MBC1, 5, 0, nil,
+			[]access{{0x4000, 0x01}, {0x2000, 0x02}},
+			[]access{{0x6000, 0x22}, {0x2000, 0x00}}},
+		{"MBC1 0x20 reads as 0x21", cartridge.MBC1, 5, 0, nil,
+			[]access{{0x4000, 0x01}, {0x2000, 0x00}},
+			[]access{{0x6000, 0x21}}},
+		{"MBC1 mode 1 banks 0x0000", cartridge.MBC1, 5, 0, nil,
+			[]access{{0x4000, 0x01}, {0x6000, 0x01}},
+			[]access{{0x2000, 0x20}, {0x6000, 0x21}}},
+		{"MBC1 bank masked to ROM size", cartridge.MBC1, 3, 0, nil,
+			[]access{{0x2000, 0x11}},
+			[]access{{0x6000, 0x01}}},
+		{"MBC1 RAM disabled", cartridge.MBC1RAM, 5, 3, nil,
+			[]access{{0xA000, 0x42}},
+			[]access{{0xA000, 0xFF}}},
+		{"MBC1 RAM enabled", cartridge.MBC1RAM, 5, 3, nil,
+			[]access{{0x0000, 0x0A}, {0xA000, 0x42}},
+			[]access{{0xA000, 0x42}}},
+		{"MBC1 RAM banking needs mode 1", cartridge.MBC1RAM, 5, 3, nil,
+			[]access{{0x0000, 0x0A}, {0xA000, 0x42}, {0x4000, 0x01}},
+			[]access{{0xA000, 0x42}}},
+		{"MBC1 RAM bank 1", cartridge.MBC1RAM, 5, 3, nil,
+			[]access{{0x0000, 0x0A}, {0xA000, 0x42}, {0x4000, 0x01}, {0x6000, 0x01}, {0xA000, 0x43}, {0x6000, 0x00}},
+			[]access{{0xA000, 0x42}}},
+		{"MBC1 RAM disabled again", cartridge.MBC1RAM, 5, 3, nil,
+			[]access{{0x0000, 0x0A}, {0xA000, 0x42}, {0x0000, 0x00}},
+			[]access{{0xA000, 0xFF}}},
+		{"MBC1 multicart", cartridge.MBC1, 5, 0, func(rom []byte) { copy(rom[0x40104:], nintendoLogo) },
+			[]access{{0x4000, 0x01}, {0x2000, 0x12}},
+			[]access{{0x6000, 0x12}, {0x2000, 0x00}}},
+		{"MBC1 multicart selects game", cartridge.MBC1, 5, 0, func(rom []byte) { copy(rom[0x40104:], nintendoLogo) },
+			[]access{{0x4000, 0x02}, {0x6000, 0x01}, {0x2000, 0x01}},
+			[]access{{0x2000, 0x20}, {0x6000, 0x21}}},
+
+		// MBC2
+		{"MBC2 bank", cartridge.MBC2, 3, 0, nil,
+			[]access{{0x2100, 0x05}},
+			[]access{{0x6000, 0x05}}},
+		{"MBC2 bank 0 reads as 1", cartridge.MBC2, 3, 0, nil,
+			[]access{{0x2100, 0x10}},
+			[]access{{0x6000, 0x01}}},
+		{"MBC2 address bit 8 clear enables RAM", cartridge.MBC2, 3, 0, nil,
+			[]access{{0x2000, 0x0A}, {0xA001, 0x12}},
+			[]access{{0x6000, 0x01}, {0xA001, 0xF2}}},
+		{"MBC2 RAM repeats", cartridge.MBC2, 3, 0, nil,
+			[]access{{0x0000, 0x0A}, {0xA001, 0x0B}},
+			[]access{{0xA201, 0xFB}, {0xBE01, 0xFB}}},
+		{"MBC2 RAM disabled", cartridge.MBC2, 3, 0, nil,
+			[]access{{0x0000, 0x0A}, {0xA001, 0x0B}, {0x0000, 0x00}},
+			[]access{{0xA001, 0xFF}}},
+
+		// MBC3
+		{"MBC3 7-bit bank", cartridge.MBC3, 6, 0, nil,
+			[]access{{0x2000, 0xFF}},
+			[]access{{0x6000, 0x7F}}},
+		{"MBC3 bank 0 reads as 1", cartridge.MBC3, 6, 0, nil,
+			[]access{{0x2000, 0x00}},
+			[]access{{0x6000, 0x01}}},
+		{"MBC3 RAM banks", cartridge.MBC3RAMBattery, 1, 3, nil,
+			[]access{{0x0000, 0x0A}, {0x4000, 0x03}, {0xA000, 0x33}, {0x4000, 0x00}, {0xA000, 0x00}, {0x4000, 0x03}},
+			[]access{{0xA000, 0x33}}},
+		{"MBC3 RAM disabled", cartridge.MBC3RAM, 1, 3, nil,
+			[]access{{0xA000, 0x33}},
+			[]access{{0xA000, 0xFF}}},
+
+		// MBC5
+		{"MBC5 bank 0", cartridge.MBC5, 8, 0, nil,
+			[]access{{0x2000, 0x00}},
+			[]access{{0x6000, 0x00}, {0x6001, 0x00}}},
+		{"MBC5 9-bit bank", cartridge.MBC5, 8, 0, nil,
+			[]access{{0x3000, 0x01}, {0x2000, 0x05}},
+			[]access{{0x6000, 0x05}, {0x6001, 0x01}}},
+		{"MBC5 high bit only", cartridge.MBC5, 8, 0, nil,
+			[]access{{0x2000, 0x05}, {0x3000, 0xFF}, {0x3000, 0x00}},
+			[]access{{0x6000, 0x05}, {0x6001, 0x00}}},
+		{"MBC5 RAM enable needs 0x0A exactly", cartridge.MBC5RAM, 1, 4, nil,
+			[]access{{0x0000, 0x1A}, {0xA000, 0x42}},
+			[]access{{0xA000, 0xFF}}},
+		{"MBC5 RAM bank 15", cartridge.MBC5RAM, 1, 4, nil,
+			[]access{{0x0000, 0x0A}, {0x4000, 0x0F}, {0xA000, 0x42}, {0x4000, 0x07}, {0xA000, 0x00}, {0x4000, 0x0F}},
+			[]access{{0xA000, 0x42}}},
+		{"MBC5 rumble bit isn't a bank bit", cartridge.MBC5RumbleRAM, 1, 3, nil,
+			[]access{{0x0000, 0x0A}, {0xA000, 0x42}, {0x4000, 0x08}},
+			[]access{{0xA000, 0x42}}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			c, err := cartridge.New(makeROM("MAPPER", test.typ, test.romSize, test.ramSize, func(rom []byte) {
+				markBanks(rom)
+				if test.edit != nil {
+					test.edit(rom)
+				}
+			}))
+			if err != nil {
+				t.Fatalf("cartridge.New() error: %v", err)
+			}
+
+			for _, w := range test.writes {
+				c.Write8(w.addr, w.val)
+			}
+			for _, r := range test.reads {
+				if got := c.Read8(r.addr); got != r.val {
+					t.Errorf("Read8(%04X) = %02X, want %02X", r.addr, got, r.val)
+				}
+			}
+		})
+	}
+}
+
+func TestRumble(t *testing.T) {
+	c, err := cartridge.New(makeROM("RUMBLE", cartridge.MBC5Rumble, 1, 0, nil))
+	if err != nil {
+		t.Fatalf("cartridge.New() error: %v", err)
+	}
+
+	if c.Rumble() {
+		t.Errorf("Rumble() = true at power on")
+	}
+	c.Write8(0x4000, 0x08)
+	if !c.Rumble() {
+		t.Errorf("Rumble() = false after setting bit 3 of the RAM bank")
+	}
+	c.Write8(0x4000, 0x00)
+	if c.Rumble() {
+		t.Errorf("Rumble() = true after clearing bit 3 of the RAM bank")
+	}
+}
@@ -0,0 +1,111 @@
+package cartridge
+
+import (
+	"bytes"
+
+	"github.com/vsinha/vm/internal/memory"
+)
+
+// nintendoLogo is the bitmap every licensed ROM carries at 0x0104-0x0133.
+var nintendoLogo = []byte{
+	0xCE, 0xED, 0x66, 0x66, 0xCC, 0x0D, 0x00, 0x0B, 0x03, 0x73, 0x00, 0x83, 0x00, 0x0C, 0x00, 0x0D,
+	0x00, 0x08, 0x11, 0x1F, 0x88, 0x89, 0x00, 0x0E, 0xDC, 0xCC, 0x6E, 0xE6, 0xDD, 0xDD, 0xD9, 0x99,
+	0xBB, 0xBB, 0x67, 0x63, 0x6E, 0x0E, 0xEC, 0xCC, 0xDD, 0xDC, 0x99, 0x9F, 0xBB, 0xB9, 0x33, 0x3E,
+}
+
+// isMBC1Multicart reports whether rom is one of the 1 MiB MBC1 multicarts
+// (MBC1M), which hold several 256 KiB games each with their own header. There's
+// nothing in the header to say so, so look for the logo of a second game at
+// the start of bank 0x10.
+func isMBC1Multicart(rom []byte) bool {
+	const second = 0x10*romBankSize + 0x0104
+	if len(rom) != 1024*1024 {
+		return false
+	}
+	return bytes.Equal(rom[second:second+len(nintendoLogo)], nintendoLogo)
+}
+
+// mbc1 is the first and most common memory bank controller, supporting up to
+// 2 MiB of ROM and 32 KiB of RAM.
+type mbc1 struct {
+	rom []byte
+	ram []byte
+
+	ramEnabled bool
+	bank1      uint8 // 5-bit ROM bank number, 0x2000-0x3FFF.
+	bank2      uint8 // 2-bit RAM bank or upper ROM bank bits, 0x4000-0x5FFF.
+	mode       uint8 // Banking mode, 0x6000-0x7FFF.
+
+	// On multicarts bit 4 of bank1 isn't connected and bank2 drives ROM
+	// address lines one lower than usual, so shift it by 4 rather than 5.
+	bank2Shift uint
+}
+
+func newMBC1(rom, ram []byte) *mbc1 {
+	m := &mbc1{rom: rom, ram: ram, bank1: 1, bank2Shift: 5}
+	if isMBC1Multicart(rom) {
+		m.bank2Shift = 4
+	}
+	return m
+}
+
+func (m *mbc1) Read8(addr uint16) uint8 {
+	switch {
+	case addr < romBankSize:
+		// In mode 1 bank2 also switches the bank mapped at 0x0000, which is
+		// how multicarts select a game.
+		bank := 0
+		if m.mode == 1 {
+			bank = int(m.bank2) << m.bank2Shift
+		}
+		return romBank(m.rom, bank, addr)
+	case addr <= memory.ROMEnd:
+		bank1 := m.bank1
+		if m.bank2Shift == 4 {
+			bank1 &= 0x0F
+		}
+		return romBank(m.rom, int(m.bank2)<<m.bank2Shift|int(bank1), addr)
+	default:
+		if !m.ramEnabled {
+			return 0xFF
+		}
+		if i, ok := ramOffset(m.ram, m.ramBank(), addr); ok {
+			return m.ram[i]
+		}
+		return 0xFF
+	}
+}
+
+func (m *mbc1) Write8(addr uint16, val uint8) {
+	switch {
+	case addr <= 0x1FFF:
+		m.ramEnabled = val&0x0F == 0x0A
+	case addr <= 0x3FFF:
+		// Bank 0 can't be selected into 0x4000-0x7FFF. The check looks at all
+		// 5 bits, so selecting 0x20 gives 0x21. On a multicart bit 4 isn't
+		// wired, so selecting 0x10 passes the check but reaches bank 0.
+		m.bank1 = val & 0x1F
+		if m.bank1 == 0 {
+			m.bank1 = 1
+		}
+	case addr <= 0x5FFF:
+		m.bank2 = val & 0x03
+	case addr <= memory.ROMEnd:
+		m.mode = val & 0x01
+	default:
+		if !m.ramEnabled {
+			return
+		}
+		if i, ok := ramOffset(m.ram, m.ramBank(), addr); ok {
+			m.ram[i] = val
+		}
+	}
+}
+
+// ramBank is the RAM bank mapped in, which bank2 only controls in mode 1.
+func (m *mbc1) ramBank() int {
+	if m.mode == 1 {
+		return int(m.bank2)
+	}
+	return 0
+}
@@ -0,0 +1,55 @@
+package cartridge
+
+import "github.com/vsinha/vm/internal/memory"
+
+// mbc2RAMSize is the number of 4-bit cells built into the MBC2 chip.
+const mbc2RAMSize = 512
+
+// mbc2 supports up to 256 KiB of ROM and has 512 4-bit cells of RAM built in.
+type mbc2 struct {
+	rom []byte
+	ram []byte // Only the lower nibble of each byte is used.
+
+	ramEnabled bool
+	bank       uint8
+}
+
+func newMBC2(rom, ram []byte) *mbc2 {
+	return &mbc2{rom: rom, ram: ram, bank: 1}
+}
+
+func (m *mbc2) Read8(addr uint16) uint8 {
+	switch {
+	case addr < romBankSize:
+		return romBank(m.rom, 0, addr)
+	case addr <= memory.ROMEnd:
+		return romBank(m.rom, int(m.bank), addr)
+	default:
+		if !m.ramEnabled || len(m.ram) == 0 {
+			return 0xFF
+		}
+		// The 512 cells repeat through the whole external RAM region and the
+		// upper nibble isn't connected, so reads as 1s.
+		return m.ram[int(addr)%len(m.ram)] | 0xF0
+	}
+}
+
+func (m *mbc2) Write8(addr uint16, val uint8) {
+	switch {
+	case addr < romBankSize:
+		// Both registers live in 0x0000-0x3FFF, address bit 8 picks which.
+		if addr&0x0100 == 0 {
+			m.ramEnabled = val&0x0F == 0x0A
+			return
+		}
+		m.bank = val & 0x0F
+		if m.bank == 0 {
+			m.bank = 1
+		}
+	case addr <= memory.ROMEnd:
+	default:
+		if m.ramEnabled && len(m.ram) > 0 {
+			m.ram[int(addr)%len(m.ram)] = val & 0x0F
+		}
+	}
+}
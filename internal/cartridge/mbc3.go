@@ -0,0 +1,74 @@
+package cartridge
+
+import "github.com/vsinha/vm/internal/memory"
+
+// mbc3 supports up to 2 MiB of ROM and 32 KiB of RAM, and optionally a
+// real-time clock.
+type mbc3 struct {
+	rom []byte
+	ram []byte
+	rtc *rtc // nil if the cartridge has no clock.
+
+	ramEnabled bool
+	romBank    uint8
+	ramBank    uint8 // 0x00-0x03 select a RAM bank, 0x08-0x0C an RTC register.
+}
+
+func newMBC3(rom, ram []byte, clock *rtc) *mbc3 {
+	return &mbc3{rom: rom, ram: ram, rtc: clock, romBank: 1}
+}
+
+func (m *mbc3) Read8(addr uint16) uint8 {
+	switch {
+	case addr < romBankSize:
+		return romBank(m.rom, 0, addr)
+	case addr <= memory.ROMEnd:
+		return romBank(m.rom, int(m.romBank), addr)
+	default:
+		if !m.ramEnabled {
+			return 0xFF
+		}
+		if m.ramBank >= rtcSeconds && m.ramBank <= rtcDaysHi && m.rtc != nil {
+			return m.rtc.read(m.ramBank)
+		}
+		if m.ramBank > 0x03 {
+			return 0xFF
+		}
+		if i, ok := ramOffset(m.ram, int(m.ramBank), addr); ok {
+			return m.ram[i]
+		}
+		return 0xFF
+	}
+}
+
+func (m *mbc3) Write8(addr uint16, val uint8) {
+	switch {
+	case addr <= 0x1FFF:
+		m.ramEnabled = val&0x0F == 0x0A
+	case addr <= 0x3FFF:
+		m.romBank = val & 0x7F
+		if m.romBank == 0 {
+			m.romBank = 1
+		}
+	case addr <= 0x5FFF:
+		m.ramBank = val
+	case addr <= memory.ROMEnd:
+		if m.rtc != nil {
+			m.rtc.latch(val)
+		}
+	default:
+		if !m.ramEnabled {
+			return
+		}
+		if m.ramBank >= rtcSeconds && m.ramBank <= rtcDaysHi && m.rtc != nil {
+			m.rtc.write(m.ramBank, val)
+			return
+		}
+		if m.ramBank > 0x03 {
+			return
+		}
+		if i, ok := ramOffset(m.ram, int(m.ramBank), addr); ok {
+			m.ram[i] = val
+		}
+	}
+}
@@ -0,0 +1,66 @@
+package cartridge
+
+import "github.com/vsinha/vm/internal/memory"
+
+// mbc5 supports up to 8 MiB of ROM, through a 9-bit bank number, and 128 KiB
+// of RAM. Unlike the earlier mappers bank 0 can be mapped into 0x4000-0x7FFF.
+type mbc5 struct {
+	rom []byte
+	ram []byte
+
+	// hasRumble is set for rumble carts, where bit 3 of the RAM bank
+	// register drives the motor instead of selecting a bank.
+	hasRumble bool
+
+	ramEnabled bool
+	romBank    uint16
+	ramBank    uint8
+	rumble     bool
+}
+
+func newMBC5(rom, ram []byte, hasRumble bool) *mbc5 {
+	return &mbc5{rom: rom, ram: ram, hasRumble: hasRumble, romBank: 1}
+}
+
+func (m *mbc5) Read8(addr uint16) uint8 {
+	switch {
+	case addr < romBankSize:
+		return romBank(m.rom, 0, addr)
+	case addr <= memory.ROMEnd:
+		return romBank(m.rom, int(m.romBank), addr)
+	default:
+		if !m.ramEnabled {
+			return 0xFF
+		}
+		if i, ok := ramOffset(m.ram, int(m.ramBank), addr); ok {
+			return m.ram[i]
+		}
+		return 0xFF
+	}
+}
+
+func (m *mbc5) Write8(addr uint16, val uint8) {
+	switch {
+	case addr <= 0x1FFF:
+		// MBC5 compares all 8 bits, not just the lower nibble.
+		m.ramEnabled = val == 0x0A
+	case addr <= 0x2FFF:
+		m.romBank = m.romBank&0x100 | uint16(val)
+	case addr <= 0x3FFF:
+		m.romBank = m.romBank&0xFF | uint16(val&0x01)<<8
+	case addr <= 0x5FFF:
+		if m.hasRumble {
+			m.rumble = val&0x08 != 0
+			val &^= 0x08
+		}
+		m.ramBank = val & 0x0F
+	case addr <= memory.ROMEnd:
+	default:
+		if !m.ramEnabled {
+			return
+		}
+		if i, ok := ramOffset(m.ram, int(m.ramBank), addr); ok {
+			m.ram[i] = val
+		}
+	}
+}
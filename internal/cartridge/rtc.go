@@ -0,0 +1,159 @@
+package cartridge
+
+import (
+	"encoding/binary"
+	"fmt"
+	"time"
+)
+
+// MBC3 RTC registers, selected by writing their number to 0x4000-0x5FFF.
+const (
+	rtcSeconds = 0x08
+	rtcMinutes = 0x09
+	rtcHours   = 0x0A
+	rtcDaysLo  = 0x0B
+	rtcDaysHi  = 0x0C // Bit 0 is day bit 8, bit 6 halts the clock, bit 7 is the day carry.
+)
+
+// Bits of the upper day counter register.
+const (
+	rtcDayBit8 = 0x01
+	rtcHalt    = 0x40
+	rtcCarry   = 0x80
+)
+
+// rtcSaveSize is the size of the RTC trailer appended to battery RAM in .sav
+// files, in the layout used by VBA-M, BGB, SameBoy and mGBA: the live and then
+// the latched registers as ten little-endian uint32s, followed by a 64-bit
+// UNIX timestamp of when the file was written.
+const rtcSaveSize = 48
+
+// rtc is the real-time clock on MBC3 cartridges. The live registers keep
+// counting whenever the clock isn't halted, but the CPU reads a copy of them
+// latched by writing 0x00 then 0x01 to 0x6000-0x7FFF.
+type rtc struct {
+	now func() time.Time
+
+	// last is the time at which the live registers were correct. Reading or
+	// writing them first brings them up to date.
+	last time.Time
+
+	live    [5]uint8 // Seconds, minutes, hours, days low, days high.
+	latched [5]uint8
+
+	// latchArmed is set by writing 0x00 to the latch register.
+	latchArmed bool
+}
+
+func newRTC(now func() time.Time) *rtc {
+	return &rtc{now: now, last: now()}
+}
+
+// update advances the live registers by the whole seconds that have passed
+// since they were last updated.
+func (r *rtc) update() {
+	now := r.now()
+	if r.live[4]&rtcHalt != 0 {
+		r.last = now
+		return
+	}
+
+	elapsed := int64(now.Sub(r.last) / time.Second)
+	if elapsed <= 0 {
+		return
+	}
+	r.last = r.last.Add(time.Duration(elapsed) * time.Second)
+	r.advance(elapsed)
+}
+
+// advance moves the live registers forward by secs seconds, setting the carry
+// bit if the 9-bit day counter overflows.
+func (r *rtc) advance(secs int64) {
+	total := int64(r.live[0]) + secs
+	r.live[0] = uint8(total % 60)
+	total = int64(r.live[1]) + total/60
+	r.live[1] = uint8(total % 60)
+	total = int64(r.live[2]) + total/60
+	r.live[2] = uint8(total % 24)
+
+	days := int64(r.live[3]) | int64(r.live[4]&rtcDayBit8)<<8
+	days += total / 24
+	if days > 0x1FF {
+		r.live[4] |= rtcCarry
+		days %= 0x200
+	}
+	r.live[3] = uint8(days)
+	r.live[4] = r.live[4]&^rtcDayBit8 | uint8(days>>8)&rtcDayBit8
+}
+
+// latch copies the live registers into the latched ones on a 0x00 then 0x01
+// write.
+func (r *rtc) latch(val uint8) {
+	if r.latchArmed && val == 0x01 {
+		r.update()
+		r.latched = r.live
+	}
+	r.latchArmed = val == 0x00
+}
+
+// read returns the latched copy of register reg.
+func (r *rtc) read(reg uint8) uint8 {
+	return r.latched[reg-rtcSeconds]
+}
+
+// write sets live register reg, masking off the bits it doesn't have. Like the
+// clock's own counting, the write isn't seen by reads until the next latch.
+func (r *rtc) write(reg, val uint8) {
+	r.update()
+	switch reg {
+	case rtcSeconds:
+		val &= 0x3F
+		// Writing the seconds also resets the sub-second counter.
+		r.last = r.now()
+	case rtcMinutes:
+		val &= 0x3F
+	case rtcHours:
+		val &= 0x1F
+	case rtcDaysHi:
+		val &= rtcDayBit8 | rtcHalt | rtcCarry
+	}
+	r.live[reg-rtcSeconds] = val
+}
+
+// MarshalBinary encodes the clock as a 48-byte .sav trailer.
+func (r *rtc) MarshalBinary() ([]byte, error) {
+	r.update()
+	b := make([]byte, rtcSaveSize)
+	for i, v := range r.live {
+		binary.LittleEndian.PutUint32(b[i*4:], uint32(v))
+	}
+	for i, v := range r.latched {
+		binary.LittleEndian.PutUint32(b[20+i*4:], uint32(v))
+	}
+	binary.LittleEndian.PutUint64(b[40:], uint64(r.last.Unix()))
+	return b, nil
+}
+
+// UnmarshalBinary restores a clock saved by MarshalBinary, running it forward
+// by however long it has been since the save was written. The older 44-byte
+// variant with a 32-bit timestamp is accepted too.
+func (r *rtc) UnmarshalBinary(b []byte) error {
+	if len(b) != rtcSaveSize && len(b) != rtcSaveSize-4 {
+		return fmt.Errorf("RTC save data is %d bytes, want %d", len(b), rtcSaveSize)
+	}
+
+	for i := range r.live {
+		r.live[i] = uint8(binary.LittleEndian.Uint32(b[i*4:]))
+		r.latched[i] = uint8(binary.LittleEndian.Uint32(b[20+i*4:]))
+	}
+
+	var saved int64
+	if len(b) == rtcSaveSize {
+		saved = int64(binary.LittleEndian.Uint64(b[40:]))
+	} else {
+		saved = int64(binary.LittleEndian.Uint32(b[40:]))
+	}
+	r.last = time.Unix(saved, 0)
+	r.update()
+	return nil
+}
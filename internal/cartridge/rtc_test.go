@@ -0,0 +1,165 @@
+package cartridge_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/vsinha/vm/internal/cartridge"
+)
+
+// fakeClock is a clock for cartridge.WithClock that only moves when told to.
+type fakeClock struct {
+	t time.Time
+}
+
+func (f *fakeClock) now() time.Time { return f.t }
+
+func newRTCCartridge(t *testing.T, clock *fakeClock) *cartridge.Cartridge {
+	t.Helper()
+	c, err := cartridge.New(makeROM("CLOCK", cartridge.MBC3TimerRAMBattery, 1, 3, nil), cartridge.WithClock(clock.now))
+	if err != nil {
+		t.Fatalf("cartridge.New() error: %v", err)
+	}
+	c.Write8(0x0000, 0x0A)
+	return c
+}
+
+// latchRTC latches the clock and returns the seconds, minutes, hours, days low
+// and days high registers.
+func latchRTC(c *cartridge.Cartridge) [5]uint8 {
+	c.Write8(0x6000, 0x00)
+	c.Write8(0x6000, 0x01)
+	return readRTC(c)
+}
+
+func readRTC(c *cartridge.Cartridge) [5]uint8 {
+	var regs [5]uint8
+	for i := range regs {
+		c.Write8(0x4000, uint8(0x08+i))
+		regs[i] = c.Read8(0xA000)
+	}
+	return regs
+}
+
+func TestRTC(t *testing.T) {
+	tests := []struct {
+		name    string
+		writes  []access // RAM bank select and value pairs written before time passes.
+		elapsed time.Duration
+		want    [5]uint8
+	}{
+		{"counts", nil, 24*time.Hour + 2*time.Hour + 3*time.Minute + 4*time.Second,
+			[5]uint8{4, 3, 2, 1, 0x00}},
+		{"whole seconds only", nil, 1900 * time.Millisecond,
+			[5]uint8{1, 0, 0, 0, 0x00}},
+		{"day bit 8", nil, 256 * 24 * time.Hour,
+			[5]uint8{0, 0, 0, 0, 0x01}},
+		{"day overflow sets carry", nil, 512*24*time.Hour + 5*time.Second,
+			[5]uint8{5, 0, 0, 0, 0x80}},
+		{"halted", []access{{0x0C, 0x40}}, time.Hour,
+			[5]uint8{0, 0, 0, 0, 0x40}},
+		{"written registers keep counting", []access{{0x08, 59}, {0x09, 59}, {0x0A, 23}, {0x0B, 0xFF}}, time.Second,
+			[5]uint8{0, 0, 0, 0, 0x01}},
+		{"unused bits masked", []access{{0x08, 0xFF}, {0x09, 0xFF}, {0x0A, 0xFF}, {0x0C, 0xFF}}, 0,
+			[5]uint8{0x3F, 0x3F, 0x1F, 0, 0xC1}},
+		{"carry cleared by writing", []access{{0x0C, 0x80}, {0x0C, 0x00}}, 0,
+			[5]uint8{0, 0, 0, 0, 0x00}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			clock := &fakeClock{t: time.Unix(1000000000, 0)}
+			c := newRTCCartridge(t, clock)
+
+			for _, w := range test.writes {
+				c.Write8(0x4000, uint8(w.addr))
+				c.Write8(0xA000, w.val)
+			}
+			clock.t = clock.t.Add(test.elapsed)
+
+			if diff := cmp.Diff(test.want, latchRTC(c)); diff != "" {
+				t.Errorf("latched registers differ (-want,+got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestRTCLatch(t *testing.T) {
+	clock := &fakeClock{t: time.Unix(1000000000, 0)}
+	c := newRTCCartridge(t, clock)
+
+	clock.t = clock.t.Add(10 * time.Second)
+	latchRTC(c)
+	clock.t = clock.t.Add(10 * time.Second)
+	if got := readRTC(c)[0]; got != 10 {
+		t.Errorf("seconds before relatching = %d, want 10", got)
+	}
+
+	// Writing 0x01 without a 0x00 first doesn't latch.
+	c.Write8(0x6000, 0x01)
+	if got := readRTC(c)[0]; got != 10 {
+		t.Errorf("seconds after writing only 0x01 = %d, want 10", got)
+	}
+
+	if got := latchRTC(c)[0]; got != 20 {
+		t.Errorf("seconds after relatching = %d, want 20", got)
+	}
+
+	// Writes go to the live registers, so they only show up once latched.
+	c.Write8(0x4000, 0x09)
+	c.Write8(0xA000, 30)
+	if got := readRTC(c)[1]; got != 0 {
+		t.Errorf("minutes after writing 30 = %d, want 0 until relatched", got)
+	}
+	if got := latchRTC(c)[1]; got != 30 {
+		t.Errorf("minutes after writing 30 and relatching = %d, want 30", got)
+	}
+
+	// The clock registers are behind the RAM enable like the RAM.
+	c.Write8(0x0000, 0x00)
+	c.Write8(0x4000, 0x08)
+	if got := c.Read8(0xA000); got != 0xFF {
+		t.Errorf("seconds with RAM disabled = %02X, want FF", got)
+	}
+}
+
+func TestRTCSave(t *testing.T) {
+	clock := &fakeClock{t: time.Unix(1000000000, 0)}
+	c := newRTCCartridge(t, clock)
+	c.Write8(0x4000, 0x00)
+	c.Write8(0xA000, 0x42)
+	clock.t = clock.t.Add(time.Hour)
+	latchRTC(c)
+
+	save, err := c.MarshalBinary()
+	if err != nil {
+		t.Fatalf("MarshalBinary() error: %v", err)
+	}
+	if got, want := len(save), 32*1024+48; got != want {
+		t.Fatalf("len(MarshalBinary()) = %d, want %d", got, want)
+	}
+
+	// Load the save into a fresh cartridge a day later. The clock should have
+	// kept running while the game was off.
+	clock.t = clock.t.Add(24 * time.Hour)
+	restored := newRTCCartridge(t, clock)
+	if err := restored.UnmarshalBinary(save); err != nil {
+		t.Fatalf("UnmarshalBinary() error: %v", err)
+	}
+
+	if diff := cmp.Diff([5]uint8{0, 0, 1, 0, 0}, readRTC(restored)); diff != "" {
+		t.Errorf("latched registers after restoring differ (-want,+got):\n%s", diff)
+	}
+	if diff := cmp.Diff([5]uint8{0, 0, 1, 1, 0}, latchRTC(restored)); diff != "" {
+		t.Errorf("live registers after restoring differ (-want,+got):\n%s", diff)
+	}
+	restored.Write8(0x4000, 0x00)
+	if got := restored.Read8(0xA000); got != 0x42 {
+		t.Errorf("RAM after restoring = %02X, want 42", got)
+	}
+
+	if err := restored.UnmarshalBinary(save[:100]); err == nil {
+		t.Errorf("UnmarshalBinary() of a short save succeeded, want an error")
+	}
+}
@@ -0,0 +1,228 @@
+// Package dma implements the Game Boy's DMA engines: OAM DMA, which copies
+// sprite attributes into OAM, and the CGB's HDMA, which copies into VRAM.
+package dma
+
+import (
+	"github.com/vsinha/vm/internal/memory"
+	"github.com/vsinha/vm/internal/ppu"
+)
+
+// Register addresses.
+const (
+	DMA   = 0xFF46 // OAM DMA source address divided by 0x100.
+	HDMA1 = 0xFF51 // HDMA source, high byte.
+	HDMA2 = 0xFF52 // HDMA source, low byte.
+	HDMA3 = 0xFF53 // HDMA destination, high byte.
+	HDMA4 = 0xFF54 // HDMA destination, low byte.
+	HDMA5 = 0xFF55 // HDMA length, mode and start.
+)
+
+// Timings, in cycles.
+const (
+	oamStartCycles = 4 // Between writing DMA and the first byte being copied.
+	oamByteCycles  = 4
+	hdmaBlockSize  = 0x10
+	// hdmaBlockCycles is how long the CPU is stalled for each block HDMA
+	// copies.
+	hdmaBlockCycles = 32
+)
+
+// oamSize is the number of bytes OAM DMA copies.
+const oamSize = memory.OAMEnd - memory.OAMStart + 1
+
+// Controller runs the DMA engines. It implements memory.Device and should be
+// attached to DMA and HDMA1 through HDMA5.
+type Controller struct {
+	bus memory.Bus
+	ppu *ppu.PPU
+	cgb bool
+
+	// OAM DMA.
+	oamSource uint8 // The last value written to DMA.
+	oamDelay  uint  // Cycles until the first byte is copied.
+	oamActive bool  // Whether bytes are being copied.
+	oamFrom   uint8 // The source of the copy running, which a restart replaces.
+	oamIndex  int   // The next byte to copy.
+	oamCycles uint  // Cycles towards copying the next byte.
+
+	// HDMA.
+	hdmaSource, hdmaDest uint16
+	hdmaBlocks           int  // Blocks left to copy.
+	hblankMode           bool // Whether an HBlank DMA is running.
+	lastHBlank           uint64
+	stall                uint // Cycles the CPU has to wait for copying.
+}
+
+// Option changes how New sets up a controller.
+type Option func(*Controller)
+
+// WithCGB turns on HDMA, which only the Game Boy Color has.
+func WithCGB() Option {
+	return func(c *Controller) {
+		c.cgb = true
+	}
+}
+
+// New returns a DMA controller that reads from bus and writes to p.
+func New(bus memory.Bus, p *ppu.PPU, opts ...Option) *Controller {
+	c := &Controller{bus: bus, ppu: p, oamSource: 0xFF}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c
+}
+
+// Read8 implements memory.Device.
+func (c *Controller) Read8(addr uint16) uint8 {
+	switch {
+	case addr == DMA:
+		return c.oamSource
+	case addr == HDMA5 && c.cgb:
+		// The bottom bits count down the blocks left minus one, reaching FF
+		// when it's done. Bit 7 is clear while an HBlank DMA is running, so a
+		// stopped one reads back how much it had left.
+		val := uint8(c.hdmaBlocks-1) & 0x7F
+		if !c.hblankMode {
+			val |= 0x80
+		}
+		return val
+	default:
+		// HDMA1-4 are write only.
+		return 0xFF
+	}
+}
+
+// Write8 implements memory.Device.
+func (c *Controller) Write8(addr uint16, val uint8) {
+	if addr == DMA {
+		c.oamSource = val
+		c.oamDelay = oamStartCycles
+		return
+	}
+	if !c.cgb {
+		return
+	}
+
+	switch addr {
+	case HDMA1:
+		c.hdmaSource = uint16(val)<<8 | c.hdmaSource&0x00FF
+	case HDMA2:
+		c.hdmaSource = c.hdmaSource&0xFF00 | uint16(val&0xF0)
+	case HDMA3:
+		c.hdmaDest = uint16(val&0x1F)<<8 | c.hdmaDest&0x00FF
+	case HDMA4:
+		c.hdmaDest = c.hdmaDest&0xFF00 | uint16(val&0xF0)
+	case HDMA5:
+		c.startHDMA(val)
+	}
+}
+
+// startHDMA handles a write to HDMA5.
+func (c *Controller) startHDMA(val uint8) {
+	if c.hblankMode && val&0x80 == 0 {
+		// Clearing bit 7 during an HBlank DMA stops it.
+		c.hblankMode = false
+		return
+	}
+
+	c.hdmaBlocks = int(val&0x7F) + 1
+	if val&0x80 != 0 {
+		c.hblankMode = true
+		c.lastHBlank = c.ppu.HBlanks()
+		// Started in HBlank, or with the LCD off, the first block is
+		// copied straight away rather than waiting for the next HBlank.
+		if c.ppu.Mode() == ppu.HBlank {
+			c.hblankBlock()
+		}
+		return
+	}
+
+	// General purpose DMA copies everything at once, stalling the CPU until
+	// it's done.
+	for c.hdmaBlocks > 0 {
+		c.copyBlock()
+	}
+}
+
+// copyBlock copies 16 bytes of HDMA and stalls the CPU while it does.
+func (c *Controller) copyBlock() {
+	for i := uint16(0); i < hdmaBlockSize; i++ {
+		c.ppu.WriteVRAM(memory.VRAMStart+(c.hdmaDest+i)&0x1FFF, c.bus.Read8(c.hdmaSource+i))
+	}
+	c.hdmaSource += hdmaBlockSize
+	c.hdmaDest += hdmaBlockSize
+	c.hdmaBlocks--
+	c.stall += hdmaBlockCycles
+}
+
+// OAMActive reports whether OAM DMA is copying. While it is the CPU can only
+// reach IO registers and HRAM.
+func (c *Controller) OAMActive() bool {
+	return c.oamActive
+}
+
+// Stall returns how many cycles the CPU has to wait for HDMA, and resets it.
+func (c *Controller) Stall() uint {
+	s := c.stall
+	c.stall = 0
+	return s
+}
+
+// Tick advances the DMA engines by the given number of cycles.
+func (c *Controller) Tick(cycles uint) {
+	c.tickOAM(cycles)
+
+	if c.hblankMode && c.ppu.HBlanks() != c.lastHBlank {
+		c.lastHBlank = c.ppu.HBlanks()
+		c.hblankBlock()
+	}
+}
+
+// hblankBlock copies the next block of an HBlank DMA.
+func (c *Controller) hblankBlock() {
+	c.copyBlock()
+	if c.hdmaBlocks == 0 {
+		c.hblankMode = false
+	}
+}
+
+func (c *Controller) tickOAM(cycles uint) {
+	if c.oamDelay > 0 {
+		// A copy that's already running carries on until the new one
+		// starts.
+		if cycles < c.oamDelay {
+			c.oamDelay -= cycles
+			c.copyOAM(cycles)
+			return
+		}
+		c.copyOAM(c.oamDelay)
+		cycles -= c.oamDelay
+		c.oamDelay = 0
+		c.oamActive = true
+		c.oamFrom = c.oamSource
+		c.oamIndex = 0
+		c.oamCycles = 0
+	}
+	c.copyOAM(cycles)
+}
+
+// copyOAM runs the OAM DMA copy for the given number of cycles.
+func (c *Controller) copyOAM(cycles uint) {
+	if !c.oamActive {
+		return
+	}
+
+	c.oamCycles += cycles
+	for ; c.oamCycles >= oamByteCycles && c.oamIndex < oamSize; c.oamCycles -= oamByteCycles {
+		src := uint16(c.oamFrom)<<8 | uint16(c.oamIndex)
+		if src >= memory.EchoStart {
+			// Sources above WRAM read WRAM's echo.
+			src -= memory.EchoStart - memory.WRAMStart
+		}
+		c.ppu.WriteOAM(c.oamIndex, c.bus.Read8(src))
+		c.oamIndex++
+	}
+	if c.oamIndex == oamSize {
+		c.oamActive = false
+	}
+}
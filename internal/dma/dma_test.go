@@ -0,0 +1,242 @@
+package dma_test
+
+import (
+	"testing"
+
+	"github.com/vsinha/vm/internal/dma"
+	"github.com/vsinha/vm/internal/interrupts"
+	"github.com/vsinha/vm/internal/memory"
+	"github.com/vsinha/vm/internal/ppu"
+)
+
+// setup returns a bus with a PPU attached and a DMA controller for them. WRAM
+// is filled with the low byte of each address.
+func setup(cgb bool) (*memory.MMU, *ppu.PPU, *dma.Controller) {
+	bus := memory.NewMMU(nil)
+	var ppuOpts []ppu.Option
+	var opts []dma.Option
+	if cgb {
+		ppuOpts = append(ppuOpts, ppu.WithCGB())
+		opts = append(opts, dma.WithCGB())
+	}
+	p := ppu.New(interrupts.New(), ppuOpts...)
+	bus.Attach(memory.VRAMStart, memory.VRAMEnd, p)
+	bus.Attach(memory.OAMStart, memory.OAMEnd, p)
+	for addr := uint32(memory.WRAMStart); addr <= memory.WRAMEnd; addr++ {
+		bus.Write8(uint16(addr), uint8(addr))
+	}
+	c := dma.New(bus, p, opts...)
+	bus.Attach(dma.DMA, dma.DMA, c)
+	bus.Attach(dma.HDMA1, dma.HDMA5, c)
+	return bus, p, c
+}
+
+func TestOAMDMA(t *testing.T) {
+	bus, p, c := setup(false)
+	bus.Write8(0xC010, 0x42)
+
+	bus.Write8(dma.DMA, 0xC0)
+	if got := bus.Read8(dma.DMA); got != 0xC0 {
+		t.Errorf("DMA = %02X, want C0", got)
+	}
+	c.Tick(4)
+	if !c.OAMActive() {
+		t.Errorf("OAMActive() = false after the start delay")
+	}
+
+	// One byte every 4 cycles.
+	c.Tick(4 * 0x10)
+	if got := p.Read8(0xFE0F); got != 0x0F {
+		t.Errorf("OAM 0F = %02X, want 0F", got)
+	}
+	if got := p.Read8(0xFE10); got != 0x00 {
+		t.Errorf("OAM 10 = %02X before it was copied, want 00", got)
+	}
+
+	c.Tick(4 * (160 - 0x10))
+	if c.OAMActive() {
+		t.Errorf("OAMActive() = true after 160 bytes")
+	}
+	if got := p.Read8(0xFE10); got != 0x42 {
+		t.Errorf("OAM 10 = %02X, want 42", got)
+	}
+	if got := p.Read8(0xFE9F); got != 0x9F {
+		t.Errorf("OAM 9F = %02X, want 9F", got)
+	}
+}
+
+func TestOAMDMAEcho(t *testing.T) {
+	bus, p, c := setup(false)
+	bus.Write8(0xC005, 0x42)
+
+	// Sources above WRAM read its echo, even 0xFE00 and up.
+	bus.Write8(dma.DMA, 0xE0)
+	c.Tick(4 + 4*160)
+	if got := p.Read8(0xFE05); got != 0x42 {
+		t.Errorf("OAM 05 = %02X, want 42", got)
+	}
+}
+
+func TestOAMDMARestart(t *testing.T) {
+	bus, p, c := setup(false)
+	bus.Write8(0xC010, 0x42)
+	bus.Write8(0xC100, 0x99)
+
+	bus.Write8(dma.DMA, 0xC0)
+	c.Tick(4 + 4*0x10)
+
+	// The first copy carries on through the start delay of the second, then
+	// the second starts over from the beginning.
+	bus.Write8(dma.DMA, 0xC1)
+	c.Tick(4)
+	if !c.OAMActive() {
+		t.Errorf("OAMActive() = false during the restart delay")
+	}
+	if got := p.Read8(0xFE10); got != 0x42 {
+		t.Errorf("OAM 10 = %02X during the restart delay, want 42", got)
+	}
+	c.Tick(4)
+	if got := p.Read8(0xFE00); got != 0x99 {
+		t.Errorf("OAM 00 = %02X after restarting, want 99", got)
+	}
+	c.Tick(4 * 159)
+	if c.OAMActive() {
+		t.Errorf("OAMActive() = true after 160 bytes of the second copy")
+	}
+	if got := p.Read8(0xFE10); got != 0x10 {
+		t.Errorf("OAM 10 = %02X after the second copy, want 10", got)
+	}
+}
+
+// startHDMA writes the HDMA registers.
+func startHDMA(bus memory.Bus, src, dst uint16, hdma5 uint8) {
+	bus.Write8(dma.HDMA1, uint8(src>>8))
+	bus.Write8(dma.HDMA2, uint8(src))
+	bus.Write8(dma.HDMA3, uint8(dst>>8))
+	bus.Write8(dma.HDMA4, uint8(dst))
+	bus.Write8(dma.HDMA5, hdma5)
+}
+
+func TestGeneralPurposeDMA(t *testing.T) {
+	bus, p, c := setup(true)
+
+	// The low 4 bits of both addresses are ignored, as are the top 3 bits of
+	// the destination.
+	startHDMA(bus, 0xC10F, 0xE208, 0x01)
+	if got := c.Stall(); got != 64 {
+		t.Errorf("Stall() = %d, want 64", got)
+	}
+	if got := c.Stall(); got != 0 {
+		t.Errorf("second Stall() = %d, want 0", got)
+	}
+	for _, addr := range []uint16{0x8200, 0x821F} {
+		if got, want := p.Read8(addr), uint8(addr); got != want {
+			t.Errorf("VRAM %04X = %02X, want %02X", addr, got, want)
+		}
+	}
+	if got := p.Read8(0x8220); got != 0x00 {
+		t.Errorf("VRAM 8220 = %02X, want 00", got)
+	}
+	if got := bus.Read8(dma.HDMA5); got != 0xFF {
+		t.Errorf("HDMA5 = %02X when done, want FF", got)
+	}
+}
+
+func TestHBlankDMA(t *testing.T) {
+	bus, p, c := setup(true)
+	p.Write8(ppu.LCDC, 0x80)
+
+	startHDMA(bus, 0xC000, 0x8000, 0x81)
+	if got := bus.Read8(dma.HDMA5); got != 0x01 {
+		t.Errorf("HDMA5 = %02X after starting, want 01", got)
+	}
+
+	// tick runs the PPU and DMA through one line.
+	tick := func() {
+		for i := 0; i < 456/4; i++ {
+			p.Tick(4)
+			c.Tick(4)
+		}
+	}
+
+	tick()
+	if got := p.Read8(0x800F); got != 0x0F {
+		t.Errorf("VRAM 800F = %02X after one HBlank, want 0F", got)
+	}
+	if got := p.Read8(0x8010); got != 0x00 {
+		t.Errorf("VRAM 8010 = %02X after one HBlank, want 00", got)
+	}
+	if got := c.Stall(); got != 32 {
+		t.Errorf("Stall() = %d, want 32", got)
+	}
+	if got := bus.Read8(dma.HDMA5); got != 0x00 {
+		t.Errorf("HDMA5 = %02X after one HBlank, want 00", got)
+	}
+
+	tick()
+	if got := p.Read8(0x801F); got != 0x1F {
+		t.Errorf("VRAM 801F = %02X after two HBlanks, want 1F", got)
+	}
+	if got := bus.Read8(dma.HDMA5); got != 0xFF {
+		t.Errorf("HDMA5 = %02X when done, want FF", got)
+	}
+
+	// Stopping a transfer part of the way through.
+	startHDMA(bus, 0xC000, 0x9000, 0x83)
+	tick()
+	bus.Write8(dma.HDMA5, 0x00)
+	if got := bus.Read8(dma.HDMA5); got != 0x82 {
+		t.Errorf("HDMA5 = %02X after stopping, want 82", got)
+	}
+	tick()
+	if got := p.Read8(0x9010); got != 0x00 {
+		t.Errorf("VRAM 9010 = %02X, want 00 (copied after stopping)", got)
+	}
+}
+
+func TestHBlankDMAStartsInHBlank(t *testing.T) {
+	for _, tc := range []struct {
+		name  string
+		setup func(p *ppu.PPU)
+	}{
+		{"LCD off", func(p *ppu.PPU) {}},
+		{"in HBlank", func(p *ppu.PPU) {
+			p.Write8(ppu.LCDC, 0x80)
+			for p.Mode() != ppu.HBlank {
+				p.Tick(4)
+			}
+		}},
+	} {
+		bus, p, c := setup(true)
+		tc.setup(p)
+
+		startHDMA(bus, 0xC000, 0x8000, 0x82)
+		if got := p.Read8(0x800F); got != 0x0F {
+			t.Errorf("%s: VRAM 800F = %02X after starting, want 0F", tc.name, got)
+		}
+		if got := bus.Read8(dma.HDMA5); got != 0x01 {
+			t.Errorf("%s: HDMA5 = %02X after starting, want 01", tc.name, got)
+		}
+
+		// The rest of the HBlank doesn't copy another block.
+		p.Tick(4)
+		c.Tick(4)
+		if got := p.Read8(0x8010); got != 0x00 {
+			t.Errorf("%s: VRAM 8010 = %02X, want 00", tc.name, got)
+		}
+	}
+}
+
+func TestNoHDMAOnDMG(t *testing.T) {
+	bus, p, c := setup(false)
+	startHDMA(bus, 0xC000, 0x8000, 0x00)
+	if got := p.Read8(0x8001); got != 0x00 {
+		t.Errorf("VRAM 8001 = %02X, want 00", got)
+	}
+	if got := c.Stall(); got != 0 {
+		t.Errorf("Stall() = %d, want 0", got)
+	}
+	if got := bus.Read8(dma.HDMA5); got != 0xFF {
+		t.Errorf("HDMA5 = %02X, want FF", got)
+	}
+}
@@ -0,0 +1,158 @@
+// Package interrupts implements the Game Boy's interrupt controller: the IE
+// and IF registers and the CPU's interrupt master enable flag (IME).
+package interrupts
+
+import "github.com/vsinha/vm/internal/memory"
+
+// Register addresses.
+const (
+	FlagAddr   = 0xFF0F    // IF, which interrupts have been requested.
+	EnableAddr = memory.IE // IE, which interrupts may be dispatched.
+)
+
+// DispatchCycles is how many cycles it takes the CPU to dispatch an
+// interrupt: two wait states, pushing the PC and jumping to the vector.
+const DispatchCycles = 20
+
+// Interrupt is one of the five interrupt sources. Its value is its bit in the
+// IE and IF registers, and lower bits have higher priority.
+type Interrupt uint8
+
+// Interrupt sources, highest priority first.
+const (
+	VBlank Interrupt = 1 << iota
+	LCDStat
+	Timer
+	Serial
+	Joypad
+)
+
+// all is every bit of IE and IF that has an interrupt behind it.
+const all = 0x1F
+
+// Vector returns the address the CPU jumps to when dispatching i.
+func (i Interrupt) Vector() uint16 {
+	switch i {
+	case VBlank:
+		return 0x40
+	case LCDStat:
+		return 0x48
+	case Timer:
+		return 0x50
+	case Serial:
+		return 0x58
+	default:
+		return 0x60
+	}
+}
+
+func (i Interrupt) String() string {
+	switch i {
+	case VBlank:
+		return "VBlank"
+	case LCDStat:
+		return "LCD STAT"
+	case Timer:
+		return "Timer"
+	case Serial:
+		return "Serial"
+	case Joypad:
+		return "Joypad"
+	default:
+		return "unknown interrupt"
+	}
+}
+
+// Controller holds the interrupt registers. It implements memory.Device and
+// should be attached to FlagAddr and EnableAddr.
+type Controller struct {
+	ie  uint8
+	iff uint8
+	ime bool
+
+	// eiDelay counts down the instructions until EI takes effect.
+	eiDelay int
+}
+
+// New returns a controller with every interrupt disabled.
+func New() *Controller {
+	return &Controller{}
+}
+
+// Read8 implements memory.Device.
+func (c *Controller) Read8(addr uint16) uint8 {
+	if addr == FlagAddr {
+		// The top three bits of IF aren't connected and read as 1s.
+		return c.iff | ^uint8(all)
+	}
+	return c.ie
+}
+
+// Write8 implements memory.Device.
+func (c *Controller) Write8(addr uint16, val uint8) {
+	if addr == FlagAddr {
+		c.iff = val & all
+		return
+	}
+	// All eight bits of IE can be written even though only five do anything.
+	c.ie = val
+}
+
+// Request sets i's bit in IF. Peripherals call this to raise an interrupt.
+func (c *Controller) Request(i Interrupt) {
+	c.iff |= uint8(i)
+}
+
+// Pending returns the highest priority interrupt that is both requested and
+// enabled, whatever the state of IME.
+func (c *Controller) Pending() (Interrupt, bool) {
+	p := c.ie & c.iff & all
+	if p == 0 {
+		return 0, false
+	}
+	// Isolate the lowest set bit.
+	return Interrupt(p & -p), true
+}
+
+// Acknowledge clears i's bit in IF and IME, as the CPU does when it
+// dispatches i.
+func (c *Controller) Acknowledge(i Interrupt) {
+	c.iff &^= uint8(i)
+	c.ime = false
+	c.eiDelay = 0
+}
+
+// IME reports whether the CPU will dispatch pending interrupts.
+func (c *Controller) IME() bool {
+	return c.ime
+}
+
+// EnableDelayed sets IME after the next instruction, as EI does.
+func (c *Controller) EnableDelayed() {
+	if !c.ime && c.eiDelay == 0 {
+		c.eiDelay = 2
+	}
+}
+
+// Enable sets IME straight away, as RETI does.
+func (c *Controller) Enable() {
+	c.ime = true
+	c.eiDelay = 0
+}
+
+// Disable clears IME, cancelling an EI that hasn't taken effect yet.
+func (c *Controller) Disable() {
+	c.ime = false
+	c.eiDelay = 0
+}
+
+// Step is called by the CPU after every instruction to count down EI's delay.
+func (c *Controller) Step() {
+	if c.eiDelay == 0 {
+		return
+	}
+	c.eiDelay--
+	if c.eiDelay == 0 {
+		c.ime = true
+	}
+}
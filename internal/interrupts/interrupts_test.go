@@ -0,0 +1,114 @@
+package interrupts_test
+
+import (
+	"testing"
+
+	"github.com/vsinha/vm/internal/interrupts"
+)
+
+func TestRegisters(t *testing.T) {
+	c := interrupts.New()
+
+	c.Write8(interrupts.FlagAddr, 0xFF)
+	if got := c.Read8(interrupts.FlagAddr); got != 0xFF {
+		t.Errorf("IF = %02X after writing FF, want FF", got)
+	}
+	c.Write8(interrupts.FlagAddr, 0x00)
+	if got := c.Read8(interrupts.FlagAddr); got != 0xE0 {
+		t.Errorf("IF = %02X after writing 00, want E0", got)
+	}
+	c.Request(interrupts.Timer)
+	if got := c.Read8(interrupts.FlagAddr); got != 0xE4 {
+		t.Errorf("IF = %02X after requesting Timer, want E4", got)
+	}
+
+	c.Write8(interrupts.EnableAddr, 0xFF)
+	if got := c.Read8(interrupts.EnableAddr); got != 0xFF {
+		t.Errorf("IE = %02X after writing FF, want FF", got)
+	}
+}
+
+func TestPending(t *testing.T) {
+	tests := []struct {
+		name   string
+		ie, rq uint8
+		want   interrupts.Interrupt
+		ok     bool
+	}{
+		{"nothing requested", 0x1F, 0x00, 0, false},
+		{"not enabled", 0x00, 0x1F, 0, false},
+		{"VBlank beats everything", 0x1F, 0x1F, interrupts.VBlank, true},
+		{"Timer beats Serial", 0x1F, 0x0C, interrupts.Timer, true},
+		{"highest enabled", 0x18, 0x1F, interrupts.Serial, true},
+		{"Joypad", 0x10, 0x10, interrupts.Joypad, true},
+		{"unused bits ignored", 0xE0, 0xE0, 0, false},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			c := interrupts.New()
+			c.Write8(interrupts.EnableAddr, test.ie)
+			c.Write8(interrupts.FlagAddr, test.rq)
+
+			got, ok := c.Pending()
+			if got != test.want || ok != test.ok {
+				t.Errorf("Pending() = %v, %v, want %v, %v", got, ok, test.want, test.ok)
+			}
+		})
+	}
+}
+
+func TestVectors(t *testing.T) {
+	want := map[interrupts.Interrupt]uint16{
+		interrupts.VBlank:  0x40,
+		interrupts.LCDStat: 0x48,
+		interrupts.Timer:   0x50,
+		interrupts.Serial:  0x58,
+		interrupts.Joypad:  0x60,
+	}
+	for i, addr := range want {
+		if got := i.Vector(); got != addr {
+			t.Errorf("%v.Vector() = %04X, want %04X", i, got, addr)
+		}
+	}
+}
+
+func TestIME(t *testing.T) {
+	c := interrupts.New()
+
+	c.EnableDelayed()
+	if c.IME() {
+		t.Errorf("IME set straight after EI")
+	}
+	c.Step() // EI itself.
+	if c.IME() {
+		t.Errorf("IME set before the instruction after EI ran")
+	}
+	c.Step() // The instruction after EI.
+	if !c.IME() {
+		t.Errorf("IME not set after the instruction after EI ran")
+	}
+
+	c.Request(interrupts.LCDStat)
+	c.Acknowledge(interrupts.LCDStat)
+	if c.IME() {
+		t.Errorf("IME still set after dispatching an interrupt")
+	}
+	if got := c.Read8(interrupts.FlagAddr); got != 0xE0 {
+		t.Errorf("IF = %02X after dispatching, want E0", got)
+	}
+
+	// DI straight after EI cancels it.
+	c.EnableDelayed()
+	c.Step()
+	c.Disable()
+	c.Step()
+	if c.IME() {
+		t.Errorf("IME set after EI; DI")
+	}
+
+	c.Enable()
+	if !c.IME() {
+		t.Errorf("IME not set straight after RETI")
+	}
+}
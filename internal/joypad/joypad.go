@@ -0,0 +1,124 @@
+// Package joypad implements the Game Boy's buttons and d-pad and the P1
+// register the CPU reads them through.
+package joypad
+
+import (
+	"strings"
+
+	"github.com/vsinha/vm/internal/interrupts"
+)
+
+// P1 is the address of the joypad register, also known as JOYP.
+const P1 = 0xFF00
+
+// P1 selects the d-pad when bit 4 is low and the buttons when bit 5 is low.
+const (
+	selectDPad    = 0x10
+	selectButtons = 0x20
+)
+
+// Button is a set of the Game Boy's buttons and d-pad directions. The d-pad is
+// in the low nibble and the buttons in the high one, in the order P1 reports
+// them.
+type Button uint8
+
+// The buttons.
+const (
+	Right Button = 1 << iota
+	Left
+	Up
+	Down
+	A
+	B
+	Select
+	Start
+)
+
+var names = [8]string{"right", "left", "up", "down", "a", "b", "select", "start"}
+
+func (b Button) String() string {
+	var s []string
+	for i, name := range names {
+		if b&(1<<uint(i)) != 0 {
+			s = append(s, name)
+		}
+	}
+	if len(s) == 0 {
+		return "none"
+	}
+	return strings.Join(s, "+")
+}
+
+// Joypad is the button matrix. It implements memory.Device and should be
+// attached to P1.
+type Joypad struct {
+	ic *interrupts.Controller
+
+	pressed Button
+	// sel holds bits 4 and 5 of P1, which select the d-pad, the buttons,
+	// both or neither.
+	sel uint8
+}
+
+// New returns a joypad with nothing pressed, that requests its interrupt from
+// ic.
+func New(ic *interrupts.Controller) *Joypad {
+	return &Joypad{ic: ic, sel: selectDPad | selectButtons}
+}
+
+// lines returns the low nibble of P1: a bit is 0 when a selected button in
+// that position is held.
+func (j *Joypad) lines() uint8 {
+	var low uint8
+	if j.sel&selectDPad == 0 {
+		low |= uint8(j.pressed) & 0x0F
+	}
+	if j.sel&selectButtons == 0 {
+		low |= uint8(j.pressed) >> 4
+	}
+	return ^low & 0x0F
+}
+
+// update runs fn, which changes the buttons or the selection, and requests the
+// joypad interrupt if any of P1's input lines went from high to low.
+func (j *Joypad) update(fn func()) {
+	before := j.lines()
+	fn()
+	if before&^j.lines() != 0 {
+		j.ic.Request(interrupts.Joypad)
+	}
+}
+
+// Read8 implements memory.Device.
+func (j *Joypad) Read8(addr uint16) uint8 {
+	return 0xC0 | j.sel | j.lines()
+}
+
+// Write8 implements memory.Device. Only the selection bits can be written.
+func (j *Joypad) Write8(addr uint16, val uint8) {
+	j.update(func() {
+		j.sel = val & (selectDPad | selectButtons)
+	})
+}
+
+// Press holds down the given buttons, leaving the others alone.
+func (j *Joypad) Press(b Button) {
+	j.Set(j.pressed | b)
+}
+
+// Release lets go of the given buttons, leaving the others alone.
+func (j *Joypad) Release(b Button) {
+	j.Set(j.pressed &^ b)
+}
+
+// Set holds down exactly the given buttons.
+func (j *Joypad) Set(b Button) {
+	j.update(func() {
+		j.pressed = b
+	})
+}
+
+// Pressed returns the buttons being held down.
+func (j *Joypad) Pressed() Button {
+	return j.pressed
+}
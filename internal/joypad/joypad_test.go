@@ -0,0 +1,132 @@
+package joypad_test
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/vsinha/vm/internal/interrupts"
+	"github.com/vsinha/vm/internal/joypad"
+)
+
+func TestRead(t *testing.T) {
+	tests := []struct {
+		name    string
+		pressed joypad.Button
+		sel     uint8
+		want    uint8
+	}{
+		{"nothing selected", joypad.A | joypad.Right, 0x30, 0xFF},
+		{"d-pad, nothing held", 0, 0x20, 0xEF},
+		{"d-pad", joypad.Right | joypad.Down | joypad.Start, 0x20, 0xE6},
+		{"buttons", joypad.A | joypad.Start | joypad.Up, 0x10, 0xD6},
+		{"both", joypad.B | joypad.Left, 0x00, 0xCD},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			j := joypad.New(interrupts.New())
+			j.Set(tc.pressed)
+			j.Write8(joypad.P1, tc.sel|0xCF)
+			if got := j.Read8(joypad.P1); got != tc.want {
+				t.Errorf("P1 = %02X, want %02X", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestPressRelease(t *testing.T) {
+	j := joypad.New(interrupts.New())
+	j.Press(joypad.A | joypad.B)
+	j.Press(joypad.Up)
+	j.Release(joypad.A)
+	if got, want := j.Pressed(), joypad.B|joypad.Up; got != want {
+		t.Errorf("Pressed() = %v, want %v", got, want)
+	}
+}
+
+func TestInterrupt(t *testing.T) {
+	ic := interrupts.New()
+	j := joypad.New(ic)
+	requested := func() bool {
+		defer ic.Write8(interrupts.FlagAddr, 0)
+		return ic.Read8(interrupts.FlagAddr)&uint8(interrupts.Joypad) != 0
+	}
+
+	// Nothing is selected, so no line goes low.
+	j.Press(joypad.Start)
+	if requested() {
+		t.Errorf("interrupt requested with no lines selected")
+	}
+
+	// Selecting the buttons pulls Start's line low.
+	j.Write8(joypad.P1, 0x10)
+	if !requested() {
+		t.Errorf("interrupt not requested selecting a held button")
+	}
+
+	// A line that is already low doesn't go low again.
+	j.Press(joypad.Select | joypad.Up)
+	if !requested() {
+		t.Errorf("interrupt not requested pressing Select")
+	}
+	j.Press(joypad.Right)
+	if requested() {
+		t.Errorf("interrupt requested pressing an unselected direction")
+	}
+	j.Release(joypad.Select)
+	if requested() {
+		t.Errorf("interrupt requested releasing a button")
+	}
+}
+
+func TestParseScript(t *testing.T) {
+	script := `# Press start on the title screen.
+0
+120 start
+125   # Let go.
+300 Right, B
+`
+	got, err := joypad.ParseScript(strings.NewReader(script))
+	if err != nil {
+		t.Fatalf("ParseScript() error: %v", err)
+	}
+	want := joypad.Script{
+		{Frame: 0},
+		{Frame: 120, Buttons: joypad.Start},
+		{Frame: 125},
+		{Frame: 300, Buttons: joypad.Right | joypad.B},
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("ParseScript() mismatch (-want,+got):\n%s", diff)
+	}
+
+	for _, tc := range []struct {
+		frame uint64
+		want  joypad.Button
+	}{
+		{0, 0},
+		{119, 0},
+		{120, joypad.Start},
+		{124, joypad.Start},
+		{125, 0},
+		{1000, joypad.Right | joypad.B},
+	} {
+		if got := got.Buttons(tc.frame); got != tc.want {
+			t.Errorf("Buttons(%d) = %v, want %v", tc.frame, got, tc.want)
+		}
+	}
+}
+
+func TestParseScriptErrors(t *testing.T) {
+	for _, script := range []string{
+		"start",
+		"10 jump",
+		"10 a\n5 b",
+		"10 a\n10 b",
+	} {
+		if _, err := joypad.ParseScript(strings.NewReader(script)); !errors.Is(err, joypad.ErrScript) {
+			t.Errorf("ParseScript(%q) error = %v, want %v", script, err, joypad.ErrScript)
+		}
+	}
+}
@@ -0,0 +1,96 @@
+package joypad
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// ErrScript is wrapped by the errors ParseScript returns for badly formed
+// scripts.
+var ErrScript = errors.New("bad input script")
+
+// Event holds down exactly Buttons from Frame on.
+type Event struct {
+	Frame   uint64
+	Buttons Button
+}
+
+// Script is a list of events, sorted by frame, that drive the joypad
+// deterministically.
+//
+// In text form, each line holds a frame number followed by the buttons held
+// from that frame on, separated by spaces or commas. A line with no buttons
+// releases everything. Blank lines and anything after a # are ignored:
+//
+//	# Wait for the title screen, then press start.
+//	120 start
+//	125
+//	# Walk right while holding B.
+//	300 right b
+//	420
+type Script []Event
+
+// ParseScript reads a script in text form. The frames must be in ascending
+// order.
+func ParseScript(r io.Reader) (Script, error) {
+	var s Script
+	sc := bufio.NewScanner(r)
+	for line := 1; sc.Scan(); line++ {
+		text := sc.Text()
+		if i := strings.IndexByte(text, '#'); i >= 0 {
+			text = text[:i]
+		}
+		fields := strings.FieldsFunc(text, func(r rune) bool {
+			return r == ' ' || r == '\t' || r == ','
+		})
+		if len(fields) == 0 {
+			continue
+		}
+
+		frame, err := strconv.ParseUint(fields[0], 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: frame %q is not a number: %w", line, fields[0], ErrScript)
+		}
+		if len(s) > 0 && frame <= s[len(s)-1].Frame {
+			return nil, fmt.Errorf("line %d: frame %d is not after frame %d: %w", line, frame, s[len(s)-1].Frame, ErrScript)
+		}
+		e := Event{Frame: frame}
+		for _, f := range fields[1:] {
+			b, err := ParseButton(f)
+			if err != nil {
+				return nil, fmt.Errorf("line %d: %w", line, err)
+			}
+			e.Buttons |= b
+		}
+		s = append(s, e)
+	}
+	if err := sc.Err(); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// ParseButton returns the button with the given name, such as "a" or "start".
+// Case is ignored.
+func ParseButton(name string) (Button, error) {
+	for i, n := range names {
+		if strings.EqualFold(name, n) {
+			return 1 << uint(i), nil
+		}
+	}
+	return 0, fmt.Errorf("unknown button %q: %w", name, ErrScript)
+}
+
+// Buttons returns the buttons the script holds down at the given frame.
+func (s Script) Buttons(frame uint64) Button {
+	i := sort.Search(len(s), func(i int) bool { return s[i].Frame > frame })
+	if i == 0 {
+		return 0
+	}
+	return s[i-1].Buttons
+}
@@ -0,0 +1,63 @@
+package memory
+
+import "io"
+
+// The Game Boy's 16-bit address space. Each region runs from its Start to its
+// End inclusive.
+const (
+	ROMStart      = 0x0000 // Cartridge ROM, bank 0 and the switchable bank.
+	ROMEnd        = 0x7FFF
+	VRAMStart     = 0x8000 // Video RAM.
+	VRAMEnd       = 0x9FFF
+	ExtRAMStart   = 0xA000 // External (cartridge) RAM.
+	ExtRAMEnd     = 0xBFFF
+	WRAMStart     = 0xC000 // Work RAM.
+	WRAMEnd       = 0xDFFF
+	EchoStart     = 0xE000 // Mirror of 0xC000-0xDDFF.
+	EchoEnd       = 0xFDFF
+	OAMStart      = 0xFE00 // Sprite attribute table.
+	OAMEnd        = 0xFE9F
+	UnusableStart = 0xFEA0 // Not connected to anything.
+	UnusableEnd   = 0xFEFF
+	IOStart       = 0xFF00 // Hardware IO registers.
+	IOEnd         = 0xFF7F
+	HRAMStart     = 0xFF80 // High RAM.
+	HRAMEnd       = 0xFFFE
+	IE            = 0xFFFF // Interrupt enable register.
+)
+
+// Bus is the CPU's view of memory. 16-bit values are little-endian.
+type Bus interface {
+	Read8(addr uint16) uint8
+	Write8(addr uint16, val uint8)
+	Read16(addr uint16) uint16
+	Write16(addr uint16, val uint16)
+}
+
+// Device is anything that can be attached to a range of addresses on the bus,
+// such as a cartridge or a peripheral's IO registers. Addresses are passed
+// through unchanged, not relative to the start of the range.
+type Device interface {
+	Read8(addr uint16) uint8
+	Write8(addr uint16, val uint8)
+}
+
+// NewReader returns an io.Reader that reads sequential bytes off b starting at
+// addr, wrapping around at the top of the address space. It never returns an
+// error.
+func NewReader(b Bus, addr uint16) io.Reader {
+	return &busReader{b: b, addr: addr}
+}
+
+type busReader struct {
+	b    Bus
+	addr uint16
+}
+
+func (r *busReader) Read(p []byte) (int, error) {
+	for i := range p {
+		p[i] = r.b.Read8(r.addr)
+		r.addr++
+	}
+	return len(p), nil
+}
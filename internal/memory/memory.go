@@ -16,6 +16,18 @@ func (m Memory) ReadAt(p []byte, off int64) (n int, err error) {
 	return len(p), nil
 }
 
+// Read8 implements Device, so a Memory can be attached to the ROM region of an
+// MMU. Like ReadAt, addresses past the end of the memory read as 0x00.
+func (m Memory) Read8(addr uint16) uint8 {
+	if int(addr) >= len(m) {
+		return 0x00
+	}
+	return m[addr]
+}
+
+// Write8 implements Device. Memory is attached as ROM so writes are ignored.
+func (m Memory) Write8(addr uint16, val uint8) {}
+
 /*
 func (m Memory) String() string {
 	b := &strings.Builder{}
@@ -0,0 +1,131 @@
+package memory
+
+// MMU routes reads and writes on the bus to the region of memory, or the
+// attached Device, that owns each address. Anything that isn't owned by a
+// Device is backed by plain RAM inside the MMU.
+type MMU struct {
+	// route holds, for every address, an index into devices. Index 0 is the
+	// MMU's own memory.
+	route   [0x10000]uint8
+	devices []Device
+
+	vram   [VRAMEnd - VRAMStart + 1]uint8
+	extRAM [ExtRAMEnd - ExtRAMStart + 1]uint8
+	wram   [WRAMEnd - WRAMStart + 1]uint8
+	oam    [OAMEnd - OAMStart + 1]uint8
+	io     [IOEnd - IOStart + 1]uint8
+	hram   [HRAMEnd - HRAMStart + 1]uint8
+	ie     uint8
+}
+
+// NewMMU returns an MMU with rom attached to the ROM region. rom may be nil or
+// an empty Memory, in which case the ROM region reads as an empty cartridge
+// slot.
+func NewMMU(rom Device) *MMU {
+	m := &MMU{}
+	m.devices = append(m.devices, (*mmuMemory)(m))
+	if mem, ok := rom.(Memory); ok && len(mem) == 0 {
+		rom = nil
+	}
+	if rom != nil {
+		m.Attach(ROMStart, ROMEnd, rom)
+	}
+	return m
+}
+
+// Attach hands the addresses from lo to hi inclusive to d, replacing whatever
+// owned them before. Peripherals use this to hook their IO registers.
+func (m *MMU) Attach(lo, hi uint16, d Device) {
+	if len(m.devices) > 0xFF {
+		panic("memory: too many devices attached to the MMU")
+	}
+	idx := len(m.devices)
+	m.devices = append(m.devices, d)
+
+	for addr := uint32(lo); addr <= uint32(hi); addr++ {
+		m.route[addr] = uint8(idx)
+	}
+}
+
+// Detach hands the addresses from lo to hi inclusive back to the MMU's own
+// memory. In the ROM region that reads as an empty cartridge slot.
+func (m *MMU) Detach(lo, hi uint16) {
+	for addr := uint32(lo); addr <= uint32(hi); addr++ {
+		m.route[addr] = 0
+	}
+}
+
+// Read8 implements Bus.
+func (m *MMU) Read8(addr uint16) uint8 {
+	return m.devices[m.route[addr]].Read8(addr)
+}
+
+// Write8 implements Bus.
+func (m *MMU) Write8(addr uint16, val uint8) {
+	m.devices[m.route[addr]].Write8(addr, val)
+}
+
+// Read16 implements Bus.
+func (m *MMU) Read16(addr uint16) uint16 {
+	return uint16(m.Read8(addr)) | uint16(m.Read8(addr+1))<<8
+}
+
+// Write16 implements Bus.
+func (m *MMU) Write16(addr uint16, val uint16) {
+	m.Write8(addr, uint8(val))
+	m.Write8(addr+1, uint8(val>>8))
+}
+
+// mmuMemory is the Device for the memory the MMU backs itself.
+type mmuMemory MMU
+
+func (m *mmuMemory) Read8(addr uint16) uint8 {
+	switch {
+	case addr <= ROMEnd:
+		// Nothing attached to the ROM region, an empty cartridge slot.
+		return 0xFF
+	case addr <= VRAMEnd:
+		return m.vram[addr-VRAMStart]
+	case addr <= ExtRAMEnd:
+		return m.extRAM[addr-ExtRAMStart]
+	case addr <= WRAMEnd:
+		return m.wram[addr-WRAMStart]
+	case addr <= EchoEnd:
+		return m.wram[addr-EchoStart]
+	case addr <= OAMEnd:
+		return m.oam[addr-OAMStart]
+	case addr <= UnusableEnd:
+		return 0x00
+	case addr <= IOEnd:
+		return m.io[addr-IOStart]
+	case addr <= HRAMEnd:
+		return m.hram[addr-HRAMStart]
+	default:
+		return m.ie
+	}
+}
+
+func (m *mmuMemory) Write8(addr uint16, val uint8) {
+	switch {
+	case addr <= ROMEnd:
+		// ROM is read only.
+	case addr <= VRAMEnd:
+		m.vram[addr-VRAMStart] = val
+	case addr <= ExtRAMEnd:
+		m.extRAM[addr-ExtRAMStart] = val
+	case addr <= WRAMEnd:
+		m.wram[addr-WRAMStart] = val
+	case addr <= EchoEnd:
+		m.wram[addr-EchoStart] = val
+	case addr <= OAMEnd:
+		m.oam[addr-OAMStart] = val
+	case addr <= UnusableEnd:
+		// Writes to the unusable region go nowhere.
+	case addr <= IOEnd:
+		m.io[addr-IOStart] = val
+	case addr <= HRAMEnd:
+		m.hram[addr-HRAMStart] = val
+	default:
+		m.ie = val
+	}
+}
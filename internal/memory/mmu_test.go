@@ -0,0 +1,129 @@
+package memory_test
+
+import (
+	"testing"
+
+	"github.com/vsinha/vm/internal/memory"
+)
+
+// register is a Device that records the last write it saw.
+type register struct {
+	addr uint16
+	val  uint8
+}
+
+func (r *register) Read8(addr uint16) uint8 {
+	return r.val
+}
+
+func (r *register) Write8(addr uint16, val uint8) {
+	r.addr, r.val = addr, val
+}
+
+func TestMMU(t *testing.T) {
+	m := memory.NewMMU(memory.Memory{0x00, 0xC3, 0x50, 0x01})
+
+	tests := []struct {
+		name  string
+		write uint16
+		val   uint8
+		read  uint16
+		want  uint8
+	}{
+		{"ROM is read only", 0x0001, 0xFF, 0x0001, 0xC3},
+		{"past the end of ROM", 0x7FFF, 0xFF, 0x7FFF, 0x00},
+		{"VRAM", 0x8000, 0x12, 0x8000, 0x12},
+		{"external RAM", 0xBFFF, 0x34, 0xBFFF, 0x34},
+		{"WRAM", 0xC123, 0x56, 0xC123, 0x56},
+		{"WRAM shows through echo", 0xC456, 0x78, 0xE456, 0x78},
+		{"echo writes through to WRAM", 0xFDFF, 0x9A, 0xDDFF, 0x9A},
+		{"OAM", 0xFE9F, 0xBC, 0xFE9F, 0xBC},
+		{"unusable", 0xFEA0, 0xDE, 0xFEA0, 0x00},
+		{"IO", 0xFF40, 0x91, 0xFF40, 0x91},
+		{"HRAM", 0xFFFE, 0xF0, 0xFFFE, 0xF0},
+		{"IE", 0xFFFF, 0x1F, 0xFFFF, 0x1F},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			m.Write8(test.write, test.val)
+			if got := m.Read8(test.read); got != test.want {
+				t.Errorf("Read8(%04X) = %02X, want %02X", test.read, got, test.want)
+			}
+		})
+	}
+}
+
+func TestMMU16(t *testing.T) {
+	m := memory.NewMMU(memory.Memory{0x00, 0xC3, 0x50, 0x01})
+
+	if got, want := m.Read16(0x0002), uint16(0x0150); got != want {
+		t.Errorf("Read16(0002) = %04X, want %04X", got, want)
+	}
+
+	m.Write16(0xC000, 0xBEEF)
+	if got, want := m.Read8(0xC000), uint8(0xEF); got != want {
+		t.Errorf("Read8(C000) = %02X, want %02X", got, want)
+	}
+	if got, want := m.Read8(0xC001), uint8(0xBE); got != want {
+		t.Errorf("Read8(C001) = %02X, want %02X", got, want)
+	}
+}
+
+func TestMMUAttach(t *testing.T) {
+	m := memory.NewMMU(nil)
+	r := &register{}
+	m.Attach(0xFF47, 0xFF47, r)
+
+	m.Write8(0xFF47, 0xE4)
+	if r.addr != 0xFF47 || r.val != 0xE4 {
+		t.Errorf("register saw write %02X to %04X, want E4 to FF47", r.val, r.addr)
+	}
+	if got, want := m.Read8(0xFF47), uint8(0xE4); got != want {
+		t.Errorf("Read8(FF47) = %02X, want %02X", got, want)
+	}
+
+	// Neighbouring registers are still backed by the MMU.
+	m.Write8(0xFF48, 0x12)
+	if r.addr != 0xFF47 {
+		t.Errorf("register saw a write to %04X", r.addr)
+	}
+	if got, want := m.Read8(0xFF48), uint8(0x12); got != want {
+		t.Errorf("Read8(FF48) = %02X, want %02X", got, want)
+	}
+}
+
+func TestMMUDetach(t *testing.T) {
+	m := memory.NewMMU(memory.Memory{0x12, 0x34})
+	r := &register{val: 0x56}
+	m.Attach(0xFF47, 0xFF47, r)
+	m.Detach(0x0000, 0x00FF)
+	m.Detach(0xFF47, 0xFF47)
+
+	if got, want := m.Read8(0x0000), uint8(0xFF); got != want {
+		t.Errorf("Read8(0000) = %02X after detaching the ROM, want %02X", got, want)
+	}
+	m.Write8(0xFF47, 0xE4)
+	if r.addr != 0 {
+		t.Errorf("detached register saw a write to %04X", r.addr)
+	}
+	if got, want := m.Read8(0xFF47), uint8(0xE4); got != want {
+		t.Errorf("Read8(FF47) = %02X, want %02X", got, want)
+	}
+}
+
+func TestMMUEmptySlot(t *testing.T) {
+	for _, tc := range []struct {
+		name string
+		rom  memory.Device
+	}{
+		{"nil", nil},
+		{"nil Memory", memory.Memory(nil)},
+		{"empty Memory", memory.Memory{}},
+	} {
+		m := memory.NewMMU(tc.rom)
+		if got, want := m.Read8(0x0100), uint8(0xFF); got != want {
+			t.Errorf("%s: Read8(0100) = %02X, want %02X", tc.name, got, want)
+		}
+	}
+}
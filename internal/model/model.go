@@ -0,0 +1,42 @@
+// Package model lists the Game Boy hardware revisions the VM can emulate.
+// They differ in the state the boot ROM leaves them in and in whether they
+// have the Game Boy Color's hardware.
+package model
+
+// Model is a Game Boy hardware revision.
+type Model int
+
+// The models.
+const (
+	DMG0 Model = iota // The original Game Boy with the early boot ROM.
+	DMG               // The original Game Boy.
+	MGB               // Game Boy Pocket and Light.
+	SGB               // Super Game Boy.
+	CGB               // Game Boy Color.
+	AGB               // Game Boy Advance, running Game Boy Color games.
+)
+
+var names = [...]string{"DMG0", "DMG", "MGB", "SGB", "CGB", "AGB"}
+
+func (m Model) String() string {
+	if m < 0 || int(m) >= len(names) {
+		return "unknown model"
+	}
+	return names[m]
+}
+
+// Parse returns the model with the given name, such as "CGB", and whether
+// there is one.
+func Parse(name string) (Model, bool) {
+	for i, n := range names {
+		if n == name {
+			return Model(i), true
+		}
+	}
+	return 0, false
+}
+
+// CGB reports whether the model has the Game Boy Color's hardware.
+func (m Model) CGB() bool {
+	return m == CGB || m == AGB
+}
@@ -198,7 +198,7 @@ func main() {
 		log.Fatalf("Error rendering template %v: %v", templates, err)
 	}
 	if err := outFile.Close(); err != nil {
-		log.Fatalf("Unable to close pipe", err)
+		log.Fatalf("Unable to close pipe: %v", err)
 	}
 
 	cmd := exec.Command("gofmt", "-w", outFile.Name())
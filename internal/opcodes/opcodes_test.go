@@ -59,6 +59,28 @@ LDH (AA) A`},
 		{[]byte{
 			0xF8, 0x81}, // LD HL SP+r8
 			`LD HL SP+-7F`}, // SAD
+		// 16-bit immediates are little-endian. These are taken from the
+		// cartridge entry point and the DMG boot ROM.
+		{[]byte{
+			0x00,              // NOP
+			0xC3, 0x50, 0x01}, // JP $0150
+			`
+NOP
+JP 150`},
+		{[]byte{
+			0x31, 0xFE, 0xFF, // LD SP, $FFFE
+			0x21, 0xFF, 0x9F, // LD HL, $9FFF
+			0xCD, 0x95, 0x00}, // CALL $0095
+			`
+LD SP FFFE
+LD HL 9FFF
+CALL 95`},
+		{[]byte{
+			0xEA, 0x00, 0xC0, // LD ($C000), A
+			0xC2, 0x0C, 0x02}, // JP NZ, $020C
+			`
+LD C000 A
+JP NZ 20C`},
 	}
 
 	for _, test := range tests {
@@ -198,10 +220,10 @@ func TestOpcodes(t *testing.T) {
 		0xc6, // ADD A,d8
 		0xff, // d8
 		0x76, // Halt
-	})
+	}, vm.WithTerminateOnHalt())
 
-	err := v.Run()
-	if err == opcodes.ErrHalt {
+	err := v.Run(nil)
+	if err != opcodes.ErrHalt {
 		t.Errorf("Got non HALTED error: %v", err)
 	}
 
@@ -213,3 +235,254 @@ func TestOpcodes(t *testing.T) {
 		t.Errorf("Register difference (-got,+want): %v", diff)
 	}
 }
+
+// execute runs the single instruction encoded in code against v.
+func execute(t *testing.T, v *vm.VM, code []byte) opcodes.ExecutionResult {
+	t.Helper()
+	i, err := opcodes.ReadInstruction(bytes.NewBuffer(code))
+	if err != nil {
+		t.Fatalf("opcodes.ReadInstruction(%x) error: %v", code, err)
+	}
+	res, err := i.Execute(v)
+	if err != nil {
+		t.Fatalf("%v.Execute() error: %v", i, err)
+	}
+	return res
+}
+
+func TestALU(t *testing.T) {
+	const (
+		z = registers.FlagRegister(registers.FlagZf)
+		n = registers.FlagRegister(registers.FlagN)
+		h = registers.FlagRegister(registers.FlagH)
+		c = registers.FlagRegister(registers.FlagCy)
+	)
+
+	tests := []struct {
+		name   string
+		code   []byte
+		in     registers.Registers
+		mem    memory.Memory
+		want   registers.Registers
+		cycles uint8
+	}{
+		{"ADD A,B", []byte{0x80}, registers.Registers{A: 0x3A, B: 0xC6}, nil, registers.Registers{A: 0x00, B: 0xC6, F: z | h | c}, 4},
+		{"ADD A,C half carry", []byte{0x81}, registers.Registers{A: 0x0F, C: 0x01}, nil, registers.Registers{A: 0x10, C: 0x01, F: h}, 4},
+		{"ADD A,A", []byte{0x87}, registers.Registers{A: 0x80}, nil, registers.Registers{A: 0x00, F: z | c}, 4},
+		{"ADD A,d8 clears N", []byte{0xC6, 0x01}, registers.Registers{A: 0x01, F: n}, nil, registers.Registers{A: 0x02}, 8},
+		{"ADD A,(HL)", []byte{0x86}, registers.Registers{A: 0x3C, L: 0x02}, memory.Memory{0, 0, 0x12}, registers.Registers{A: 0x4E, L: 0x02}, 8},
+		{"ADC A,E with carry", []byte{0x8B}, registers.Registers{A: 0xE1, E: 0x0F, F: c}, nil, registers.Registers{A: 0xF1, E: 0x0F, F: h}, 4},
+		{"ADC A,d8 carry out", []byte{0xCE, 0x3B}, registers.Registers{A: 0xE1, F: c}, nil, registers.Registers{A: 0x1D, F: c}, 8},
+		{"ADC A,d8 carry only", []byte{0xCE, 0xFF}, registers.Registers{A: 0x00, F: c}, nil, registers.Registers{A: 0x00, F: z | h | c}, 8},
+		{"SUB E", []byte{0x93}, registers.Registers{A: 0x3E, E: 0x3E}, nil, registers.Registers{A: 0x00, E: 0x3E, F: z | n}, 4},
+		{"SUB d8 half borrow", []byte{0xD6, 0x0F}, registers.Registers{A: 0x3E}, nil, registers.Registers{A: 0x2F, F: n | h}, 8},
+		{"SUB B borrow", []byte{0x90}, registers.Registers{A: 0x3E, B: 0x40}, nil, registers.Registers{A: 0xFE, B: 0x40, F: n | c}, 4},
+		{"SBC A,H", []byte{0x9C}, registers.Registers{A: 0x3B, H: 0x2A, F: c}, nil, registers.Registers{A: 0x10, H: 0x2A, F: n}, 4},
+		{"SBC A,d8", []byte{0xDE, 0x3A}, registers.Registers{A: 0x3B, F: c}, nil, registers.Registers{A: 0x00, F: z | n}, 8},
+		{"SBC A,(HL)", []byte{0x9E}, registers.Registers{A: 0x3B, F: c}, memory.Memory{0x4F}, registers.Registers{A: 0xEB, F: n | h | c}, 8},
+		{"AND L", []byte{0xA5}, registers.Registers{A: 0x5A, L: 0x3F}, nil, registers.Registers{A: 0x1A, L: 0x3F, F: h}, 4},
+		{"AND d8", []byte{0xE6, 0x00}, registers.Registers{A: 0x5A, F: c}, nil, registers.Registers{A: 0x00, F: z | h}, 8},
+		{"XOR A", []byte{0xAF}, registers.Registers{A: 0xFF, F: n | h | c}, nil, registers.Registers{A: 0x00, F: z}, 4},
+		{"XOR d8", []byte{0xEE, 0x0F}, registers.Registers{A: 0xFF}, nil, registers.Registers{A: 0xF0}, 8},
+		{"OR D", []byte{0xB2}, registers.Registers{A: 0x5A, D: 0x03}, nil, registers.Registers{A: 0x5B, D: 0x03}, 4},
+		{"OR (HL)", []byte{0xB6}, registers.Registers{A: 0x00}, memory.Memory{0x00}, registers.Registers{A: 0x00, F: z}, 8},
+		{"CP B", []byte{0xB8}, registers.Registers{A: 0x3C, B: 0x2F}, nil, registers.Registers{A: 0x3C, B: 0x2F, F: n | h}, 4},
+		{"CP d8", []byte{0xFE, 0x3C}, registers.Registers{A: 0x3C}, nil, registers.Registers{A: 0x3C, F: z | n}, 8},
+		{"CP (HL)", []byte{0xBE}, registers.Registers{A: 0x3C}, memory.Memory{0x40}, registers.Registers{A: 0x3C, F: n | c}, 8},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			v := vm.New(test.mem)
+			*v.Reg() = test.in
+
+			res := execute(t, v, test.code)
+			if res.Cycles != test.cycles {
+				t.Errorf("Cycles = %d, want %d", res.Cycles, test.cycles)
+			}
+			if diff := cmp.Diff(test.want, *v.Reg()); diff != "" {
+				t.Errorf("Register difference (-want,+got): %v", diff)
+			}
+		})
+	}
+}
+
+func TestCBPrefixed(t *testing.T) {
+	const (
+		z = registers.FlagRegister(registers.FlagZf)
+		n = registers.FlagRegister(registers.FlagN)
+		h = registers.FlagRegister(registers.FlagH)
+		c = registers.FlagRegister(registers.FlagCy)
+	)
+
+	tests := []struct {
+		name      string
+		code      []byte
+		in        registers.Registers
+		hlPtr     uint8 // The byte at 0xC000 before executing.
+		want      registers.Registers
+		wantHLPtr uint8 // The byte at 0xC000 after executing.
+		cycles    uint8
+	}{
+		{"RLC B", []byte{0xCB, 0x00}, registers.Registers{B: 0x85}, 0, registers.Registers{B: 0x0B, F: c}, 0, 8},
+		{"RLC A zero", []byte{0xCB, 0x07}, registers.Registers{F: n | h | c}, 0, registers.Registers{F: z}, 0, 8},
+		{"RRC C", []byte{0xCB, 0x09}, registers.Registers{C: 0x01}, 0, registers.Registers{C: 0x80, F: c}, 0, 8},
+		{"RL L", []byte{0xCB, 0x15}, registers.Registers{L: 0x80}, 0, registers.Registers{L: 0x00, F: z | c}, 0, 8},
+		{"RL L through carry", []byte{0xCB, 0x15}, registers.Registers{L: 0x11, F: c}, 0, registers.Registers{L: 0x23}, 0, 8},
+		{"RR A", []byte{0xCB, 0x1F}, registers.Registers{A: 0x01}, 0, registers.Registers{A: 0x00, F: z | c}, 0, 8},
+		{"RR (HL)", []byte{0xCB, 0x1E}, registers.Registers{H: 0xC0, F: c}, 0x8A, registers.Registers{H: 0xC0}, 0xC5, 16},
+		{"SLA D", []byte{0xCB, 0x22}, registers.Registers{D: 0x80}, 0, registers.Registers{D: 0x00, F: z | c}, 0, 8},
+		{"SRA E", []byte{0xCB, 0x2B}, registers.Registers{E: 0x8A}, 0, registers.Registers{E: 0xC5}, 0, 8},
+		{"SRA (HL)", []byte{0xCB, 0x2E}, registers.Registers{H: 0xC0}, 0x01, registers.Registers{H: 0xC0, F: z | c}, 0x00, 16},
+		{"SWAP A", []byte{0xCB, 0x37}, registers.Registers{A: 0xF0, F: c}, 0, registers.Registers{A: 0x0F}, 0, 8},
+		{"SWAP (HL)", []byte{0xCB, 0x36}, registers.Registers{H: 0xC0}, 0x00, registers.Registers{H: 0xC0, F: z}, 0x00, 16},
+		{"SRL A", []byte{0xCB, 0x3F}, registers.Registers{A: 0xFF}, 0, registers.Registers{A: 0x7F, F: c}, 0, 8},
+		{"BIT 7,H clear", []byte{0xCB, 0x7C}, registers.Registers{H: 0x7F, F: n}, 0, registers.Registers{H: 0x7F, F: z | h}, 0, 8},
+		{"BIT 7,H keeps carry", []byte{0xCB, 0x7C}, registers.Registers{H: 0x80, F: c}, 0, registers.Registers{H: 0x80, F: h | c}, 0, 8},
+		{"BIT 4,(HL)", []byte{0xCB, 0x66}, registers.Registers{H: 0xC0}, 0xEF, registers.Registers{H: 0xC0, F: z | h}, 0xEF, 16},
+		{"RES 0,A", []byte{0xCB, 0x87}, registers.Registers{A: 0xFF, F: z}, 0, registers.Registers{A: 0xFE, F: z}, 0, 8},
+		{"RES 7,(HL)", []byte{0xCB, 0xBE}, registers.Registers{H: 0xC0}, 0xFF, registers.Registers{H: 0xC0}, 0x7F, 16},
+		{"SET 3,C", []byte{0xCB, 0xD9}, registers.Registers{}, 0, registers.Registers{C: 0x08}, 0, 8},
+		{"SET 0,(HL)", []byte{0xCB, 0xC6}, registers.Registers{H: 0xC0}, 0x80, registers.Registers{H: 0xC0}, 0x81, 16},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			v := vm.New(nil)
+			*v.Reg() = test.in
+			v.Mem().Write8(0xC000, test.hlPtr)
+
+			res := execute(t, v, test.code)
+			if res.Cycles != test.cycles {
+				t.Errorf("Cycles = %d, want %d", res.Cycles, test.cycles)
+			}
+			if diff := cmp.Diff(test.want, *v.Reg()); diff != "" {
+				t.Errorf("Register difference (-want,+got): %v", diff)
+			}
+			if got := v.Mem().Read8(0xC000); got != test.wantHLPtr {
+				t.Errorf("(HL) = %02X, want %02X", got, test.wantHLPtr)
+			}
+		})
+	}
+}
+
+func TestStack(t *testing.T) {
+	const (
+		z = registers.FlagRegister(registers.FlagZf)
+		c = registers.FlagRegister(registers.FlagCy)
+	)
+
+	tests := []struct {
+		name      string
+		code      []byte
+		in        registers.Registers
+		stack     []byte // Memory contents at 0xC00E and 0xC00F before executing.
+		want      registers.Registers
+		wantStack []byte // Memory contents at 0xC00E and 0xC00F after executing.
+		cycles    uint8
+		didSetPC  bool
+	}{
+		{"PUSH BC", []byte{0xC5}, registers.Registers{B: 0x12, C: 0x34, SP: 0xC010}, []byte{0, 0},
+			registers.Registers{B: 0x12, C: 0x34, SP: 0xC00E}, []byte{0x34, 0x12}, 16, false},
+		{"PUSH AF", []byte{0xF5}, registers.Registers{A: 0x12, F: z | c, SP: 0xC010}, []byte{0, 0},
+			registers.Registers{A: 0x12, F: z | c, SP: 0xC00E}, []byte{0x90, 0x12}, 16, false},
+		{"POP DE", []byte{0xD1}, registers.Registers{SP: 0xC00E}, []byte{0x34, 0x12},
+			registers.Registers{D: 0x12, E: 0x34, SP: 0xC010}, []byte{0x34, 0x12}, 12, false},
+		{"POP AF masks F", []byte{0xF1}, registers.Registers{SP: 0xC00E}, []byte{0xFF, 0x12},
+			registers.Registers{A: 0x12, F: 0xF0, SP: 0xC010}, []byte{0xFF, 0x12}, 12, false},
+		{"CALL a16", []byte{0xCD, 0x40, 0x00}, registers.Registers{PC: 0x20, SP: 0xC010}, []byte{0, 0},
+			registers.Registers{PC: 0x40, SP: 0xC00E}, []byte{0x23, 0x00}, 24, true},
+		{"CALL NZ,a16 taken", []byte{0xC4, 0x40, 0x00}, registers.Registers{PC: 0x20, SP: 0xC010}, []byte{0, 0},
+			registers.Registers{PC: 0x40, SP: 0xC00E}, []byte{0x23, 0x00}, 24, true},
+		{"CALL NZ,a16 not taken", []byte{0xC4, 0x40, 0x00}, registers.Registers{PC: 0x20, SP: 0xC010, F: z}, []byte{0, 0},
+			registers.Registers{PC: 0x20, SP: 0xC010, F: z}, []byte{0, 0}, 12, false},
+		{"CALL C,a16 taken", []byte{0xDC, 0x40, 0x00}, registers.Registers{PC: 0x20, SP: 0xC010, F: c}, []byte{0, 0},
+			registers.Registers{PC: 0x40, SP: 0xC00E, F: c}, []byte{0x23, 0x00}, 24, true},
+		{"RET", []byte{0xC9}, registers.Registers{PC: 0x40, SP: 0xC00E}, []byte{0x23, 0x00},
+			registers.Registers{PC: 0x23, SP: 0xC010}, []byte{0x23, 0x00}, 16, true},
+		{"RETI", []byte{0xD9}, registers.Registers{PC: 0x40, SP: 0xC00E}, []byte{0x23, 0x00},
+			registers.Registers{PC: 0x23, SP: 0xC010}, []byte{0x23, 0x00}, 16, true},
+		{"RET Z taken", []byte{0xC8}, registers.Registers{PC: 0x40, SP: 0xC00E, F: z}, []byte{0x23, 0x00},
+			registers.Registers{PC: 0x23, SP: 0xC010, F: z}, []byte{0x23, 0x00}, 20, true},
+		{"RET NC not taken", []byte{0xD0}, registers.Registers{PC: 0x40, SP: 0xC00E, F: c}, []byte{0x23, 0x00},
+			registers.Registers{PC: 0x40, SP: 0xC00E, F: c}, []byte{0x23, 0x00}, 8, false},
+		{"RST 38H", []byte{0xFF}, registers.Registers{PC: 0x20, SP: 0xC010}, []byte{0, 0},
+			registers.Registers{PC: 0x38, SP: 0xC00E}, []byte{0x21, 0x00}, 16, true},
+		{"RST 08H", []byte{0xCF}, registers.Registers{PC: 0x20, SP: 0xC010}, []byte{0, 0},
+			registers.Registers{PC: 0x08, SP: 0xC00E}, []byte{0x21, 0x00}, 16, true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			v := vm.New(nil)
+			*v.Reg() = test.in
+			v.Mem().Write8(0xC00E, test.stack[0])
+			v.Mem().Write8(0xC00F, test.stack[1])
+
+			res := execute(t, v, test.code)
+			if res.Cycles != test.cycles {
+				t.Errorf("Cycles = %d, want %d", res.Cycles, test.cycles)
+			}
+			if res.DidSetPC != test.didSetPC {
+				t.Errorf("DidSetPC = %v, want %v", res.DidSetPC, test.didSetPC)
+			}
+			if diff := cmp.Diff(test.want, *v.Reg()); diff != "" {
+				t.Errorf("Register difference (-want,+got): %v", diff)
+			}
+			gotStack := []byte{v.Mem().Read8(0xC00E), v.Mem().Read8(0xC00F)}
+			if diff := cmp.Diff(test.wantStack, gotStack); diff != "" {
+				t.Errorf("Stack difference (-want,+got): %v", diff)
+			}
+		})
+	}
+}
+
+func TestJumps(t *testing.T) {
+	const (
+		z = registers.FlagRegister(registers.FlagZf)
+		c = registers.FlagRegister(registers.FlagCy)
+	)
+
+	tests := []struct {
+		name     string
+		code     []byte
+		in       registers.Registers
+		want     registers.Registers
+		cycles   uint8
+		didSetPC bool
+	}{
+		{"JP a16", []byte{0xC3, 0x50, 0x01}, registers.Registers{PC: 0x20}, registers.Registers{PC: 0x0150}, 16, true},
+		{"JP NZ,a16 taken", []byte{0xC2, 0x50, 0x01}, registers.Registers{PC: 0x20}, registers.Registers{PC: 0x0150}, 16, true},
+		{"JP Z,a16 not taken", []byte{0xCA, 0x50, 0x01}, registers.Registers{PC: 0x20}, registers.Registers{PC: 0x20}, 12, false},
+		{"JP C,a16 taken", []byte{0xDA, 0x50, 0x01}, registers.Registers{PC: 0x20, F: c}, registers.Registers{PC: 0x0150, F: c}, 16, true},
+		{"JP NC,a16 not taken", []byte{0xD2, 0x50, 0x01}, registers.Registers{PC: 0x20, F: c}, registers.Registers{PC: 0x20, F: c}, 12, false},
+		{"JP (HL)", []byte{0xE9}, registers.Registers{PC: 0x20, H: 0xC0, L: 0x12}, registers.Registers{PC: 0xC012, H: 0xC0, L: 0x12}, 4, true},
+		{"JR r8 forward", []byte{0x18, 0x05}, registers.Registers{PC: 0x20}, registers.Registers{PC: 0x27}, 12, true},
+		{"JR r8 backward", []byte{0x18, 0xFB}, registers.Registers{PC: 0x20}, registers.Registers{PC: 0x1D}, 12, true},
+		{"JR r8 to self", []byte{0x18, 0xFE}, registers.Registers{PC: 0x20}, registers.Registers{PC: 0x20}, 12, true},
+		{"JR r8 wraps", []byte{0x18, 0x80}, registers.Registers{PC: 0x10}, registers.Registers{PC: 0xFF92}, 12, true},
+		{"JR NZ,r8 taken", []byte{0x20, 0xFC}, registers.Registers{PC: 0x20}, registers.Registers{PC: 0x1E}, 12, true},
+		{"JR NZ,r8 not taken", []byte{0x20, 0xFC}, registers.Registers{PC: 0x20, F: z}, registers.Registers{PC: 0x20, F: z}, 8, false},
+		{"JR Z,r8 taken", []byte{0x28, 0x10}, registers.Registers{PC: 0x20, F: z}, registers.Registers{PC: 0x32, F: z}, 12, true},
+		{"JR NC,r8 taken", []byte{0x30, 0x10}, registers.Registers{PC: 0x20}, registers.Registers{PC: 0x32}, 12, true},
+		{"JR C,r8 not taken", []byte{0x38, 0x10}, registers.Registers{PC: 0x20}, registers.Registers{PC: 0x20}, 8, false},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			v := vm.New(nil)
+			*v.Reg() = test.in
+
+			res := execute(t, v, test.code)
+			if res.Cycles != test.cycles {
+				t.Errorf("Cycles = %d, want %d", res.Cycles, test.cycles)
+			}
+			if res.DidSetPC != test.didSetPC {
+				t.Errorf("DidSetPC = %v, want %v", res.DidSetPC, test.didSetPC)
+			}
+			if diff := cmp.Diff(test.want, *v.Reg()); diff != "" {
+				t.Errorf("Register difference (-want,+got): %v", diff)
+			}
+		})
+	}
+}
@@ -6,6 +6,7 @@ import (
 	"fmt"
 	"io"
 
+	"github.com/vsinha/vm/internal/interrupts"
 	"github.com/vsinha/vm/internal/memory"
 	"github.com/vsinha/vm/internal/registers"
 )
@@ -13,9 +14,15 @@ import (
 // ErrUnimplemented is the unimplemented error
 var ErrUnimplemented = errors.New("this opcode has not yet been implemented")
 
-// ErrHalt is the control flow error for when the VM should be stoppped.
+// ErrHalt is the control flow error returned by HALT. The VM idles the CPU
+// until an interrupt is pending, or stops running if asked to.
 var ErrHalt = errors.New("HALTED")
 
+// ErrStop is the control flow error returned by STOP. The VM either switches
+// CPU speed, if one was requested through KEY1, or stops the CPU until a
+// button is pressed.
+var ErrStop = errors.New("STOPPED")
+
 // ErrOperatorNotValidType is returned when the in memory representation of the
 // operator doesn't match the opcode's required type.
 var ErrOperatorNotValidType = errors.New("operator was not the expected type for this opcode")
@@ -43,11 +50,14 @@ type Instruction interface {
 }
 
 type vm interface {
-	Mem() memory.Memory
+	Mem() memory.Bus
 	Reg() *registers.Registers
+	Interrupts() *interrupts.Controller
 }
 
-var endianness = binary.BigEndian
+// The SM83 stores 16-bit immediates low byte first, so JP $0150 is encoded
+// as C3 50 01.
+var endianness = binary.LittleEndian
 
 func readImmediate16BitAddress(r io.Reader) (uint16, error) {
 	return readImmediate16BitData(r)
@@ -114,19 +124,296 @@ func printLiteral(rawOperand string) bool {
 	}
 }
 
-func addInto(v vm, i Instruction, dest *registers.Reg, a registers.Reg, b registers.Reg) (ExecutionResult, error) {
-	v.Reg().A = a + b
-	// TODO set carry bits
+// flags packs the four condition flags into a value for the F register.
+func flags(z, n, h, c bool) registers.FlagRegister {
+	var f registers.FlagRegister
+	f.SetZ(z)
+	f.SetN(n)
+	f.SetH(h)
+	f.SetC(c)
+	return f
+}
+
+// readHLPtr reads the byte in memory addressed by the HL register pair.
+func readHLPtr(v vm) registers.Reg {
+	return registers.Reg(v.Mem().Read8(v.Reg().HL()))
+}
+
+// writeHLPtr writes to the byte in memory addressed by the HL register pair.
+func writeHLPtr(v vm, val registers.Reg) {
+	v.Mem().Write8(v.Reg().HL(), uint8(val))
+}
+
+// add8 adds b (and the carry in, if set) to a. The half carry is the carry out
+// of bit 3 and the carry is the carry out of bit 7.
+func add8(a, b registers.Reg, carryIn bool) (registers.Reg, registers.FlagRegister) {
+	c := 0
+	if carryIn {
+		c = 1
+	}
+	sum := int(a) + int(b) + c
+	result := registers.Reg(sum)
+	h := int(a&0xF)+int(b&0xF)+c > 0xF
+	return result, flags(result == 0, false, h, sum > 0xFF)
+}
+
+// sub8 subtracts b (and the carry in, if set) from a. The half carry is set
+// when bit 4 had to be borrowed from and the carry when the whole byte did.
+func sub8(a, b registers.Reg, carryIn bool) (registers.Reg, registers.FlagRegister) {
+	c := 0
+	if carryIn {
+		c = 1
+	}
+	diff := int(a) - int(b) - c
+	result := registers.Reg(diff)
+	h := int(a&0xF)-int(b&0xF)-c < 0
+	return result, flags(result == 0, true, h, diff < 0)
+}
+
+// addIntoA implements ADD A,x.
+func addIntoA(v vm, i Instruction, b registers.Reg) (ExecutionResult, error) {
+	v.Reg().A, v.Reg().F = add8(v.Reg().A, b, false)
+	return ExecutionResult{Cycles: i.cycles()[0]}, nil
+}
+
+// adcIntoA implements ADC A,x, adding in the current carry flag.
+func adcIntoA(v vm, i Instruction, b registers.Reg) (ExecutionResult, error) {
+	v.Reg().A, v.Reg().F = add8(v.Reg().A, b, v.Reg().F.C())
+	return ExecutionResult{Cycles: i.cycles()[0]}, nil
+}
+
+// subFromA implements SUB x.
+func subFromA(v vm, i Instruction, b registers.Reg) (ExecutionResult, error) {
+	v.Reg().A, v.Reg().F = sub8(v.Reg().A, b, false)
+	return ExecutionResult{Cycles: i.cycles()[0]}, nil
+}
+
+// sbcFromA implements SBC A,x, subtracting out the current carry flag.
+func sbcFromA(v vm, i Instruction, b registers.Reg) (ExecutionResult, error) {
+	v.Reg().A, v.Reg().F = sub8(v.Reg().A, b, v.Reg().F.C())
+	return ExecutionResult{Cycles: i.cycles()[0]}, nil
+}
+
+// andIntoA implements AND x. AND always sets the half carry flag.
+func andIntoA(v vm, i Instruction, b registers.Reg) (ExecutionResult, error) {
+	v.Reg().A &= b
+	v.Reg().F = flags(v.Reg().A == 0, false, true, false)
+	return ExecutionResult{Cycles: i.cycles()[0]}, nil
+}
+
+// xorIntoA implements XOR x.
+func xorIntoA(v vm, i Instruction, b registers.Reg) (ExecutionResult, error) {
+	v.Reg().A ^= b
+	v.Reg().F = flags(v.Reg().A == 0, false, false, false)
+	return ExecutionResult{Cycles: i.cycles()[0]}, nil
+}
+
+// orIntoA implements OR x.
+func orIntoA(v vm, i Instruction, b registers.Reg) (ExecutionResult, error) {
+	v.Reg().A |= b
+	v.Reg().F = flags(v.Reg().A == 0, false, false, false)
+	return ExecutionResult{Cycles: i.cycles()[0]}, nil
+}
+
+// compareWithA implements CP x, which is a SUB that throws away the result and
+// only keeps the flags.
+func compareWithA(v vm, i Instruction, b registers.Reg) (ExecutionResult, error) {
+	_, v.Reg().F = sub8(v.Reg().A, b, false)
+	return ExecutionResult{Cycles: i.cycles()[0]}, nil
+}
+
+// bitOp is one of the CB prefixed rotate, shift, swap or bit setting
+// operations. It returns the new value and sets any flags it affects.
+type bitOp func(v vm, r registers.Reg) registers.Reg
+
+// applyToReg runs op on a register in place.
+func applyToReg(v vm, i Instruction, r *registers.Reg, op bitOp) (ExecutionResult, error) {
+	*r = op(v, *r)
+	return ExecutionResult{Cycles: i.cycles()[0]}, nil
+}
+
+// applyToHLPtr runs op on the byte addressed by HL and writes it back.
+func applyToHLPtr(v vm, i Instruction, op bitOp) (ExecutionResult, error) {
+	writeHLPtr(v, op(v, readHLPtr(v)))
+	return ExecutionResult{Cycles: i.cycles()[0]}, nil
+}
+
+// rlc rotates left, copying bit 7 into both bit 0 and the carry.
+func rlc(v vm, r registers.Reg) registers.Reg {
+	out := r<<1 | r>>7
+	v.Reg().F = flags(out == 0, false, false, r&0x80 != 0)
+	return out
+}
+
+// rrc rotates right, copying bit 0 into both bit 7 and the carry.
+func rrc(v vm, r registers.Reg) registers.Reg {
+	out := r>>1 | r<<7
+	v.Reg().F = flags(out == 0, false, false, r&0x01 != 0)
+	return out
+}
+
+// rl rotates left through the carry flag.
+func rl(v vm, r registers.Reg) registers.Reg {
+	out := r << 1
+	if v.Reg().F.C() {
+		out |= 0x01
+	}
+	v.Reg().F = flags(out == 0, false, false, r&0x80 != 0)
+	return out
+}
+
+// rr rotates right through the carry flag.
+func rr(v vm, r registers.Reg) registers.Reg {
+	out := r >> 1
+	if v.Reg().F.C() {
+		out |= 0x80
+	}
+	v.Reg().F = flags(out == 0, false, false, r&0x01 != 0)
+	return out
+}
+
+// sla shifts left into the carry, bit 0 becomes 0.
+func sla(v vm, r registers.Reg) registers.Reg {
+	out := r << 1
+	v.Reg().F = flags(out == 0, false, false, r&0x80 != 0)
+	return out
+}
+
+// sra shifts right into the carry, bit 7 keeps its value.
+func sra(v vm, r registers.Reg) registers.Reg {
+	out := r>>1 | r&0x80
+	v.Reg().F = flags(out == 0, false, false, r&0x01 != 0)
+	return out
+}
+
+// swap exchanges the upper and lower nibbles.
+func swap(v vm, r registers.Reg) registers.Reg {
+	out := r<<4 | r>>4
+	v.Reg().F = flags(out == 0, false, false, false)
+	return out
+}
+
+// srl shifts right into the carry, bit 7 becomes 0.
+func srl(v vm, r registers.Reg) registers.Reg {
+	out := r >> 1
+	v.Reg().F = flags(out == 0, false, false, r&0x01 != 0)
+	return out
+}
+
+// res returns an operation clearing bit n. No flags are affected.
+func res(n uint8) bitOp {
+	return func(v vm, r registers.Reg) registers.Reg {
+		return r &^ (1 << n)
+	}
+}
+
+// set returns an operation setting bit n. No flags are affected.
+func set(n uint8) bitOp {
+	return func(v vm, r registers.Reg) registers.Reg {
+		return r | 1<<n
+	}
+}
+
+// testBit implements BIT n,x: Z is set when bit n of r is 0, the carry is left
+// untouched.
+func testBit(v vm, i Instruction, n uint8, r registers.Reg) (ExecutionResult, error) {
+	v.Reg().F = flags(r&(1<<n) == 0, false, true, v.Reg().F.C())
+	return ExecutionResult{Cycles: i.cycles()[0]}, nil
+}
+
+// push16 pushes val onto the stack. The stack grows down, so the high byte
+// ends up at the higher address.
+func push16(v vm, val uint16) {
+	v.Reg().SP -= 2
+	v.Mem().Write16(v.Reg().SP, val)
+}
+
+// pop16 pops a value pushed by push16 off the stack.
+func pop16(v vm) uint16 {
+	val := v.Mem().Read16(v.Reg().SP)
+	v.Reg().SP += 2
+	return val
+}
+
+// call pushes the address of the instruction following i and jumps to addr.
+func call(v vm, i Instruction, addr uint16) (ExecutionResult, error) {
+	push16(v, v.Reg().PC+uint16(i.Length()))
+	v.Reg().PC = addr
+	return ExecutionResult{Cycles: i.cycles()[0], DidSetPC: true}, nil
+}
+
+// callIf implements CALL cc,a16. When the condition doesn't hold the
+// instruction falls through and costs the shorter cycle count.
+func callIf(v vm, i Instruction, cond bool, operand interface{}) (ExecutionResult, error) {
+	addr, ok := operand.(uint16)
+	if !ok {
+		return ExecutionResult{}, ErrOperatorNotValidType
+	}
+	if !cond {
+		return ExecutionResult{Cycles: i.cycles()[1]}, nil
+	}
+	return call(v, i, addr)
+}
+
+// ret pops the return address off the stack into the PC.
+func ret(v vm, i Instruction) (ExecutionResult, error) {
+	v.Reg().PC = pop16(v)
+	return ExecutionResult{Cycles: i.cycles()[0], DidSetPC: true}, nil
+}
+
+// retIf implements RET cc, returning only if the condition holds.
+func retIf(v vm, i Instruction, cond bool) (ExecutionResult, error) {
+	if !cond {
+		return ExecutionResult{Cycles: i.cycles()[1]}, nil
+	}
+	return ret(v, i)
+}
+
+// push implements PUSH rr.
+func push(v vm, i Instruction, val uint16) (ExecutionResult, error) {
+	push16(v, val)
+	return ExecutionResult{Cycles: i.cycles()[0]}, nil
+}
+
+// pop implements POP rr, handing the popped value to one of the register
+// pair setters.
+func pop(v vm, i Instruction, set func(uint16)) (ExecutionResult, error) {
+	set(pop16(v))
 	return ExecutionResult{Cycles: i.cycles()[0]}, nil
 }
 
-func addIntoA(v vm, i Instruction, a registers.Reg, b registers.Reg) (ExecutionResult, error) {
-	return addInto(v, i, &v.Reg().A, a, b)
+// jumpIf implements JP cc,a16. When the condition doesn't hold the
+// instruction falls through and costs the shorter cycle count.
+func jumpIf(v vm, i Instruction, cond bool, operand interface{}) (ExecutionResult, error) {
+	addr, ok := operand.(uint16)
+	if !ok {
+		return ExecutionResult{}, ErrOperatorNotValidType
+	}
+	if !cond {
+		return ExecutionResult{Cycles: i.cycles()[1]}, nil
+	}
+	v.Reg().PC = addr
+	return ExecutionResult{Cycles: i.cycles()[0], DidSetPC: true}, nil
+}
+
+// jumpRelativeIf implements JR cc,r8. The offset is signed and relative to the
+// address of the instruction following the JR, so an offset of -2 loops
+// forever.
+func jumpRelativeIf(v vm, i Instruction, cond bool, operand interface{}) (ExecutionResult, error) {
+	offset, ok := operand.(int8)
+	if !ok {
+		return ExecutionResult{}, ErrOperatorNotValidType
+	}
+	if !cond {
+		return ExecutionResult{Cycles: i.cycles()[1]}, nil
+	}
+	v.Reg().PC += uint16(i.Length()) + uint16(offset)
+	return ExecutionResult{Cycles: i.cycles()[0], DidSetPC: true}, nil
 }
 
 // Execute NOP instruction. 0x0
 func (i *NOP) Execute(v vm) (ExecutionResult, error) {
-	return ExecutionResult{}, nil
+	return ExecutionResult{Cycles: i.cycles()[0]}, nil
 }
 
 // Execute LD_BC_d16 instruction. 0x1
@@ -136,7 +423,7 @@ func (i *LD_BC_d16) Execute(v vm) (ExecutionResult, error) {
 
 // Execute STOP_0 instruction. 0x10
 func (i *STOP_0) Execute(v vm) (ExecutionResult, error) {
-	return ExecutionResult{}, ErrUnimplemented
+	return ExecutionResult{Cycles: i.cycles()[0]}, ErrStop
 }
 
 // Execute LD_DE_d16 instruction.
@@ -177,9 +464,13 @@ func (i *RLA) Execute(v vm) (ExecutionResult, error) {
 // Execute JR_r8 instruction. JR is a relative jump between 128
 // addresses forward or backwards.
 func (i *JR_r8) Execute(v vm) (ExecutionResult, error) {
-	// vm.Reg().PC += i.operand1.(int8)
-	// return ExecutionResult{Cycles: i.cycles()[0], DidSDidSetPC: true}, ErrUnimplemented
-	return ExecutionResult{}, ErrUnimplemented
+	offset, ok := i.operand1.(int8)
+	if !ok {
+		return ExecutionResult{}, ErrOperatorNotValidType
+	}
+
+	v.Reg().PC += uint16(i.Length()) + uint16(offset)
+	return ExecutionResult{Cycles: i.cycles()[0], DidSetPC: true}, nil
 }
 
 // Execute ADD_HL_DE instruction.
@@ -224,7 +515,7 @@ func (i *LD_BCDeref_A) Execute(v vm) (ExecutionResult, error) {
 
 // Execute JR_NZ_r8 instruction.
 func (i *JR_NZ_r8) Execute(v vm) (ExecutionResult, error) {
-	return ExecutionResult{}, ErrUnimplemented
+	return jumpRelativeIf(v, i, !v.Reg().F.Z(), i.operand2)
 }
 
 // Execute LD_HL_d16 instruction.
@@ -264,7 +555,7 @@ func (i *DAA) Execute(v vm) (ExecutionResult, error) {
 
 // Execute JR_Z_r8 instruction.
 func (i *JR_Z_r8) Execute(v vm) (ExecutionResult, error) {
-	return ExecutionResult{}, ErrUnimplemented
+	return jumpRelativeIf(v, i, v.Reg().F.Z(), i.operand2)
 }
 
 // Execute ADD_HL_HL instruction.
@@ -309,12 +600,18 @@ func (i *INC_BC) Execute(v vm) (ExecutionResult, error) {
 
 // Execute JR_NC_r8 instruction.
 func (i *JR_NC_r8) Execute(v vm) (ExecutionResult, error) {
-	return ExecutionResult{}, ErrUnimplemented
+	return jumpRelativeIf(v, i, !v.Reg().F.C(), i.operand2)
 }
 
 // Execute LD_SP_d16 instruction.
 func (i *LD_SP_d16) Execute(v vm) (ExecutionResult, error) {
-	return ExecutionResult{}, ErrUnimplemented
+	d, ok := i.operand2.(uint16)
+	if !ok {
+		return ExecutionResult{}, ErrOperatorNotValidType
+	}
+
+	v.Reg().SP = d
+	return ExecutionResult{Cycles: i.cycles()[0]}, nil
 }
 
 // Execute LD_HLPtrDec_A instruction.
@@ -349,7 +646,7 @@ func (i *SCF) Execute(v vm) (ExecutionResult, error) {
 
 // Execute JR_C_r8 instruction.
 func (i *JR_C_r8) Execute(v vm) (ExecutionResult, error) {
-	return ExecutionResult{}, ErrUnimplemented
+	return jumpRelativeIf(v, i, v.Reg().F.C(), i.operand2)
 }
 
 // Execute ADD_HL_SP instruction.
@@ -679,7 +976,7 @@ func (i *LD_HLPtr_L) Execute(v vm) (ExecutionResult, error) {
 
 // Execute Halt.
 func (i *HALT) Execute(v vm) (ExecutionResult, error) {
-	return ExecutionResult{}, fmt.Errorf("HALTED")
+	return ExecutionResult{Cycles: i.cycles()[0]}, ErrHalt
 }
 
 // Execute LD_HLPtr_A instruction.
@@ -734,82 +1031,82 @@ func (i *LD_a16Deref_SP) Execute(v vm) (ExecutionResult, error) {
 
 // Execute ADD_A_B instruction.
 func (i *ADD_A_B) Execute(v vm) (ExecutionResult, error) {
-	return addIntoA(v, i, v.Reg().A, v.Reg().B)
+	return addIntoA(v, i, v.Reg().B)
 }
 
 // Execute ADD_A_C instruction.
 func (i *ADD_A_C) Execute(v vm) (ExecutionResult, error) {
-	return addIntoA(v, i, v.Reg().A, v.Reg().C)
+	return addIntoA(v, i, v.Reg().C)
 }
 
 // Execute ADD_A_D instruction.
 func (i *ADD_A_D) Execute(v vm) (ExecutionResult, error) {
-	return addIntoA(v, i, v.Reg().A, v.Reg().D)
+	return addIntoA(v, i, v.Reg().D)
 }
 
 // Execute ADD_A_E instruction.
 func (i *ADD_A_E) Execute(v vm) (ExecutionResult, error) {
-	return addIntoA(v, i, v.Reg().A, v.Reg().E)
+	return addIntoA(v, i, v.Reg().E)
 }
 
 // Execute ADD_A_H instruction.
 func (i *ADD_A_H) Execute(v vm) (ExecutionResult, error) {
-	return addIntoA(v, i, v.Reg().A, v.Reg().H)
+	return addIntoA(v, i, v.Reg().H)
 }
 
 // Execute ADD_A_L instruction.
 func (i *ADD_A_L) Execute(v vm) (ExecutionResult, error) {
-	return addIntoA(v, i, v.Reg().A, v.Reg().L)
+	return addIntoA(v, i, v.Reg().L)
 }
 
 // Execute ADD_A_HLPtr instruction.
 func (i *ADD_A_HLPtr) Execute(v vm) (ExecutionResult, error) {
-	return ExecutionResult{}, ErrUnimplemented
+	return addIntoA(v, i, readHLPtr(v))
 }
 
 // Execute ADD_A_A instruction.
 func (i *ADD_A_A) Execute(v vm) (ExecutionResult, error) {
-	return addIntoA(v, i, v.Reg().A, v.Reg().A)
+	return addIntoA(v, i, v.Reg().A)
 }
 
 // Execute ADC_A_B instruction.
 func (i *ADC_A_B) Execute(v vm) (ExecutionResult, error) {
-	return ExecutionResult{}, ErrUnimplemented
+	return adcIntoA(v, i, v.Reg().B)
 }
 
 // Execute ADC_A_C instruction.
 func (i *ADC_A_C) Execute(v vm) (ExecutionResult, error) {
-	return ExecutionResult{}, ErrUnimplemented
+	return adcIntoA(v, i, v.Reg().C)
 }
 
 // Execute ADC_A_D instruction.
 func (i *ADC_A_D) Execute(v vm) (ExecutionResult, error) {
-	return ExecutionResult{}, ErrUnimplemented
+	return adcIntoA(v, i, v.Reg().D)
 }
 
 // Execute ADC_A_E instruction.
 func (i *ADC_A_E) Execute(v vm) (ExecutionResult, error) {
-	return ExecutionResult{}, ErrUnimplemented
+	return adcIntoA(v, i, v.Reg().E)
 }
 
 // Execute ADC_A_H instruction.
 func (i *ADC_A_H) Execute(v vm) (ExecutionResult, error) {
-	return ExecutionResult{}, ErrUnimplemented
+	return adcIntoA(v, i, v.Reg().H)
 }
 
 // Execute ADC_A_L instruction.
 func (i *ADC_A_L) Execute(v vm) (ExecutionResult, error) {
-	return ExecutionResult{}, ErrUnimplemented
+	return adcIntoA(v, i, v.Reg().L)
 }
 
 // Execute ADC_A_HLPtr instruction.
 func (i *ADC_A_HLPtr) Execute(v vm) (ExecutionResult, error) {
-	return ExecutionResult{}, ErrUnimplemented
+	return adcIntoA(v, i, readHLPtr(v))
 }
 
 // Execute ADC_A_A instruction.
 func (i *ADC_A_A) Execute(v vm) (ExecutionResult, error) {
-	return ExecutionResult{}, ErrUnimplemented
+	return adcIntoA(v, i, v.Reg().A)
 }
 
 // Execute ADD_HL_BC instruction.
@@ -819,82 +1116,82 @@ func (i *ADD_HL_BC) Execute(v vm) (ExecutionResult, error) {
 
 // Execute SUB_B instruction.
 func (i *SUB_B) Execute(v vm) (ExecutionResult, error) {
-	return ExecutionResult{}, ErrUnimplemented
+	return subFromA(v, i, v.Reg().B)
 }
 
 // Execute SUB_C instruction.
 func (i *SUB_C) Execute(v vm) (ExecutionResult, error) {
-	return ExecutionResult{}, ErrUnimplemented
+	return subFromA(v, i, v.Reg().C)
 }
 
 // Execute SUB_D instruction.
 func (i *SUB_D) Execute(v vm) (ExecutionResult, error) {
-	return ExecutionResult{}, ErrUnimplemented
+	return subFromA(v, i, v.Reg().D)
 }
 
 // Execute SUB_E instruction.
 func (i *SUB_E) Execute(v vm) (ExecutionResult, error) {
-	return ExecutionResult{}, ErrUnimplemented
+	return subFromA(v, i, v.Reg().E)
 }
 
 // Execute SUB_H instruction.
 func (i *SUB_H) Execute(v vm) (ExecutionResult, error) {
-	return ExecutionResult{}, ErrUnimplemented
+	return subFromA(v, i, v.Reg().H)
 }
 
 // Execute SUB_L instruction.
 func (i *SUB_L) Execute(v vm) (ExecutionResult, error) {
-	return ExecutionResult{}, ErrUnimplemented
+	return subFromA(v, i, v.Reg().L)
 }
 
 // Execute SUB_HLPtr instruction.
 func (i *SUB_HLPtr) Execute(v vm) (ExecutionResult, error) {
-	return ExecutionResult{}, ErrUnimplemented
+	return subFromA(v, i, readHLPtr(v))
 }
 
 // Execute SUB_A instruction.
 func (i *SUB_A) Execute(v vm) (ExecutionResult, error) {
-	return ExecutionResult{}, ErrUnimplemented
+	return subFromA(v, i, v.Reg().A)
 }
 
 // Execute SBC_A_B instruction.
 func (i *SBC_A_B) Execute(v vm) (ExecutionResult, error) {
-	return ExecutionResult{}, ErrUnimplemented
+	return sbcFromA(v, i, v.Reg().B)
 }
 
 // Execute SBC_A_C instruction.
 func (i *SBC_A_C) Execute(v vm) (ExecutionResult, error) {
-	return ExecutionResult{}, ErrUnimplemented
+	return sbcFromA(v, i, v.Reg().C)
 }
 
 // Execute SBC_A_D instruction.
 func (i *SBC_A_D) Execute(v vm) (ExecutionResult, error) {
-	return ExecutionResult{}, ErrUnimplemented
+	return sbcFromA(v, i, v.Reg().D)
 }
 
 // Execute SBC_A_E instruction.
 func (i *SBC_A_E) Execute(v vm) (ExecutionResult, error) {
-	return ExecutionResult{}, ErrUnimplemented
+	return sbcFromA(v, i, v.Reg().E)
 }
 
 // Execute SBC_A_H instruction.
 func (i *SBC_A_H) Execute(v vm) (ExecutionResult, error) {
-	return ExecutionResult{}, ErrUnimplemented
+	return sbcFromA(v, i, v.Reg().H)
 }
 
 // Execute SBC_A_L instruction.
 func (i *SBC_A_L) Execute(v vm) (ExecutionResult, error) {
-	return ExecutionResult{}, ErrUnimplemented
+	return sbcFromA(v, i, v.Reg().L)
 }
 
 // Execute SBC_A_HLPtr instruction.
 func (i *SBC_A_HLPtr) Execute(v vm) (ExecutionResult, error) {
-	return ExecutionResult{}, ErrUnimplemented
+	return sbcFromA(v, i, readHLPtr(v))
 }
 
 // Execute SBC_A_A instruction.
 func (i *SBC_A_A) Execute(v vm) (ExecutionResult, error) {
-	return ExecutionResult{}, ErrUnimplemented
+	return sbcFromA(v, i, v.Reg().A)
 }
 
 // Execute LD_A_BCDeref instruction.
@@ -904,82 +1201,82 @@ func (i *LD_A_BCDeref) Execute(v vm) (ExecutionResult, error) {
 
 // Execute AND_B instruction.
 func (i *AND_B) Execute(v vm) (ExecutionResult, error) {
-	return ExecutionResult{}, ErrUnimplemented
+	return andIntoA(v, i, v.Reg().B)
 }
 
 // Execute AND_C instruction.
 func (i *AND_C) Execute(v vm) (ExecutionResult, error) {
-	return ExecutionResult{}, ErrUnimplemented
+	return andIntoA(v, i, v.Reg().C)
 }
 
 // Execute AND_D instruction.
 func (i *AND_D) Execute(v vm) (ExecutionResult, error) {
-	return ExecutionResult{}, ErrUnimplemented
+	return andIntoA(v, i, v.Reg().D)
 }
 
 // Execute AND_E instruction.
 func (i *AND_E) Execute(v vm) (ExecutionResult, error) {
-	return ExecutionResult{}, ErrUnimplemented
+	return andIntoA(v, i, v.Reg().E)
 }
 
 // Execute AND_H instruction.
 func (i *AND_H) Execute(v vm) (ExecutionResult, error) {
-	return ExecutionResult{}, ErrUnimplemented
+	return andIntoA(v, i, v.Reg().H)
 }
 
 // Execute AND_L instruction.
 func (i *AND_L) Execute(v vm) (ExecutionResult, error) {
-	return ExecutionResult{}, ErrUnimplemented
+	return andIntoA(v, i, v.Reg().L)
 }
 
 // Execute AND_HLPtr instruction.
 func (i *AND_HLPtr) Execute(v vm) (ExecutionResult, error) {
-	return ExecutionResult{}, ErrUnimplemented
+	return andIntoA(v, i, readHLPtr(v))
 }
 
 // Execute AND_A instruction.
 func (i *AND_A) Execute(v vm) (ExecutionResult, error) {
-	return ExecutionResult{}, ErrUnimplemented
+	return andIntoA(v, i, v.Reg().A)
 }
 
 // Execute XOR_B instruction.
 func (i *XOR_B) Execute(v vm) (ExecutionResult, error) {
-	return ExecutionResult{}, ErrUnimplemented
+	return xorIntoA(v, i, v.Reg().B)
 }
 
 // Execute XOR_C instruction.
 func (i *XOR_C) Execute(v vm) (ExecutionResult, error) {
-	return ExecutionResult{}, ErrUnimplemented
+	return xorIntoA(v, i, v.Reg().C)
 }
 
 // Execute XOR_D instruction.
 func (i *XOR_D) Execute(v vm) (ExecutionResult, error) {
-	return ExecutionResult{}, ErrUnimplemented
+	return xorIntoA(v, i, v.Reg().D)
 }
 
 // Execute XOR_E instruction.
 func (i *XOR_E) Execute(v vm) (ExecutionResult, error) {
-	return ExecutionResult{}, ErrUnimplemented
+	return xorIntoA(v, i, v.Reg().E)
 }
 
 // Execute XOR_H instruction.
 func (i *XOR_H) Execute(v vm) (ExecutionResult, error) {
-	return ExecutionResult{}, ErrUnimplemented
+	return xorIntoA(v, i, v.Reg().H)
 }
 
 // Execute XOR_L instruction.
 func (i *XOR_L) Execute(v vm) (ExecutionResult, error) {
-	return ExecutionResult{}, ErrUnimplemented
+	return xorIntoA(v, i, v.Reg().L)
 }
 
 // Execute XOR_HLPtr instruction.
 func (i *XOR_HLPtr) Execute(v vm) (ExecutionResult, error) {
-	return ExecutionResult{}, ErrUnimplemented
+	return xorIntoA(v, i, readHLPtr(v))
 }
 
 // Execute XOR_A instruction.
 func (i *XOR_A) Execute(v vm) (ExecutionResult, error) {
-	return ExecutionResult{}, ErrUnimplemented
+	return xorIntoA(v, i, v.Reg().A)
 }
 
 // Execute DEC_BC instruction.
@@ -989,82 +1286,82 @@ func (i *DEC_BC) Execute(v vm) (ExecutionResult, error) {
 
 // Execute OR_B instruction.
 func (i *OR_B) Execute(v vm) (ExecutionResult, error) {
-	return ExecutionResult{}, ErrUnimplemented
+	return orIntoA(v, i, v.Reg().B)
 }
 
 // Execute OR_C instruction.
 func (i *OR_C) Execute(v vm) (ExecutionResult, error) {
-	return ExecutionResult{}, ErrUnimplemented
+	return orIntoA(v, i, v.Reg().C)
 }
 
 // Execute OR_D instruction.
 func (i *OR_D) Execute(v vm) (ExecutionResult, error) {
-	return ExecutionResult{}, ErrUnimplemented
+	return orIntoA(v, i, v.Reg().D)
 }
 
 // Execute OR_E instruction.
 func (i *OR_E) Execute(v vm) (ExecutionResult, error) {
-	return ExecutionResult{}, ErrUnimplemented
+	return orIntoA(v, i, v.Reg().E)
 }
 
 // Execute OR_H instruction.
 func (i *OR_H) Execute(v vm) (ExecutionResult, error) {
-	return ExecutionResult{}, ErrUnimplemented
+	return orIntoA(v, i, v.Reg().H)
 }
 
 // Execute OR_L instruction.
 func (i *OR_L) Execute(v vm) (ExecutionResult, error) {
-	return ExecutionResult{}, ErrUnimplemented
+	return orIntoA(v, i, v.Reg().L)
 }
 
 // Execute OR_HLPtr instruction.
 func (i *OR_HLPtr) Execute(v vm) (ExecutionResult, error) {
-	return ExecutionResult{}, ErrUnimplemented
+	return orIntoA(v, i, readHLPtr(v))
 }
 
 // Execute OR_A instruction.
 func (i *OR_A) Execute(v vm) (ExecutionResult, error) {
-	return ExecutionResult{}, ErrUnimplemented
+	return orIntoA(v, i, v.Reg().A)
 }
 
 // Execute CP_B instruction.
 func (i *CP_B) Execute(v vm) (ExecutionResult, error) {
-	return ExecutionResult{}, ErrUnimplemented
+	return compareWithA(v, i, v.Reg().B)
 }
 
 // Execute CP_C instruction.
 func (i *CP_C) Execute(v vm) (ExecutionResult, error) {
-	return ExecutionResult{}, ErrUnimplemented
+	return compareWithA(v, i, v.Reg().C)
 }
 
 // Execute CP_D instruction.
 func (i *CP_D) Execute(v vm) (ExecutionResult, error) {
-	return ExecutionResult{}, ErrUnimplemented
+	return compareWithA(v, i, v.Reg().D)
 }
 
 // Execute CP_E instruction.
 func (i *CP_E) Execute(v vm) (ExecutionResult, error) {
-	return ExecutionResult{}, ErrUnimplemented
+	return compareWithA(v, i, v.Reg().E)
 }
 
 // Execute CP_H instruction.
 func (i *CP_H) Execute(v vm) (ExecutionResult, error) {
-	return ExecutionResult{}, ErrUnimplemented
+	return compareWithA(v, i, v.Reg().H)
 }
 
 // Execute CP_L instruction.
 func (i *CP_L) Execute(v vm) (ExecutionResult, error) {
-	return ExecutionResult{}, ErrUnimplemented
+	return compareWithA(v, i, v.Reg().L)
 }
 
 // Execute CP_HLPtr instruction.
 func (i *CP_HLPtr) Execute(v vm) (ExecutionResult, error) {
-	return ExecutionResult{}, ErrUnimplemented
+	return compareWithA(v, i, readHLPtr(v))
 }
 
 // Execute CP_A instruction.
 func (i *CP_A) Execute(v vm) (ExecutionResult, error) {
-	return ExecutionResult{}, ErrUnimplemented
+	return compareWithA(v, i, v.Reg().A)
 }
 
 // Execute INC_C instruction.
@@ -1074,32 +1371,38 @@ func (i *INC_C) Execute(v vm) (ExecutionResult, error) {
 
 // Execute RET_NZ instruction.
 func (i *RET_NZ) Execute(v vm) (ExecutionResult, error) {
-	return ExecutionResult{}, ErrUnimplemented
+	return retIf(v, i, !v.Reg().F.Z())
 }
 
 // Execute POP_BC instruction.
 func (i *POP_BC) Execute(v vm) (ExecutionResult, error) {
-	return ExecutionResult{}, ErrUnimplemented
+	return pop(v, i, v.Reg().SetBC)
 }
 
 // Execute JP_NZ_a16 instruction.
 func (i *JP_NZ_a16) Execute(v vm) (ExecutionResult, error) {
-	return ExecutionResult{}, ErrUnimplemented
+	return jumpIf(v, i, !v.Reg().F.Z(), i.operand2)
 }
 
 // Execute JP_a16 instruction.
 func (i *JP_a16) Execute(v vm) (ExecutionResult, error) {
-	return ExecutionResult{}, ErrUnimplemented
+	addr, ok := i.operand1.(uint16)
+	if !ok {
+		return ExecutionResult{}, ErrOperatorNotValidType
+	}
+
+	v.Reg().PC = addr
+	return ExecutionResult{Cycles: i.cycles()[0], DidSetPC: true}, nil
 }
 
 // Execute CALL_NZ_a16 instruction.
 func (i *CALL_NZ_a16) Execute(v vm) (ExecutionResult, error) {
-	return ExecutionResult{}, ErrUnimplemented
+	return callIf(v, i, !v.Reg().F.Z(), i.operand2)
 }
 
 // Execute PUSH_BC instruction.
 func (i *PUSH_BC) Execute(v vm) (ExecutionResult, error) {
-	return ExecutionResult{}, ErrUnimplemented
+	return push(v, i, v.Reg().BC())
 }
 
 // Execute ADD_A_d8 instruction.
@@ -1109,27 +1412,27 @@ func (i *ADD_A_d8) Execute(v vm) (ExecutionResult, error) {
 		return ExecutionResult{}, ErrOperatorNotValidType
 	}
 
-	return addIntoA(v, i, v.Reg().A, registers.Reg(d))
+	return addIntoA(v, i, registers.Reg(d))
 }
 
 // Execute RST_00H instruction.
 func (i *RST_00H) Execute(v vm) (ExecutionResult, error) {
-	return ExecutionResult{}, ErrUnimplemented
+	return call(v, i, 0x00)
 }
 
 // Execute RET_Z instruction.
 func (i *RET_Z) Execute(v vm) (ExecutionResult, error) {
-	return ExecutionResult{}, ErrUnimplemented
+	return retIf(v, i, v.Reg().F.Z())
 }
 
 // Execute RET instruction.
 func (i *RET) Execute(v vm) (ExecutionResult, error) {
-	return ExecutionResult{}, ErrUnimplemented
+	return ret(v, i)
 }
 
 // Execute JP_Z_a16 instruction.
 func (i *JP_Z_a16) Execute(v vm) (ExecutionResult, error) {
-	return ExecutionResult{}, ErrUnimplemented
+	return jumpIf(v, i, v.Reg().F.Z(), i.operand2)
 }
 
 // Execute PREFIX_CB instruction.
@@ -1139,22 +1442,32 @@ func (i *PREFIX_CB) Execute(v vm) (ExecutionResult, error) {
 
 // Execute CALL_Z_a16 instruction.
 func (i *CALL_Z_a16) Execute(v vm) (ExecutionResult, error) {
-	return ExecutionResult{}, ErrUnimplemented
+	return callIf(v, i, v.Reg().F.Z(), i.operand2)
 }
 
 // Execute CALL_a16 instruction.
 func (i *CALL_a16) Execute(v vm) (ExecutionResult, error) {
-	return ExecutionResult{}, ErrUnimplemented
+	addr, ok := i.operand1.(uint16)
+	if !ok {
+		return ExecutionResult{}, ErrOperatorNotValidType
+	}
+
+	return call(v, i, addr)
 }
 
 // Execute ADC_A_d8 instruction.
 func (i *ADC_A_d8) Execute(v vm) (ExecutionResult, error) {
-	return ExecutionResult{}, ErrUnimplemented
+	d, ok := i.operand2.(uint8)
+	if !ok {
+		return ExecutionResult{}, ErrOperatorNotValidType
+	}
+
+	return adcIntoA(v, i, registers.Reg(d))
 }
 
 // Execute RST_08H instruction.
 func (i *RST_08H) Execute(v vm) (ExecutionResult, error) {
-	return ExecutionResult{}, ErrUnimplemented
+	return call(v, i, 0x08)
 }
 
 // Execute DEC_C instruction.
@@ -1164,67 +1477,78 @@ func (i *DEC_C) Execute(v vm) (ExecutionResult, error) {
 
 // Execute RET_NC instruction.
 func (i *RET_NC) Execute(v vm) (ExecutionResult, error) {
-	return ExecutionResult{}, ErrUnimplemented
+	return retIf(v, i, !v.Reg().F.C())
 }
 
 // Execute POP_DE instruction.
 func (i *POP_DE) Execute(v vm) (ExecutionResult, error) {
-	return ExecutionResult{}, ErrUnimplemented
+	return pop(v, i, v.Reg().SetDE)
 }
 
 // Execute JP_NC_a16 instruction.
 func (i *JP_NC_a16) Execute(v vm) (ExecutionResult, error) {
-	return ExecutionResult{}, ErrUnimplemented
+	return jumpIf(v, i, !v.Reg().F.C(), i.operand2)
 }
 
 // Execute CALL_NC_a16 instruction.
 func (i *CALL_NC_a16) Execute(v vm) (ExecutionResult, error) {
-	return ExecutionResult{}, ErrUnimplemented
+	return callIf(v, i, !v.Reg().F.C(), i.operand2)
 }
 
 // Execute PUSH_DE instruction.
 func (i *PUSH_DE) Execute(v vm) (ExecutionResult, error) {
-	return ExecutionResult{}, ErrUnimplemented
+	return push(v, i, v.Reg().DE())
 }
 
 // Execute SUB_d8 instruction.
 func (i *SUB_d8) Execute(v vm) (ExecutionResult, error) {
-	return ExecutionResult{}, ErrUnimplemented
+	d, ok := i.operand1.(uint8)
+	if !ok {
+		return ExecutionResult{}, ErrOperatorNotValidType
+	}
+
+	return subFromA(v, i, registers.Reg(d))
 }
 
 // Execute RST_10H instruction.
 func (i *RST_10H) Execute(v vm) (ExecutionResult, error) {
-	return ExecutionResult{}, ErrUnimplemented
+	return call(v, i, 0x10)
 }
 
 // Execute RET_C instruction.
 func (i *RET_C) Execute(v vm) (ExecutionResult, error) {
-	return ExecutionResult{}, ErrUnimplemented
+	return retIf(v, i, v.Reg().F.C())
 }
 
 // Execute RETI instruction.
 func (i *RETI) Execute(v vm) (ExecutionResult, error) {
-	return ExecutionResult{}, ErrUnimplemented
+	v.Interrupts().Enable()
+	return ret(v, i)
 }
 
 // Execute JP_C_a16 instruction.
 func (i *JP_C_a16) Execute(v vm) (ExecutionResult, error) {
-	return ExecutionResult{}, ErrUnimplemented
+	return jumpIf(v, i, v.Reg().F.C(), i.operand2)
 }
 
 // Execute CALL_C_a16 instruction.
 func (i *CALL_C_a16) Execute(v vm) (ExecutionResult, error) {
-	return ExecutionResult{}, ErrUnimplemented
+	return callIf(v, i, v.Reg().F.C(), i.operand2)
 }
 
 // Execute SBC_A_d8 instruction.
 func (i *SBC_A_d8) Execute(v vm) (ExecutionResult, error) {
-	return ExecutionResult{}, ErrUnimplemented
+	d, ok := i.operand2.(uint8)
+	if !ok {
+		return ExecutionResult{}, ErrOperatorNotValidType
+	}
+
+	return sbcFromA(v, i, registers.Reg(d))
 }
 
 // Execute RST_18H instruction.
 func (i *RST_18H) Execute(v vm) (ExecutionResult, error) {
-	return ExecutionResult{}, ErrUnimplemented
+	return call(v, i, 0x18)
 }
 
 // Execute LD_C_d8 instruction.
@@ -1239,7 +1563,7 @@ func (i *LDH_a8Deref_A) Execute(v vm) (ExecutionResult, error) {
 
 // Execute POP_HL instruction.
 func (i *POP_HL) Execute(v vm) (ExecutionResult, error) {
-	return ExecutionResult{}, ErrUnimplemented
+	return pop(v, i, v.Reg().SetHL)
 }
 
 // Execute LD_CDeref_A instruction.
@@ -1249,17 +1573,22 @@ func (i *LD_CDeref_A) Execute(v vm) (ExecutionResult, error) {
 
 // Execute PUSH_HL instruction.
 func (i *PUSH_HL) Execute(v vm) (ExecutionResult, error) {
-	return ExecutionResult{}, ErrUnimplemented
+	return push(v, i, v.Reg().HL())
 }
 
 // Execute AND_d8 instruction.
 func (i *AND_d8) Execute(v vm) (ExecutionResult, error) {
-	return ExecutionResult{}, ErrUnimplemented
+	d, ok := i.operand1.(uint8)
+	if !ok {
+		return ExecutionResult{}, ErrOperatorNotValidType
+	}
+
+	return andIntoA(v, i, registers.Reg(d))
 }
 
 // Execute RST_20H instruction.
 func (i *RST_20H) Execute(v vm) (ExecutionResult, error) {
-	return ExecutionResult{}, ErrUnimplemented
+	return call(v, i, 0x20)
 }
 
 // Execute ADD_SP_r8 instruction.
@@ -1269,7 +1598,10 @@ func (i *ADD_SP_r8) Execute(v vm) (ExecutionResult, error) {
 
 // Execute JP_HLPtr instruction.
 func (i *JP_HLPtr) Execute(v vm) (ExecutionResult, error) {
-	return ExecutionResult{}, ErrUnimplemented
+	// Despite the mnemonic this jumps to the address in HL, it doesn't read
+	// the target out of memory.
+	v.Reg().PC = v.Reg().HL()
+	return ExecutionResult{Cycles: i.cycles()[0], DidSetPC: true}, nil
 }
 
 // Execute LD_a16Deref_A instruction.
@@ -1279,12 +1611,17 @@ func (i *LD_a16Deref_A) Execute(v vm) (ExecutionResult, error) {
 
 // Execute XOR_d8 instruction.
 func (i *XOR_d8) Execute(v vm) (ExecutionResult, error) {
-	return ExecutionResult{}, ErrUnimplemented
+	d, ok := i.operand1.(uint8)
+	if !ok {
+		return ExecutionResult{}, ErrOperatorNotValidType
+	}
+
+	return xorIntoA(v, i, registers.Reg(d))
 }
 
 // Execute RST_28H instruction.
 func (i *RST_28H) Execute(v vm) (ExecutionResult, error) {
-	return ExecutionResult{}, ErrUnimplemented
+	return call(v, i, 0x28)
 }
 
 // Execute RRCA instruction.
@@ -1299,7 +1636,8 @@ func (i *LDH_A_a8Deref) Execute(v vm) (ExecutionResult, error) {
 
 // Execute POP_AF instruction.
 func (i *POP_AF) Execute(v vm) (ExecutionResult, error) {
-	return ExecutionResult{}, ErrUnimplemented
+	// SetAF drops the lower nibble of F, which doesn't exist in hardware.
+	return pop(v, i, v.Reg().SetAF)
 }
 
 // Execute LD_A_CDeref instruction.
@@ -1309,22 +1647,28 @@ func (i *LD_A_CDeref) Execute(v vm) (ExecutionResult, error) {
 
 // Execute DI instruction.
 func (i *DI) Execute(v vm) (ExecutionResult, error) {
-	return ExecutionResult{}, ErrUnimplemented
+	v.Interrupts().Disable()
+	return ExecutionResult{Cycles: i.cycles()[0]}, nil
 }
 
 // Execute PUSH_AF instruction.
 func (i *PUSH_AF) Execute(v vm) (ExecutionResult, error) {
-	return ExecutionResult{}, ErrUnimplemented
+	return push(v, i, v.Reg().AF())
 }
 
 // Execute OR_d8 instruction.
 func (i *OR_d8) Execute(v vm) (ExecutionResult, error) {
-	return ExecutionResult{}, ErrUnimplemented
+	d, ok := i.operand1.(uint8)
+	if !ok {
+		return ExecutionResult{}, ErrOperatorNotValidType
+	}
+
+	return orIntoA(v, i, registers.Reg(d))
 }
 
 // Execute RST_30H instruction.
 func (i *RST_30H) Execute(v vm) (ExecutionResult, error) {
-	return ExecutionResult{}, ErrUnimplemented
+	return call(v, i, 0x30)
 }
 
 // Execute LD_HL_SP_plus_r8 instruction.
@@ -1342,1297 +1686,1303 @@ func (i *LD_A_a16Deref) Execute(v vm) (ExecutionResult, error) {
 	return ExecutionResult{}, ErrUnimplemented
 }
 
-// Execute EI instruction.
+// Execute EI instruction. IME is only set after the following instruction.
 func (i *EI) Execute(v vm) (ExecutionResult, error) {
-	return ExecutionResult{}, ErrUnimplemented
+	v.Interrupts().EnableDelayed()
+	return ExecutionResult{Cycles: i.cycles()[0]}, nil
 }
 
 // Execute CP_d8 instruction.
 func (i *CP_d8) Execute(v vm) (ExecutionResult, error) {
-	return ExecutionResult{}, ErrUnimplemented
+	d, ok := i.operand1.(uint8)
+	if !ok {
+		return ExecutionResult{}, ErrOperatorNotValidType
+	}
+
+	return compareWithA(v, i, registers.Reg(d))
 }
 
 // Execute RST_38H instruction.
 func (i *RST_38H) Execute(v vm) (ExecutionResult, error) {
-	return ExecutionResult{}, ErrUnimplemented
+	return call(v, i, 0x38)
 }
 
 // Execute RLC_B instruction.
 func (i *RLC_B) Execute(v vm) (ExecutionResult, error) {
-	return ExecutionResult{}, ErrUnimplemented
+	return applyToReg(v, i, &v.Reg().B, rlc)
 }
 
 // Execute RLC_C instruction.
 func (i *RLC_C) Execute(v vm) (ExecutionResult, error) {
-	return ExecutionResult{}, ErrUnimplemented
+	return applyToReg(v, i, &v.Reg().C, rlc)
 }
 
 // Execute RL_B instruction.
 func (i *RL_B) Execute(v vm) (ExecutionResult, error) {
-	return ExecutionResult{}, ErrUnimplemented
+	return applyToReg(v, i, &v.Reg().B, rl)
 }
 
 // Execute RL_C instruction.
 func (i *RL_C) Execute(v vm) (ExecutionResult, error) {
-	return ExecutionResult{}, ErrUnimplemented
+	return applyToReg(v, i, &v.Reg().C, rl)
 }
 
 // Execute RL_D instruction.
 func (i *RL_D) Execute(v vm) (ExecutionResult, error) {
-	return ExecutionResult{}, ErrUnimplemented
+	return applyToReg(v, i, &v.Reg().D, rl)
 }
 
 // Execute RL_E instruction.
 func (i *RL_E) Execute(v vm) (ExecutionResult, error) {
-	return ExecutionResult{}, ErrUnimplemented
+	return applyToReg(v, i, &v.Reg().E, rl)
 }
 
 // Execute RL_H instruction.
 func (i *RL_H) Execute(v vm) (ExecutionResult, error) {
-	return ExecutionResult{}, ErrUnimplemented
+	return applyToReg(v, i, &v.Reg().H, rl)
 }
 
 // Execute RL_L instruction.
 func (i *RL_L) Execute(v vm) (ExecutionResult, error) {
-	return ExecutionResult{}, ErrUnimplemented
+	return applyToReg(v, i, &v.Reg().L, rl)
 }
 
 // Execute RL_HLPtr instruction.
 func (i *RL_HLPtr) Execute(v vm) (ExecutionResult, error) {
-	return ExecutionResult{}, ErrUnimplemented
+	return applyToHLPtr(v, i, rl)
 }
 
 // Execute RL_A instruction.
 func (i *RL_A) Execute(v vm) (ExecutionResult, error) {
-	return ExecutionResult{}, ErrUnimplemented
+	return applyToReg(v, i, &v.Reg().A, rl)
 }
 
 // Execute RR_B instruction.
 func (i *RR_B) Execute(v vm) (ExecutionResult, error) {
-	return ExecutionResult{}, ErrUnimplemented
+	return applyToReg(v, i, &v.Reg().B, rr)
 }
 
 // Execute RR_C instruction.
 func (i *RR_C) Execute(v vm) (ExecutionResult, error) {
-	return ExecutionResult{}, ErrUnimplemented
+	return applyToReg(v, i, &v.Reg().C, rr)
 }
 
 // Execute RR_D instruction.
 func (i *RR_D) Execute(v vm) (ExecutionResult, error) {
-	return ExecutionResult{}, ErrUnimplemented
+	return applyToReg(v, i, &v.Reg().D, rr)
 }
 
 // Execute RR_E instruction.
 func (i *RR_E) Execute(v vm) (ExecutionResult, error) {
-	return ExecutionResult{}, ErrUnimplemented
+	return applyToReg(v, i, &v.Reg().E, rr)
 }
 
 // Execute RR_H instruction.
 func (i *RR_H) Execute(v vm) (ExecutionResult, error) {
-	return ExecutionResult{}, ErrUnimplemented
+	return applyToReg(v, i, &v.Reg().H, rr)
 }
 
 // Execute RR_L instruction.
 func (i *RR_L) Execute(v vm) (ExecutionResult, error) {
-	return ExecutionResult{}, ErrUnimplemented
+	return applyToReg(v, i, &v.Reg().L, rr)
 }
 
 // Execute RR_HLPtr instruction.
 func (i *RR_HLPtr) Execute(v vm) (ExecutionResult, error) {
-	return ExecutionResult{}, ErrUnimplemented
+	return applyToHLPtr(v, i, rr)
 }
 
 // Execute RR_A instruction.
 func (i *RR_A) Execute(v vm) (ExecutionResult, error) {
-	return ExecutionResult{}, ErrUnimplemented
+	return applyToReg(v, i, &v.Reg().A, rr)
 }
 
 // Execute RLC_D instruction.
 func (i *RLC_D) Execute(v vm) (ExecutionResult, error) {
-	return ExecutionResult{}, ErrUnimplemented
+	return applyToReg(v, i, &v.Reg().D, rlc)
 }
 
 // Execute SLA_B instruction.
 func (i *SLA_B) Execute(v vm) (ExecutionResult, error) {
-	return ExecutionResult{}, ErrUnimplemented
+	return applyToReg(v, i, &v.Reg().B, sla)
 }
 
 // Execute SLA_C instruction.
 func (i *SLA_C) Execute(v vm) (ExecutionResult, error) {
-	return ExecutionResult{}, ErrUnimplemented
+	return applyToReg(v, i, &v.Reg().C, sla)
 }
 
 // Execute SLA_D instruction.
 func (i *SLA_D) Execute(v vm) (ExecutionResult, error) {
-	return ExecutionResult{}, ErrUnimplemented
+	return applyToReg(v, i, &v.Reg().D, sla)
 }
 
 // Execute SLA_E instruction.
 func (i *SLA_E) Execute(v vm) (ExecutionResult, error) {
-	return ExecutionResult{}, ErrUnimplemented
+	return applyToReg(v, i, &v.Reg().E, sla)
 }
 
 // Execute SLA_H instruction.
 func (i *SLA_H) Execute(v vm) (ExecutionResult, error) {
-	return ExecutionResult{}, ErrUnimplemented
+	return applyToReg(v, i, &v.Reg().H, sla)
 }
 
 // Execute SLA_L instruction.
 func (i *SLA_L) Execute(v vm) (ExecutionResult, error) {
-	return ExecutionResult{}, ErrUnimplemented
+	return applyToReg(v, i, &v.Reg().L, sla)
 }
 
 // Execute SLA_HLPtr instruction.
 func (i *SLA_HLPtr) Execute(v vm) (ExecutionResult, error) {
-	return ExecutionResult{}, ErrUnimplemented
+	return applyToHLPtr(v, i, sla)
 }
 
 // Execute SLA_A instruction.
 func (i *SLA_A) Execute(v vm) (ExecutionResult, error) {
-	return ExecutionResult{}, ErrUnimplemented
+	return applyToReg(v, i, &v.Reg().A, sla)
 }
 
 // Execute SRA_B instruction.
 func (i *SRA_B) Execute(v vm) (ExecutionResult, error) {
-	return ExecutionResult{}, ErrUnimplemented
+	return applyToReg(v, i, &v.Reg().B, sra)
 }
 
 // Execute SRA_C instruction.
 func (i *SRA_C) Execute(v vm) (ExecutionResult, error) {
-	return ExecutionResult{}, ErrUnimplemented
+	return applyToReg(v, i, &v.Reg().C, sra)
 }
 
 // Execute SRA_D instruction.
 func (i *SRA_D) Execute(v vm) (ExecutionResult, error) {
-	return ExecutionResult{}, ErrUnimplemented
+	return applyToReg(v, i, &v.Reg().D, sra)
 }
 
 // Execute SRA_E instruction.
 func (i *SRA_E) Execute(v vm) (ExecutionResult, error) {
-	return ExecutionResult{}, ErrUnimplemented
+	return applyToReg(v, i, &v.Reg().E, sra)
 }
 
 // Execute SRA_H instruction.
 func (i *SRA_H) Execute(v vm) (ExecutionResult, error) {
-	return ExecutionResult{}, ErrUnimplemented
+	return applyToReg(v, i, &v.Reg().H, sra)
 }
 
 // Execute SRA_L instruction.
 func (i *SRA_L) Execute(v vm) (ExecutionResult, error) {
-	return ExecutionResult{}, ErrUnimplemented
+	return applyToReg(v, i, &v.Reg().L, sra)
 }
 
 // Execute SRA_HLPtr instruction.
 func (i *SRA_HLPtr) Execute(v vm) (ExecutionResult, error) {
-	return ExecutionResult{}, ErrUnimplemented
+	return applyToHLPtr(v, i, sra)
 }
 
 // Execute SRA_A instruction.
 func (i *SRA_A) Execute(v vm) (ExecutionResult, error) {
-	return ExecutionResult{}, ErrUnimplemented
+	return applyToReg(v, i, &v.Reg().A, sra)
 }
 
 // Execute RLC_E instruction.
 func (i *RLC_E) Execute(v vm) (ExecutionResult, error) {
-	return ExecutionResult{}, ErrUnimplemented
+	return applyToReg(v, i, &v.Reg().E, rlc)
 }
 
 // Execute SWAP_B instruction.
 func (i *SWAP_B) Execute(v vm) (ExecutionResult, error) {
-	return ExecutionResult{}, ErrUnimplemented
+	return applyToReg(v, i, &v.Reg().B, swap)
 }
 
 // Execute SWAP_C instruction.
 func (i *SWAP_C) Execute(v vm) (ExecutionResult, error) {
-	return ExecutionResult{}, ErrUnimplemented
+	return applyToReg(v, i, &v.Reg().C, swap)
 }
 
 // Execute SWAP_D instruction.
 func (i *SWAP_D) Execute(v vm) (ExecutionResult, error) {
-	return ExecutionResult{}, ErrUnimplemented
+	return applyToReg(v, i, &v.Reg().D, swap)
 }
 
 // Execute SWAP_E instruction.
 func (i *SWAP_E) Execute(v vm) (ExecutionResult, error) {
-	return ExecutionResult{}, ErrUnimplemented
+	return applyToReg(v, i, &v.Reg().E, swap)
 }
 
 // Execute SWAP_H instruction.
 func (i *SWAP_H) Execute(v vm) (ExecutionResult, error) {
-	return ExecutionResult{}, ErrUnimplemented
+	return applyToReg(v, i, &v.Reg().H, swap)
 }
 
 // Execute SWAP_L instruction.
 func (i *SWAP_L) Execute(v vm) (ExecutionResult, error) {
-	return ExecutionResult{}, ErrUnimplemented
+	return applyToReg(v, i, &v.Reg().L, swap)
 }
 
 // Execute SWAP_HLPtr instruction.
 func (i *SWAP_HLPtr) Execute(v vm) (ExecutionResult, error) {
-	return ExecutionResult{}, ErrUnimplemented
+	return applyToHLPtr(v, i, swap)
 }
 
 // Execute SWAP_A instruction.
 func (i *SWAP_A) Execute(v vm) (ExecutionResult, error) {
-	return ExecutionResult{}, ErrUnimplemented
+	return applyToReg(v, i, &v.Reg().A, swap)
 }
 
 // Execute SRL_B instruction.
 func (i *SRL_B) Execute(v vm) (ExecutionResult, error) {
-	return ExecutionResult{}, ErrUnimplemented
+	return applyToReg(v, i, &v.Reg().B, srl)
 }
 
 // Execute SRL_C instruction.
 func (i *SRL_C) Execute(v vm) (ExecutionResult, error) {
-	return ExecutionResult{}, ErrUnimplemented
+	return applyToReg(v, i, &v.Reg().C, srl)
 }
 
 // Execute SRL_D instruction.
 func (i *SRL_D) Execute(v vm) (ExecutionResult, error) {
-	return ExecutionResult{}, ErrUnimplemented
+	return applyToReg(v, i, &v.Reg().D, srl)
 }
 
 // Execute SRL_E instruction.
 func (i *SRL_E) Execute(v vm) (ExecutionResult, error) {
-	return ExecutionResult{}, ErrUnimplemented
+	return applyToReg(v, i, &v.Reg().E, srl)
 }
 
 // Execute SRL_H instruction.
 func (i *SRL_H) Execute(v vm) (ExecutionResult, error) {
-	return ExecutionResult{}, ErrUnimplemented
+	return applyToReg(v, i, &v.Reg().H, srl)
 }
 
 // Execute SRL_L instruction.
 func (i *SRL_L) Execute(v vm) (ExecutionResult, error) {
-	return ExecutionResult{}, ErrUnimplemented
+	return applyToReg(v, i, &v.Reg().L, srl)
 }
 
 // Execute SRL_HLPtr instruction.
 func (i *SRL_HLPtr) Execute(v vm) (ExecutionResult, error) {
-	return ExecutionResult{}, ErrUnimplemented
+	return applyToHLPtr(v, i, srl)
 }
 
 // Execute SRL_A instruction.
 func (i *SRL_A) Execute(v vm) (ExecutionResult, error) {
-	return ExecutionResult{}, ErrUnimplemented
+	return applyToReg(v, i, &v.Reg().A, srl)
 }
 
 // Execute RLC_H instruction.
 func (i *RLC_H) Execute(v vm) (ExecutionResult, error) {
-	return ExecutionResult{}, ErrUnimplemented
+	return applyToReg(v, i, &v.Reg().H, rlc)
 }
 
 // Execute BIT_0_B instruction.
 func (i *BIT_0_B) Execute(v vm) (ExecutionResult, error) {
-	return ExecutionResult{}, ErrUnimplemented
+	return testBit(v, i, 0, v.Reg().B)
 }
 
 // Execute BIT_0_C instruction.
 func (i *BIT_0_C) Execute(v vm) (ExecutionResult, error) {
-	return ExecutionResult{}, ErrUnimplemented
+	return testBit(v, i, 0, v.Reg().C)
 }
 
 // Execute BIT_0_D instruction.
 func (i *BIT_0_D) Execute(v vm) (ExecutionResult, error) {
-	return ExecutionResult{}, ErrUnimplemented
+	return testBit(v, i, 0, v.Reg().D)
 }
 
 // Execute BIT_0_E instruction.
 func (i *BIT_0_E) Execute(v vm) (ExecutionResult, error) {
-	return ExecutionResult{}, ErrUnimplemented
+	return testBit(v, i, 0, v.Reg().E)
 }
 
 // Execute BIT_0_H instruction.
 func (i *BIT_0_H) Execute(v vm) (ExecutionResult, error) {
-	return ExecutionResult{}, ErrUnimplemented
+	return testBit(v, i, 0, v.Reg().H)
 }
 
 // Execute BIT_0_L instruction.
 func (i *BIT_0_L) Execute(v vm) (ExecutionResult, error) {
-	return ExecutionResult{}, ErrUnimplemented
+	return testBit(v, i, 0, v.Reg().L)
 }
 
 // Execute BIT_0_HLPtr instruction.
 func (i *BIT_0_HLPtr) Execute(v vm) (ExecutionResult, error) {
-	return ExecutionResult{}, ErrUnimplemented
+	return testBit(v, i, 0, readHLPtr(v))
 }
 
 // Execute BIT_0_A instruction.
 func (i *BIT_0_A) Execute(v vm) (ExecutionResult, error) {
-	return ExecutionResult{}, ErrUnimplemented
+	return testBit(v, i, 0, v.Reg().A)
 }
 
 // Execute BIT_1_B instruction.
 func (i *BIT_1_B) Execute(v vm) (ExecutionResult, error) {
-	return ExecutionResult{}, ErrUnimplemented
+	return testBit(v, i, 1, v.Reg().B)
 }
 
 // Execute BIT_1_C instruction.
 func (i *BIT_1_C) Execute(v vm) (ExecutionResult, error) {
-	return ExecutionResult{}, ErrUnimplemented
+	return testBit(v, i, 1, v.Reg().C)
 }
 
 // Execute BIT_1_D instruction.
 func (i *BIT_1_D) Execute(v vm) (ExecutionResult, error) {
-	return ExecutionResult{}, ErrUnimplemented
+	return testBit(v, i, 1, v.Reg().D)
 }
 
 // Execute BIT_1_E instruction.
 func (i *BIT_1_E) Execute(v vm) (ExecutionResult, error) {
-	return ExecutionResult{}, ErrUnimplemented
+	return testBit(v, i, 1, v.Reg().E)
 }
 
 // Execute BIT_1_H instruction.
 func (i *BIT_1_H) Execute(v vm) (ExecutionResult, error) {
-	return ExecutionResult{}, ErrUnimplemented
+	return testBit(v, i, 1, v.Reg().H)
 }
 
 // Execute BIT_1_L instruction.
 func (i *BIT_1_L) Execute(v vm) (ExecutionResult, error) {
-	return ExecutionResult{}, ErrUnimplemented
+	return testBit(v, i, 1, v.Reg().L)
 }
 
 // Execute BIT_1_HLPtr instruction.
 func (i *BIT_1_HLPtr) Execute(v vm) (ExecutionResult, error) {
-	return ExecutionResult{}, ErrUnimplemented
+	return testBit(v, i, 1, readHLPtr(v))
 }
 
 // Execute BIT_1_A instruction.
 func (i *BIT_1_A) Execute(v vm) (ExecutionResult, error) {
-	return ExecutionResult{}, ErrUnimplemented
+	return testBit(v, i, 1, v.Reg().A)
 }
 
 // Execute RLC_L instruction.
 func (i *RLC_L) Execute(v vm) (ExecutionResult, error) {
-	return ExecutionResult{}, ErrUnimplemented
+	return applyToReg(v, i, &v.Reg().L, rlc)
 }
 
 // Execute BIT_2_B instruction.
 func (i *BIT_2_B) Execute(v vm) (ExecutionResult, error) {
-	return ExecutionResult{}, ErrUnimplemented
+	return testBit(v, i, 2, v.Reg().B)
 }
 
 // Execute BIT_2_C instruction.
 func (i *BIT_2_C) Execute(v vm) (ExecutionResult, error) {
-	return ExecutionResult{}, ErrUnimplemented
+	return testBit(v, i, 2, v.Reg().C)
 }
 
 // Execute BIT_2_D instruction.
 func (i *BIT_2_D) Execute(v vm) (ExecutionResult, error) {
-	return ExecutionResult{}, ErrUnimplemented
+	return testBit(v, i, 2, v.Reg().D)
 }
 
 // Execute BIT_2_E instruction.
 func (i *BIT_2_E) Execute(v vm) (ExecutionResult, error) {
-	return ExecutionResult{}, ErrUnimplemented
+	return testBit(v, i, 2, v.Reg().E)
 }
 
 // Execute BIT_2_H instruction.
 func (i *BIT_2_H) Execute(v vm) (ExecutionResult, error) {
-	return ExecutionResult{}, ErrUnimplemented
+	return testBit(v, i, 2, v.Reg().H)
 }
 
 // Execute BIT_2_L instruction.
 func (i *BIT_2_L) Execute(v vm) (ExecutionResult, error) {
-	return ExecutionResult{}, ErrUnimplemented
+	return testBit(v, i, 2, v.Reg().L)
 }
 
 // Execute BIT_2_HLPtr instruction.
 func (i *BIT_2_HLPtr) Execute(v vm) (ExecutionResult, error) {
-	return ExecutionResult{}, ErrUnimplemented
+	return testBit(v, i, 2, readHLPtr(v))
 }
 
 // Execute BIT_2_A instruction.
 func (i *BIT_2_A) Execute(v vm) (ExecutionResult, error) {
-	return ExecutionResult{}, ErrUnimplemented
+	return testBit(v, i, 2, v.Reg().A)
 }
 
 // Execute BIT_3_B instruction.
 func (i *BIT_3_B) Execute(v vm) (ExecutionResult, error) {
-	return ExecutionResult{}, ErrUnimplemented
+	return testBit(v, i, 3, v.Reg().B)
 }
 
 // Execute BIT_3_C instruction.
 func (i *BIT_3_C) Execute(v vm) (ExecutionResult, error) {
-	return ExecutionResult{}, ErrUnimplemented
+	return testBit(v, i, 3, v.Reg().C)
 }
 
 // Execute BIT_3_D instruction.
 func (i *BIT_3_D) Execute(v vm) (ExecutionResult, error) {
-	return ExecutionResult{}, ErrUnimplemented
+	return testBit(v, i, 3, v.Reg().D)
 }
 
 // Execute BIT_3_E instruction.
 func (i *BIT_3_E) Execute(v vm) (ExecutionResult, error) {
-	return ExecutionResult{}, ErrUnimplemented
+	return testBit(v, i, 3, v.Reg().E)
 }
 
 // Execute BIT_3_H instruction.
 func (i *BIT_3_H) Execute(v vm) (ExecutionResult, error) {
-	return ExecutionResult{}, ErrUnimplemented
+	return testBit(v, i, 3, v.Reg().H)
 }
 
 // Execute BIT_3_L instruction.
 func (i *BIT_3_L) Execute(v vm) (ExecutionResult, error) {
-	return ExecutionResult{}, ErrUnimplemented
+	return testBit(v, i, 3, v.Reg().L)
 }
 
 // Execute BIT_3_HLPtr instruction.
 func (i *BIT_3_HLPtr) Execute(v vm) (ExecutionResult, error) {
-	return ExecutionResult{}, ErrUnimplemented
+	return testBit(v, i, 3, readHLPtr(v))
 }
 
 // Execute BIT_3_A instruction.
 func (i *BIT_3_A) Execute(v vm) (ExecutionResult, error) {
-	return ExecutionResult{}, ErrUnimplemented
+	return testBit(v, i, 3, v.Reg().A)
 }
 
 // Execute RLC_HLPtr instruction.
 func (i *RLC_HLPtr) Execute(v vm) (ExecutionResult, error) {
-	return ExecutionResult{}, ErrUnimplemented
+	return applyToHLPtr(v, i, rlc)
 }
 
 // Execute BIT_4_B instruction.
 func (i *BIT_4_B) Execute(v vm) (ExecutionResult, error) {
-	return ExecutionResult{}, ErrUnimplemented
+	return testBit(v, i, 4, v.Reg().B)
 }
 
 // Execute BIT_4_C instruction.
 func (i *BIT_4_C) Execute(v vm) (ExecutionResult, error) {
-	return ExecutionResult{}, ErrUnimplemented
+	return testBit(v, i, 4, v.Reg().C)
 }
 
 // Execute BIT_4_D instruction.
 func (i *BIT_4_D) Execute(v vm) (ExecutionResult, error) {
-	return ExecutionResult{}, ErrUnimplemented
+	return testBit(v, i, 4, v.Reg().D)
 }
 
 // Execute BIT_4_E instruction.
 func (i *BIT_4_E) Execute(v vm) (ExecutionResult, error) {
-	return ExecutionResult{}, ErrUnimplemented
+	return testBit(v, i, 4, v.Reg().E)
 }
 
 // Execute BIT_4_H instruction.
 func (i *BIT_4_H) Execute(v vm) (ExecutionResult, error) {
-	return ExecutionResult{}, ErrUnimplemented
+	return testBit(v, i, 4, v.Reg().H)
 }
 
 // Execute BIT_4_L instruction.
 func (i *BIT_4_L) Execute(v vm) (ExecutionResult, error) {
-	return ExecutionResult{}, ErrUnimplemented
+	return testBit(v, i, 4, v.Reg().L)
 }
 
 // Execute BIT_4_HLPtr instruction.
 func (i *BIT_4_HLPtr) Execute(v vm) (ExecutionResult, error) {
-	return ExecutionResult{}, ErrUnimplemented
+	return testBit(v, i, 4, readHLPtr(v))
 }
 
 // Execute BIT_4_A instruction.
 func (i *BIT_4_A) Execute(v vm) (ExecutionResult, error) {
-	return ExecutionResult{}, ErrUnimplemented
+	return testBit(v, i, 4, v.Reg().A)
 }
 
 // Execute BIT_5_B instruction.
 func (i *BIT_5_B) Execute(v vm) (ExecutionResult, error) {
-	return ExecutionResult{}, ErrUnimplemented
+	return testBit(v, i, 5, v.Reg().B)
 }
 
 // Execute BIT_5_C instruction.
 func (i *BIT_5_C) Execute(v vm) (ExecutionResult, error) {
-	return ExecutionResult{}, ErrUnimplemented
+	return testBit(v, i, 5, v.Reg().C)
 }
 
 // Execute BIT_5_D instruction.
 func (i *BIT_5_D) Execute(v vm) (ExecutionResult, error) {
-	return ExecutionResult{}, ErrUnimplemented
+	return testBit(v, i, 5, v.Reg().D)
 }
 
 // Execute BIT_5_E instruction.
 func (i *BIT_5_E) Execute(v vm) (ExecutionResult, error) {
-	return ExecutionResult{}, ErrUnimplemented
+	return testBit(v, i, 5, v.Reg().E)
 }
 
 // Execute BIT_5_H instruction.
 func (i *BIT_5_H) Execute(v vm) (ExecutionResult, error) {
-	return ExecutionResult{}, ErrUnimplemented
+	return testBit(v, i, 5, v.Reg().H)
 }
 
 // Execute BIT_5_L instruction.
 func (i *BIT_5_L) Execute(v vm) (ExecutionResult, error) {
-	return ExecutionResult{}, ErrUnimplemented
+	return testBit(v, i, 5, v.Reg().L)
 }
 
 // Execute BIT_5_HLPtr instruction.
 func (i *BIT_5_HLPtr) Execute(v vm) (ExecutionResult, error) {
-	return ExecutionResult{}, ErrUnimplemented
+	return testBit(v, i, 5, readHLPtr(v))
 }
 
 // Execute BIT_5_A instruction.
 func (i *BIT_5_A) Execute(v vm) (ExecutionResult, error) {
-	return ExecutionResult{}, ErrUnimplemented
+	return testBit(v, i, 5, v.Reg().A)
 }
 
 // Execute RLC_A instruction.
 func (i *RLC_A) Execute(v vm) (ExecutionResult, error) {
-	return ExecutionResult{}, ErrUnimplemented
+	return applyToReg(v, i, &v.Reg().A, rlc)
 }
 
 // Execute BIT_6_B instruction.
 func (i *BIT_6_B) Execute(v vm) (ExecutionResult, error) {
-	return ExecutionResult{}, ErrUnimplemented
+	return testBit(v, i, 6, v.Reg().B)
 }
 
 // Execute BIT_6_C instruction.
 func (i *BIT_6_C) Execute(v vm) (ExecutionResult, error) {
-	return ExecutionResult{}, ErrUnimplemented
+	return testBit(v, i, 6, v.Reg().C)
 }
 
 // Execute BIT_6_D instruction.
 func (i *BIT_6_D) Execute(v vm) (ExecutionResult, error) {
-	return ExecutionResult{}, ErrUnimplemented
+	return testBit(v, i, 6, v.Reg().D)
 }
 
 // Execute BIT_6_E instruction.
 func (i *BIT_6_E) Execute(v vm) (ExecutionResult, error) {
-	return ExecutionResult{}, ErrUnimplemented
+	return testBit(v, i, 6, v.Reg().E)
 }
 
 // Execute BIT_6_H instruction.
 func (i *BIT_6_H) Execute(v vm) (ExecutionResult, error) {
-	return ExecutionResult{}, ErrUnimplemented
+	return testBit(v, i, 6, v.Reg().H)
 }
 
 // Execute BIT_6_L instruction.
 func (i *BIT_6_L) Execute(v vm) (ExecutionResult, error) {
-	return ExecutionResult{}, ErrUnimplemented
+	return testBit(v, i, 6, v.Reg().L)
 }
 
 // Execute BIT_6_HLPtr instruction.
 func (i *BIT_6_HLPtr) Execute(v vm) (ExecutionResult, error) {
-	return ExecutionResult{}, ErrUnimplemented
+	return testBit(v, i, 6, readHLPtr(v))
 }
 
 // Execute BIT_6_A instruction.
 func (i *BIT_6_A) Execute(v vm) (ExecutionResult, error) {
-	return ExecutionResult{}, ErrUnimplemented
+	return testBit(v, i, 6, v.Reg().A)
 }
 
 // Execute BIT_7_B instruction.
 func (i *BIT_7_B) Execute(v vm) (ExecutionResult, error) {
-	return ExecutionResult{}, ErrUnimplemented
+	return testBit(v, i, 7, v.Reg().B)
 }
 
 // Execute BIT_7_C instruction.
 func (i *BIT_7_C) Execute(v vm) (ExecutionResult, error) {
-	return ExecutionResult{}, ErrUnimplemented
+	return testBit(v, i, 7, v.Reg().C)
 }
 
 // Execute BIT_7_D instruction.
 func (i *BIT_7_D) Execute(v vm) (ExecutionResult, error) {
-	return ExecutionResult{}, ErrUnimplemented
+	return testBit(v, i, 7, v.Reg().D)
 }
 
 // Execute BIT_7_E instruction.
 func (i *BIT_7_E) Execute(v vm) (ExecutionResult, error) {
-	return ExecutionResult{}, ErrUnimplemented
+	return testBit(v, i, 7, v.Reg().E)
 }
 
 // Execute BIT_7_H instruction.
 func (i *BIT_7_H) Execute(v vm) (ExecutionResult, error) {
-	return ExecutionResult{}, ErrUnimplemented
+	return testBit(v, i, 7, v.Reg().H)
 }
 
 // Execute BIT_7_L instruction.
 func (i *BIT_7_L) Execute(v vm) (ExecutionResult, error) {
-	return ExecutionResult{}, ErrUnimplemented
+	return testBit(v, i, 7, v.Reg().L)
 }
 
 // Execute BIT_7_HLPtr instruction.
 func (i *BIT_7_HLPtr) Execute(v vm) (ExecutionResult, error) {
-	return ExecutionResult{}, ErrUnimplemented
+	return testBit(v, i, 7, readHLPtr(v))
 }
 
 // Execute BIT_7_A instruction.
 func (i *BIT_7_A) Execute(v vm) (ExecutionResult, error) {
-	return ExecutionResult{}, ErrUnimplemented
+	return testBit(v, i, 7, v.Reg().A)
 }
 
 // Execute RRC_B instruction.
 func (i *RRC_B) Execute(v vm) (ExecutionResult, error) {
-	return ExecutionResult{}, ErrUnimplemented
+	return applyToReg(v, i, &v.Reg().B, rrc)
 }
 
 // Execute RES_0_B instruction.
 func (i *RES_0_B) Execute(v vm) (ExecutionResult, error) {
-	return ExecutionResult{}, ErrUnimplemented
+	return applyToReg(v, i, &v.Reg().B, res(0))
 }
 
 // Execute RES_0_C instruction.
 func (i *RES_0_C) Execute(v vm) (ExecutionResult, error) {
-	return ExecutionResult{}, ErrUnimplemented
+	return applyToReg(v, i, &v.Reg().C, res(0))
 }
 
 // Execute RES_0_D instruction.
 func (i *RES_0_D) Execute(v vm) (ExecutionResult, error) {
-	return ExecutionResult{}, ErrUnimplemented
+	return applyToReg(v, i, &v.Reg().D, res(0))
 }
 
 // Execute RES_0_E instruction.
 func (i *RES_0_E) Execute(v vm) (ExecutionResult, error) {
-	return ExecutionResult{}, ErrUnimplemented
+	return applyToReg(v, i, &v.Reg().E, res(0))
 }
 
 // Execute RES_0_H instruction.
 func (i *RES_0_H) Execute(v vm) (ExecutionResult, error) {
-	return ExecutionResult{}, ErrUnimplemented
+	return applyToReg(v, i, &v.Reg().H, res(0))
 }
 
 // Execute RES_0_L instruction.
 func (i *RES_0_L) Execute(v vm) (ExecutionResult, error) {
-	return ExecutionResult{}, ErrUnimplemented
+	return applyToReg(v, i, &v.Reg().L, res(0))
 }
 
 // Execute RES_0_HLPtr instruction.
 func (i *RES_0_HLPtr) Execute(v vm) (ExecutionResult, error) {
-	return ExecutionResult{}, ErrUnimplemented
+	return applyToHLPtr(v, i, res(0))
 }
 
 // Execute RES_0_A instruction.
 func (i *RES_0_A) Execute(v vm) (ExecutionResult, error) {
-	return ExecutionResult{}, ErrUnimplemented
+	return applyToReg(v, i, &v.Reg().A, res(0))
 }
 
 // Execute RES_1_B instruction.
 func (i *RES_1_B) Execute(v vm) (ExecutionResult, error) {
-	return ExecutionResult{}, ErrUnimplemented
+	return applyToReg(v, i, &v.Reg().B, res(1))
 }
 
 // Execute RES_1_C instruction.
 func (i *RES_1_C) Execute(v vm) (ExecutionResult, error) {
-	return ExecutionResult{}, ErrUnimplemented
+	return applyToReg(v, i, &v.Reg().C, res(1))
 }
 
 // Execute RES_1_D instruction.
 func (i *RES_1_D) Execute(v vm) (ExecutionResult, error) {
-	return ExecutionResult{}, ErrUnimplemented
+	return applyToReg(v, i, &v.Reg().D, res(1))
 }
 
 // Execute RES_1_E instruction.
 func (i *RES_1_E) Execute(v vm) (ExecutionResult, error) {
-	return ExecutionResult{}, ErrUnimplemented
+	return applyToReg(v, i, &v.Reg().E, res(1))
 }
 
 // Execute RES_1_H instruction.
 func (i *RES_1_H) Execute(v vm) (ExecutionResult, error) {
-	return ExecutionResult{}, ErrUnimplemented
+	return applyToReg(v, i, &v.Reg().H, res(1))
 }
 
 // Execute RES_1_L instruction.
 func (i *RES_1_L) Execute(v vm) (ExecutionResult, error) {
-	return ExecutionResult{}, ErrUnimplemented
+	return applyToReg(v, i, &v.Reg().L, res(1))
 }
 
 // Execute RES_1_HLPtr instruction.
 func (i *RES_1_HLPtr) Execute(v vm) (ExecutionResult, error) {
-	return ExecutionResult{}, ErrUnimplemented
+	return applyToHLPtr(v, i, res(1))
 }
 
 // Execute RES_1_A instruction.
 func (i *RES_1_A) Execute(v vm) (ExecutionResult, error) {
-	return ExecutionResult{}, ErrUnimplemented
+	return applyToReg(v, i, &v.Reg().A, res(1))
 }
 
 // Execute RRC_C instruction.
 func (i *RRC_C) Execute(v vm) (ExecutionResult, error) {
-	return ExecutionResult{}, ErrUnimplemented
+	return applyToReg(v, i, &v.Reg().C, rrc)
 }
 
 // Execute RES_2_B instruction.
 func (i *RES_2_B) Execute(v vm) (ExecutionResult, error) {
-	return ExecutionResult{}, ErrUnimplemented
+	return applyToReg(v, i, &v.Reg().B, res(2))
 }
 
 // Execute RES_2_C instruction.
 func (i *RES_2_C) Execute(v vm) (ExecutionResult, error) {
-	return ExecutionResult{}, ErrUnimplemented
+	return applyToReg(v, i, &v.Reg().C, res(2))
 }
 
 // Execute RES_2_D instruction.
 func (i *RES_2_D) Execute(v vm) (ExecutionResult, error) {
-	return ExecutionResult{}, ErrUnimplemented
+	return applyToReg(v, i, &v.Reg().D, res(2))
 }
 
 // Execute RES_2_E instruction.
 func (i *RES_2_E) Execute(v vm) (ExecutionResult, error) {
-	return ExecutionResult{}, ErrUnimplemented
+	return applyToReg(v, i, &v.Reg().E, res(2))
 }
 
 // Execute RES_2_H instruction.
 func (i *RES_2_H) Execute(v vm) (ExecutionResult, error) {
-	return ExecutionResult{}, ErrUnimplemented
+	return applyToReg(v, i, &v.Reg().H, res(2))
 }
 
 // Execute RES_2_L instruction.
 func (i *RES_2_L) Execute(v vm) (ExecutionResult, error) {
-	return ExecutionResult{}, ErrUnimplemented
+	return applyToReg(v, i, &v.Reg().L, res(2))
 }
 
 // Execute RES_2_HLPtr instruction.
 func (i *RES_2_HLPtr) Execute(v vm) (ExecutionResult, error) {
-	return ExecutionResult{}, ErrUnimplemented
+	return applyToHLPtr(v, i, res(2))
 }
 
 // Execute RES_2_A instruction.
 func (i *RES_2_A) Execute(v vm) (ExecutionResult, error) {
-	return ExecutionResult{}, ErrUnimplemented
+	return applyToReg(v, i, &v.Reg().A, res(2))
 }
 
 // Execute RES_3_B instruction.
 func (i *RES_3_B) Execute(v vm) (ExecutionResult, error) {
-	return ExecutionResult{}, ErrUnimplemented
+	return applyToReg(v, i, &v.Reg().B, res(3))
 }
 
 // Execute RES_3_C instruction.
 func (i *RES_3_C) Execute(v vm) (ExecutionResult, error) {
-	return ExecutionResult{}, ErrUnimplemented
+	return applyToReg(v, i, &v.Reg().C, res(3))
 }
 
 // Execute RES_3_D instruction.
 func (i *RES_3_D) Execute(v vm) (ExecutionResult, error) {
-	return ExecutionResult{}, ErrUnimplemented
+	return applyToReg(v, i, &v.Reg().D, res(3))
 }
 
 // Execute RES_3_E instruction.
 func (i *RES_3_E) Execute(v vm) (ExecutionResult, error) {
-	return ExecutionResult{}, ErrUnimplemented
+	return applyToReg(v, i, &v.Reg().E, res(3))
 }
 
 // Execute RES_3_H instruction.
 func (i *RES_3_H) Execute(v vm) (ExecutionResult, error) {
-	return ExecutionResult{}, ErrUnimplemented
+	return applyToReg(v, i, &v.Reg().H, res(3))
 }
 
 // Execute RES_3_L instruction.
 func (i *RES_3_L) Execute(v vm) (ExecutionResult, error) {
-	return ExecutionResult{}, ErrUnimplemented
+	return applyToReg(v, i, &v.Reg().L, res(3))
 }
 
 // Execute RES_3_HLPtr instruction.
 func (i *RES_3_HLPtr) Execute(v vm) (ExecutionResult, error) {
-	return ExecutionResult{}, ErrUnimplemented
+	return applyToHLPtr(v, i, res(3))
 }
 
 // Execute RES_3_A instruction.
 func (i *RES_3_A) Execute(v vm) (ExecutionResult, error) {
-	return ExecutionResult{}, ErrUnimplemented
+	return applyToReg(v, i, &v.Reg().A, res(3))
 }
 
 // Execute RRC_D instruction.
 func (i *RRC_D) Execute(v vm) (ExecutionResult, error) {
-	return ExecutionResult{}, ErrUnimplemented
+	return applyToReg(v, i, &v.Reg().D, rrc)
 }
 
 // Execute RES_4_B instruction.
 func (i *RES_4_B) Execute(v vm) (ExecutionResult, error) {
-	return ExecutionResult{}, ErrUnimplemented
+	return applyToReg(v, i, &v.Reg().B, res(4))
 }
 
 // Execute RES_4_C instruction.
 func (i *RES_4_C) Execute(v vm) (ExecutionResult, error) {
-	return ExecutionResult{}, ErrUnimplemented
+	return applyToReg(v, i, &v.Reg().C, res(4))
 }
 
 // Execute RES_4_D instruction.
 func (i *RES_4_D) Execute(v vm) (ExecutionResult, error) {
-	return ExecutionResult{}, ErrUnimplemented
+	return applyToReg(v, i, &v.Reg().D, res(4))
 }
 
 // Execute RES_4_E instruction.
 func (i *RES_4_E) Execute(v vm) (ExecutionResult, error) {
-	return ExecutionResult{}, ErrUnimplemented
+	return applyToReg(v, i, &v.Reg().E, res(4))
 }
 
 // Execute RES_4_H instruction.
 func (i *RES_4_H) Execute(v vm) (ExecutionResult, error) {
-	return ExecutionResult{}, ErrUnimplemented
+	return applyToReg(v, i, &v.Reg().H, res(4))
 }
 
 // Execute RES_4_L instruction.
 func (i *RES_4_L) Execute(v vm) (ExecutionResult, error) {
-	return ExecutionResult{}, ErrUnimplemented
+	return applyToReg(v, i, &v.Reg().L, res(4))
 }
 
 // Execute RES_4_HLPtr instruction.
 func (i *RES_4_HLPtr) Execute(v vm) (ExecutionResult, error) {
-	return ExecutionResult{}, ErrUnimplemented
+	return applyToHLPtr(v, i, res(4))
 }
 
 // Execute RES_4_A instruction.
 func (i *RES_4_A) Execute(v vm) (ExecutionResult, error) {
-	return ExecutionResult{}, ErrUnimplemented
+	return applyToReg(v, i, &v.Reg().A, res(4))
 }
 
 // Execute RES_5_B instruction.
 func (i *RES_5_B) Execute(v vm) (ExecutionResult, error) {
-	return ExecutionResult{}, ErrUnimplemented
+	return applyToReg(v, i, &v.Reg().B, res(5))
 }
 
 // Execute RES_5_C instruction.
 func (i *RES_5_C) Execute(v vm) (ExecutionResult, error) {
-	return ExecutionResult{}, ErrUnimplemented
+	return applyToReg(v, i, &v.Reg().C, res(5))
 }
 
 // Execute RES_5_D instruction.
 func (i *RES_5_D) Execute(v vm) (ExecutionResult, error) {
-	return ExecutionResult{}, ErrUnimplemented
+	return applyToReg(v, i, &v.Reg().D, res(5))
 }
 
 // Execute RES_5_E instruction.
 func (i *RES_5_E) Execute(v vm) (ExecutionResult, error) {
-	return ExecutionResult{}, ErrUnimplemented
+	return applyToReg(v, i, &v.Reg().E, res(5))
 }
 
 // Execute RES_5_H instruction.
 func (i *RES_5_H) Execute(v vm) (ExecutionResult, error) {
-	return ExecutionResult{}, ErrUnimplemented
+	return applyToReg(v, i, &v.Reg().H, res(5))
 }
 
 // Execute RES_5_L instruction.
 func (i *RES_5_L) Execute(v vm) (ExecutionResult, error) {
-	return ExecutionResult{}, ErrUnimplemented
+	return applyToReg(v, i, &v.Reg().L, res(5))
 }
 
 // Execute RES_5_HLPtr instruction.
 func (i *RES_5_HLPtr) Execute(v vm) (ExecutionResult, error) {
-	return ExecutionResult{}, ErrUnimplemented
+	return applyToHLPtr(v, i, res(5))
 }
 
 // Execute RES_5_A instruction.
 func (i *RES_5_A) Execute(v vm) (ExecutionResult, error) {
-	return ExecutionResult{}, ErrUnimplemented
+	return applyToReg(v, i, &v.Reg().A, res(5))
 }
 
 // Execute RRC_E instruction.
 func (i *RRC_E) Execute(v vm) (ExecutionResult, error) {
-	return ExecutionResult{}, ErrUnimplemented
+	return applyToReg(v, i, &v.Reg().E, rrc)
 }
 
 // Execute RES_6_B instruction.
 func (i *RES_6_B) Execute(v vm) (ExecutionResult, error) {
-	return ExecutionResult{}, ErrUnimplemented
+	return applyToReg(v, i, &v.Reg().B, res(6))
 }
 
 // Execute RES_6_C instruction.
 func (i *RES_6_C) Execute(v vm) (ExecutionResult, error) {
-	return ExecutionResult{}, ErrUnimplemented
+	return applyToReg(v, i, &v.Reg().C, res(6))
 }
 
 // Execute RES_6_D instruction.
 func (i *RES_6_D) Execute(v vm) (ExecutionResult, error) {
-	return ExecutionResult{}, ErrUnimplemented
+	return applyToReg(v, i, &v.Reg().D, res(6))
 }
 
 // Execute RES_6_E instruction.
 func (i *RES_6_E) Execute(v vm) (ExecutionResult, error) {
-	return ExecutionResult{}, ErrUnimplemented
+	return applyToReg(v, i, &v.Reg().E, res(6))
 }
 
 // Execute RES_6_H instruction.
 func (i *RES_6_H) Execute(v vm) (ExecutionResult, error) {
-	return ExecutionResult{}, ErrUnimplemented
+	return applyToReg(v, i, &v.Reg().H, res(6))
 }
 
 // Execute RES_6_L instruction.
 func (i *RES_6_L) Execute(v vm) (ExecutionResult, error) {
-	return ExecutionResult{}, ErrUnimplemented
+	return applyToReg(v, i, &v.Reg().L, res(6))
 }
 
 // Execute RES_6_HLPtr instruction.
 func (i *RES_6_HLPtr) Execute(v vm) (ExecutionResult, error) {
-	return ExecutionResult{}, ErrUnimplemented
+	return applyToHLPtr(v, i, res(6))
 }
 
 // Execute RES_6_A instruction.
 func (i *RES_6_A) Execute(v vm) (ExecutionResult, error) {
-	return ExecutionResult{}, ErrUnimplemented
+	return applyToReg(v, i, &v.Reg().A, res(6))
 }
 
 // Execute RES_7_B instruction.
 func (i *RES_7_B) Execute(v vm) (ExecutionResult, error) {
-	return ExecutionResult{}, ErrUnimplemented
+	return applyToReg(v, i, &v.Reg().B, res(7))
 }
 
 // Execute RES_7_C instruction.
 func (i *RES_7_C) Execute(v vm) (ExecutionResult, error) {
-	return ExecutionResult{}, ErrUnimplemented
+	return applyToReg(v, i, &v.Reg().C, res(7))
 }
 
 // Execute RES_7_D instruction.
 func (i *RES_7_D) Execute(v vm) (ExecutionResult, error) {
-	return ExecutionResult{}, ErrUnimplemented
+	return applyToReg(v, i, &v.Reg().D, res(7))
 }
 
 // Execute RES_7_E instruction.
 func (i *RES_7_E) Execute(v vm) (ExecutionResult, error) {
-	return ExecutionResult{}, ErrUnimplemented
+	return applyToReg(v, i, &v.Reg().E, res(7))
 }
 
 // Execute RES_7_H instruction.
 func (i *RES_7_H) Execute(v vm) (ExecutionResult, error) {
-	return ExecutionResult{}, ErrUnimplemented
+	return applyToReg(v, i, &v.Reg().H, res(7))
 }
 
 // Execute RES_7_L instruction.
 func (i *RES_7_L) Execute(v vm) (ExecutionResult, error) {
-	return ExecutionResult{}, ErrUnimplemented
+	return applyToReg(v, i, &v.Reg().L, res(7))
 }
 
 // Execute RES_7_HLPtr instruction.
 func (i *RES_7_HLPtr) Execute(v vm) (ExecutionResult, error) {
-	return ExecutionResult{}, ErrUnimplemented
+	return applyToHLPtr(v, i, res(7))
 }
 
 // Execute RES_7_A instruction.
 func (i *RES_7_A) Execute(v vm) (ExecutionResult, error) {
-	return ExecutionResult{}, ErrUnimplemented
+	return applyToReg(v, i, &v.Reg().A, res(7))
 }
 
 // Execute RRC_H instruction.
 func (i *RRC_H) Execute(v vm) (ExecutionResult, error) {
-	return ExecutionResult{}, ErrUnimplemented
+	return applyToReg(v, i, &v.Reg().H, rrc)
 }
 
 // Execute SET_0_B instruction.
 func (i *SET_0_B) Execute(v vm) (ExecutionResult, error) {
-	return ExecutionResult{}, ErrUnimplemented
+	return applyToReg(v, i, &v.Reg().B, set(0))
 }
 
 // Execute SET_0_C instruction.
 func (i *SET_0_C) Execute(v vm) (ExecutionResult, error) {
-	return ExecutionResult{}, ErrUnimplemented
+	return applyToReg(v, i, &v.Reg().C, set(0))
 }
 
 // Execute SET_0_D instruction.
 func (i *SET_0_D) Execute(v vm) (ExecutionResult, error) {
-	return ExecutionResult{}, ErrUnimplemented
+	return applyToReg(v, i, &v.Reg().D, set(0))
 }
 
 // Execute SET_0_E instruction.
 func (i *SET_0_E) Execute(v vm) (ExecutionResult, error) {
-	return ExecutionResult{}, ErrUnimplemented
+	return applyToReg(v, i, &v.Reg().E, set(0))
 }
 
 // Execute SET_0_H instruction.
 func (i *SET_0_H) Execute(v vm) (ExecutionResult, error) {
-	return ExecutionResult{}, ErrUnimplemented
+	return applyToReg(v, i, &v.Reg().H, set(0))
 }
 
 // Execute SET_0_L instruction.
 func (i *SET_0_L) Execute(v vm) (ExecutionResult, error) {
-	return ExecutionResult{}, ErrUnimplemented
+	return applyToReg(v, i, &v.Reg().L, set(0))
 }
 
 // Execute SET_0_HLPtr instruction.
 func (i *SET_0_HLPtr) Execute(v vm) (ExecutionResult, error) {
-	return ExecutionResult{}, ErrUnimplemented
+	return applyToHLPtr(v, i, set(0))
 }
 
 // Execute SET_0_A instruction.
 func (i *SET_0_A) Execute(v vm) (ExecutionResult, error) {
-	return ExecutionResult{}, ErrUnimplemented
+	return applyToReg(v, i, &v.Reg().A, set(0))
 }
 
 // Execute SET_1_B instruction.
 func (i *SET_1_B) Execute(v vm) (ExecutionResult, error) {
-	return ExecutionResult{}, ErrUnimplemented
+	return applyToReg(v, i, &v.Reg().B, set(1))
 }
 
 // Execute SET_1_C instruction.
 func (i *SET_1_C) Execute(v vm) (ExecutionResult, error) {
-	return ExecutionResult{}, ErrUnimplemented
+	return applyToReg(v, i, &v.Reg().C, set(1))
 }
 
 // Execute SET_1_D instruction.
 func (i *SET_1_D) Execute(v vm) (ExecutionResult, error) {
-	return ExecutionResult{}, ErrUnimplemented
+	return applyToReg(v, i, &v.Reg().D, set(1))
 }
 
 // Execute SET_1_E instruction.
 func (i *SET_1_E) Execute(v vm) (ExecutionResult, error) {
-	return ExecutionResult{}, ErrUnimplemented
+	return applyToReg(v, i, &v.Reg().E, set(1))
 }
 
 // Execute SET_1_H instruction.
 func (i *SET_1_H) Execute(v vm) (ExecutionResult, error) {
-	return ExecutionResult{}, ErrUnimplemented
+	return applyToReg(v, i, &v.Reg().H, set(1))
 }
 
 // Execute SET_1_L instruction.
 func (i *SET_1_L) Execute(v vm) (ExecutionResult, error) {
-	return ExecutionResult{}, ErrUnimplemented
+	return applyToReg(v, i, &v.Reg().L, set(1))
 }
 
 // Execute SET_1_HLPtr instruction.
 func (i *SET_1_HLPtr) Execute(v vm) (ExecutionResult, error) {
-	return ExecutionResult{}, ErrUnimplemented
+	return applyToHLPtr(v, i, set(1))
 }
 
 // Execute SET_1_A instruction.
 func (i *SET_1_A) Execute(v vm) (ExecutionResult, error) {
-	return ExecutionResult{}, ErrUnimplemented
+	return applyToReg(v, i, &v.Reg().A, set(1))
 }
 
 // Execute RRC_L instruction.
 func (i *RRC_L) Execute(v vm) (ExecutionResult, error) {
-	return ExecutionResult{}, ErrUnimplemented
+	return applyToReg(v, i, &v.Reg().L, rrc)
 }
 
 // Execute SET_2_B instruction.
 func (i *SET_2_B) Execute(v vm) (ExecutionResult, error) {
-	return ExecutionResult{}, ErrUnimplemented
+	return applyToReg(v, i, &v.Reg().B, set(2))
 }
 
 // Execute SET_2_C instruction.
 func (i *SET_2_C) Execute(v vm) (ExecutionResult, error) {
-	return ExecutionResult{}, ErrUnimplemented
+	return applyToReg(v, i, &v.Reg().C, set(2))
 }
 
 // Execute SET_2_D instruction.
 func (i *SET_2_D) Execute(v vm) (ExecutionResult, error) {
-	return ExecutionResult{}, ErrUnimplemented
+	return applyToReg(v, i, &v.Reg().D, set(2))
 }
 
 // Execute SET_2_E instruction.
 func (i *SET_2_E) Execute(v vm) (ExecutionResult, error) {
-	return ExecutionResult{}, ErrUnimplemented
+	return applyToReg(v, i, &v.Reg().E, set(2))
 }
 
 // Execute SET_2_H instruction.
 func (i *SET_2_H) Execute(v vm) (ExecutionResult, error) {
-	return ExecutionResult{}, ErrUnimplemented
+	return applyToReg(v, i, &v.Reg().H, set(2))
 }
 
 // Execute SET_2_L instruction.
 func (i *SET_2_L) Execute(v vm) (ExecutionResult, error) {
-	return ExecutionResult{}, ErrUnimplemented
+	return applyToReg(v, i, &v.Reg().L, set(2))
 }
 
 // Execute SET_2_HLPtr instruction.
 func (i *SET_2_HLPtr) Execute(v vm) (ExecutionResult, error) {
-	return ExecutionResult{}, ErrUnimplemented
+	return applyToHLPtr(v, i, set(2))
 }
 
 // Execute SET_2_A instruction.
 func (i *SET_2_A) Execute(v vm) (ExecutionResult, error) {
-	return ExecutionResult{}, ErrUnimplemented
+	return applyToReg(v, i, &v.Reg().A, set(2))
 }
 
 // Execute SET_3_B instruction.
 func (i *SET_3_B) Execute(v vm) (ExecutionResult, error) {
-	return ExecutionResult{}, ErrUnimplemented
+	return applyToReg(v, i, &v.Reg().B, set(3))
 }
 
 // Execute SET_3_C instruction.
 func (i *SET_3_C) Execute(v vm) (ExecutionResult, error) {
-	return ExecutionResult{}, ErrUnimplemented
+	return applyToReg(v, i, &v.Reg().C, set(3))
 }
 
 // Execute SET_3_D instruction.
 func (i *SET_3_D) Execute(v vm) (ExecutionResult, error) {
-	return ExecutionResult{}, ErrUnimplemented
+	return applyToReg(v, i, &v.Reg().D, set(3))
 }
 
 // Execute SET_3_E instruction.
 func (i *SET_3_E) Execute(v vm) (ExecutionResult, error) {
-	return ExecutionResult{}, ErrUnimplemented
+	return applyToReg(v, i, &v.Reg().E, set(3))
 }
 
 // Execute SET_3_H instruction.
 func (i *SET_3_H) Execute(v vm) (ExecutionResult, error) {
-	return ExecutionResult{}, ErrUnimplemented
+	return applyToReg(v, i, &v.Reg().H, set(3))
 }
 
 // Execute SET_3_L instruction.
 func (i *SET_3_L) Execute(v vm) (ExecutionResult, error) {
-	return ExecutionResult{}, ErrUnimplemented
+	return applyToReg(v, i, &v.Reg().L, set(3))
 }
 
 // Execute SET_3_HLPtr instruction.
 func (i *SET_3_HLPtr) Execute(v vm) (ExecutionResult, error) {
-	return ExecutionResult{}, ErrUnimplemented
+	return applyToHLPtr(v, i, set(3))
 }
 
 // Execute SET_3_A instruction.
 func (i *SET_3_A) Execute(v vm) (ExecutionResult, error) {
-	return ExecutionResult{}, ErrUnimplemented
+	return applyToReg(v, i, &v.Reg().A, set(3))
 }
 
 // Execute RRC_HLPtr instruction.
 func (i *RRC_HLPtr) Execute(v vm) (ExecutionResult, error) {
-	return ExecutionResult{}, ErrUnimplemented
+	return applyToHLPtr(v, i, rrc)
 }
 
 // Execute SET_4_B instruction.
 func (i *SET_4_B) Execute(v vm) (ExecutionResult, error) {
-	return ExecutionResult{}, ErrUnimplemented
+	return applyToReg(v, i, &v.Reg().B, set(4))
 }
 
 // Execute SET_4_C instruction.
 func (i *SET_4_C) Execute(v vm) (ExecutionResult, error) {
-	return ExecutionResult{}, ErrUnimplemented
+	return applyToReg(v, i, &v.Reg().C, set(4))
 }
 
 // Execute SET_4_D instruction.
 func (i *SET_4_D) Execute(v vm) (ExecutionResult, error) {
-	return ExecutionResult{}, ErrUnimplemented
+	return applyToReg(v, i, &v.Reg().D, set(4))
 }
 
 // Execute SET_4_E instruction.
 func (i *SET_4_E) Execute(v vm) (ExecutionResult, error) {
-	return ExecutionResult{}, ErrUnimplemented
+	return applyToReg(v, i, &v.Reg().E, set(4))
 }
 
 // Execute SET_4_H instruction.
 func (i *SET_4_H) Execute(v vm) (ExecutionResult, error) {
-	return ExecutionResult{}, ErrUnimplemented
+	return applyToReg(v, i, &v.Reg().H, set(4))
 }
 
 // Execute SET_4_L instruction.
 func (i *SET_4_L) Execute(v vm) (ExecutionResult, error) {
-	return ExecutionResult{}, ErrUnimplemented
+	return applyToReg(v, i, &v.Reg().L, set(4))
 }
 
 // Execute SET_4_HLPtr instruction.
 func (i *SET_4_HLPtr) Execute(v vm) (ExecutionResult, error) {
-	return ExecutionResult{}, ErrUnimplemented
+	return applyToHLPtr(v, i, set(4))
 }
 
 // Execute SET_4_A instruction.
 func (i *SET_4_A) Execute(v vm) (ExecutionResult, error) {
-	return ExecutionResult{}, ErrUnimplemented
+	return applyToReg(v, i, &v.Reg().A, set(4))
 }
 
 // Execute SET_5_B instruction.
 func (i *SET_5_B) Execute(v vm) (ExecutionResult, error) {
-	return ExecutionResult{}, ErrUnimplemented
+	return applyToReg(v, i, &v.Reg().B, set(5))
 }
 
 // Execute SET_5_C instruction.
 func (i *SET_5_C) Execute(v vm) (ExecutionResult, error) {
-	return ExecutionResult{}, ErrUnimplemented
+	return applyToReg(v, i, &v.Reg().C, set(5))
 }
 
 // Execute SET_5_D instruction.
 func (i *SET_5_D) Execute(v vm) (ExecutionResult, error) {
-	return ExecutionResult{}, ErrUnimplemented
+	return applyToReg(v, i, &v.Reg().D, set(5))
 }
 
 // Execute SET_5_E instruction.
 func (i *SET_5_E) Execute(v vm) (ExecutionResult, error) {
-	return ExecutionResult{}, ErrUnimplemented
+	return applyToReg(v, i, &v.Reg().E, set(5))
 }
 
 // Execute SET_5_H instruction.
 func (i *SET_5_H) Execute(v vm) (ExecutionResult, error) {
-	return ExecutionResult{}, ErrUnimplemented
+	return applyToReg(v, i, &v.Reg().H, set(5))
 }
 
 // Execute SET_5_L instruction.
 func (i *SET_5_L) Execute(v vm) (ExecutionResult, error) {
-	return ExecutionResult{}, ErrUnimplemented
+	return applyToReg(v, i, &v.Reg().L, set(5))
 }
 
 // Execute SET_5_HLPtr instruction.
 func (i *SET_5_HLPtr) Execute(v vm) (ExecutionResult, error) {
-	return ExecutionResult{}, ErrUnimplemented
+	return applyToHLPtr(v, i, set(5))
 }
 
 // Execute SET_5_A instruction.
 func (i *SET_5_A) Execute(v vm) (ExecutionResult, error) {
-	return ExecutionResult{}, ErrUnimplemented
+	return applyToReg(v, i, &v.Reg().A, set(5))
 }
 
 // Execute RRC_A instruction.
 func (i *RRC_A) Execute(v vm) (ExecutionResult, error) {
-	return ExecutionResult{}, ErrUnimplemented
+	return applyToReg(v, i, &v.Reg().A, rrc)
 }
 
 // Execute SET_6_B instruction.
 func (i *SET_6_B) Execute(v vm) (ExecutionResult, error) {
-	return ExecutionResult{}, ErrUnimplemented
+	return applyToReg(v, i, &v.Reg().B, set(6))
 }
 
 // Execute SET_6_C instruction.
 func (i *SET_6_C) Execute(v vm) (ExecutionResult, error) {
-	return ExecutionResult{}, ErrUnimplemented
+	return applyToReg(v, i, &v.Reg().C, set(6))
 }
 
 // Execute SET_6_D instruction.
 func (i *SET_6_D) Execute(v vm) (ExecutionResult, error) {
-	return ExecutionResult{}, ErrUnimplemented
+	return applyToReg(v, i, &v.Reg().D, set(6))
 }
 
 // Execute SET_6_E instruction.
 func (i *SET_6_E) Execute(v vm) (ExecutionResult, error) {
-	return ExecutionResult{}, ErrUnimplemented
+	return applyToReg(v, i, &v.Reg().E, set(6))
 }
 
 // Execute SET_6_H instruction.
 func (i *SET_6_H) Execute(v vm) (ExecutionResult, error) {
-	return ExecutionResult{}, ErrUnimplemented
+	return applyToReg(v, i, &v.Reg().H, set(6))
 }
 
 // Execute SET_6_L instruction.
 func (i *SET_6_L) Execute(v vm) (ExecutionResult, error) {
-	return ExecutionResult{}, ErrUnimplemented
+	return applyToReg(v, i, &v.Reg().L, set(6))
 }
 
 // Execute SET_6_HLPtr instruction.
 func (i *SET_6_HLPtr) Execute(v vm) (ExecutionResult, error) {
-	return ExecutionResult{}, ErrUnimplemented
+	return applyToHLPtr(v, i, set(6))
 }
 
 // Execute SET_6_A instruction.
 func (i *SET_6_A) Execute(v vm) (ExecutionResult, error) {
-	return ExecutionResult{}, ErrUnimplemented
+	return applyToReg(v, i, &v.Reg().A, set(6))
 }
 
 // Execute SET_7_B instruction.
 func (i *SET_7_B) Execute(v vm) (ExecutionResult, error) {
-	return ExecutionResult{}, ErrUnimplemented
+	return applyToReg(v, i, &v.Reg().B, set(7))
 }
 
 // Execute SET_7_C instruction.
 func (i *SET_7_C) Execute(v vm) (ExecutionResult, error) {
-	return ExecutionResult{}, ErrUnimplemented
+	return applyToReg(v, i, &v.Reg().C, set(7))
 }
 
 // Execute SET_7_D instruction.
 func (i *SET_7_D) Execute(v vm) (ExecutionResult, error) {
-	return ExecutionResult{}, ErrUnimplemented
+	return applyToReg(v, i, &v.Reg().D, set(7))
 }
 
 // Execute SET_7_E instruction.
 func (i *SET_7_E) Execute(v vm) (ExecutionResult, error) {
-	return ExecutionResult{}, ErrUnimplemented
+	return applyToReg(v, i, &v.Reg().E, set(7))
 }
 
 // Execute SET_7_H instruction.
 func (i *SET_7_H) Execute(v vm) (ExecutionResult, error) {
-	return ExecutionResult{}, ErrUnimplemented
+	return applyToReg(v, i, &v.Reg().H, set(7))
 }
 
 // Execute SET_7_L instruction.
 func (i *SET_7_L) Execute(v vm) (ExecutionResult, error) {
-	return ExecutionResult{}, ErrUnimplemented
+	return applyToReg(v, i, &v.Reg().L, set(7))
 }
 
 // Execute SET_7_HLPtr instruction.
 func (i *SET_7_HLPtr) Execute(v vm) (ExecutionResult, error) {
-	return ExecutionResult{}, ErrUnimplemented
+	return applyToHLPtr(v, i, set(7))
 }
 
 // Execute SET_7_A instruction.
 func (i *SET_7_A) Execute(v vm) (ExecutionResult, error) {
-	return ExecutionResult{}, ErrUnimplemented
+	return applyToReg(v, i, &v.Reg().A, set(7))
 }
@@ -0,0 +1,76 @@
+package ppu
+
+import "image/color"
+
+// palettes is one of the CGB's two palette RAMs: eight palettes of four
+// colours, each colour two bytes of little-endian RGB555.
+type palettes struct {
+	ram [64]uint8
+
+	// index is the byte of ram read and written through the data register.
+	// If autoIncrement is set it moves on after every write.
+	index         uint8
+	autoIncrement bool
+}
+
+func (ps *palettes) readIndex() uint8 {
+	val := 0x40 | ps.index // Bit 6 isn't connected and reads as 1.
+	if ps.autoIncrement {
+		val |= 0x80
+	}
+	return val
+}
+
+func (ps *palettes) writeIndex(val uint8) {
+	ps.index = val & 0x3F
+	ps.autoIncrement = val&0x80 != 0
+}
+
+func (ps *palettes) read(locked bool) uint8 {
+	if locked {
+		return 0xFF
+	}
+	return ps.ram[ps.index]
+}
+
+// write sets the byte at the index. The index still increments if the write
+// is dropped because palette RAM is locked.
+func (ps *palettes) write(val uint8, locked bool) {
+	if !locked {
+		ps.ram[ps.index] = val
+	}
+	if ps.autoIncrement {
+		ps.index = (ps.index + 1) & 0x3F
+	}
+}
+
+// color returns colour i of palette pal.
+func (ps *palettes) color(pal, i uint8, correct bool) color.RGBA {
+	off := int(pal&0x07)*8 + int(i)*2
+	return ColorFromRGB555(uint16(ps.ram[off])|uint16(ps.ram[off+1])<<8, correct)
+}
+
+// ColorFromRGB555 converts a CGB colour, with red in the low 5 bits, then
+// green, then blue, to RGB888. If correct is set the colour is run through a
+// curve that mixes the channels and dims them the way the CGB's LCD did.
+func ColorFromRGB555(c uint16, correct bool) color.RGBA {
+	r := uint32(c & 0x1F)
+	g := uint32(c>>5) & 0x1F
+	b := uint32(c>>10) & 0x1F
+
+	if correct {
+		return color.RGBA{
+			R: uint8((r*13 + g*2 + b) >> 1),
+			G: uint8((g*3 + b) << 1),
+			B: uint8((r*3 + g*2 + b*11) >> 1),
+			A: 0xFF,
+		}
+	}
+	// Copy the top bits into the bottom so 0x1F becomes 0xFF.
+	return color.RGBA{
+		R: uint8(r<<3 | r>>2),
+		G: uint8(g<<3 | g>>2),
+		B: uint8(b<<3 | b>>2),
+		A: 0xFF,
+	}
+}
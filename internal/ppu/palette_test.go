@@ -0,0 +1,252 @@
+package ppu_test
+
+import (
+	"image/color"
+	"testing"
+
+	"github.com/vsinha/vm/internal/interrupts"
+	"github.com/vsinha/vm/internal/model"
+	"github.com/vsinha/vm/internal/ppu"
+)
+
+// RGB555 colours.
+const (
+	cgbWhite = 0x7FFF
+	cgbRed   = 0x001F
+	cgbGreen = 0x03E0
+	cgbBlue  = 0x7C00
+)
+
+var (
+	red   = color.RGBA{0xFF, 0x00, 0x00, 0xFF}
+	green = color.RGBA{0x00, 0xFF, 0x00, 0xFF}
+	blue  = color.RGBA{0x00, 0x00, 0xFF, 0xFF}
+)
+
+func newCGBPPU() *ppu.PPU {
+	return ppu.New(interrupts.New(), ppu.WithCGB())
+}
+
+// setPalette writes the four colours of palette pal through the index and
+// data registers at indexReg and indexReg+1.
+func setPalette(p *ppu.PPU, indexReg uint16, pal uint8, colors [4]uint16) {
+	p.Write8(indexReg, 0x80|pal*8)
+	for _, c := range colors {
+		p.Write8(indexReg+1, uint8(c))
+		p.Write8(indexReg+1, uint8(c>>8))
+	}
+}
+
+func TestColorFromRGB555(t *testing.T) {
+	tests := []struct {
+		in      uint16
+		correct bool
+		want    color.RGBA
+	}{
+		{0x0000, false, black},
+		{cgbWhite, false, white},
+		{cgbRed, false, red},
+		{cgbGreen, false, green},
+		{cgbBlue, false, blue},
+		{0x0010, false, color.RGBA{0x84, 0x00, 0x00, 0xFF}},
+		{0x0000, true, black},
+		{cgbWhite, true, color.RGBA{0xF8, 0xF8, 0xF8, 0xFF}},
+		{cgbRed, true, color.RGBA{0xC9, 0x00, 0x2E, 0xFF}},
+	}
+
+	for _, test := range tests {
+		if got := ppu.ColorFromRGB555(test.in, test.correct); got != test.want {
+			t.Errorf("ColorFromRGB555(%04X, %v) = %v, want %v", test.in, test.correct, got, test.want)
+		}
+	}
+}
+
+func TestVRAMBanks(t *testing.T) {
+	p := newCGBPPU()
+	p.Write8(0x8000, 0x11)
+	p.Write8(ppu.VBK, 0x01)
+	if got := p.Read8(ppu.VBK); got != 0xFF {
+		t.Errorf("VBK = %02X, want FF", got)
+	}
+	p.Write8(0x8000, 0x22)
+	if got := p.Read8(0x8000); got != 0x22 {
+		t.Errorf("bank 1 Read8(8000) = %02X, want 22", got)
+	}
+	p.Write8(ppu.VBK, 0xFE)
+	if got := p.Read8(0x8000); got != 0x11 {
+		t.Errorf("bank 0 Read8(8000) = %02X, want 11", got)
+	}
+
+	dmg, _ := newPPU()
+	dmg.Write8(ppu.VBK, 0x01)
+	dmg.Write8(0x8000, 0x22)
+	if got := dmg.Read8(ppu.VBK); got != 0xFF {
+		t.Errorf("DMG VBK = %02X, want FF", got)
+	}
+	dmg.Write8(ppu.VBK, 0x00)
+	if got := dmg.Read8(0x8000); got != 0x22 {
+		t.Errorf("DMG Read8(8000) = %02X, want 22 (no banking)", got)
+	}
+}
+
+func TestPaletteRAM(t *testing.T) {
+	p := newCGBPPU()
+
+	p.Write8(ppu.BCPS, 0x82)
+	p.Write8(ppu.BCPD, 0x12)
+	p.Write8(ppu.BCPD, 0x34)
+	if got := p.Read8(ppu.BCPS); got != 0xC4 {
+		t.Errorf("BCPS after two writes = %02X, want C4", got)
+	}
+	p.Write8(ppu.BCPS, 0x03)
+	if got := p.Read8(ppu.BCPD); got != 0x34 {
+		t.Errorf("BCPD at 03 = %02X, want 34", got)
+	}
+	// Reads don't increment the index, and neither do writes without bit 7.
+	p.Read8(ppu.BCPD)
+	p.Write8(ppu.BCPD, 0x56)
+	if got := p.Read8(ppu.BCPS); got != 0x43 {
+		t.Errorf("BCPS = %02X, want 43", got)
+	}
+
+	// The index wraps.
+	p.Write8(ppu.OCPS, 0xBF)
+	p.Write8(ppu.OCPD, 0x78)
+	if got := p.Read8(ppu.OCPS); got != 0xC0 {
+		t.Errorf("OCPS after wrapping = %02X, want C0", got)
+	}
+	p.Write8(ppu.OCPS, 0x3F)
+	if got := p.Read8(ppu.OCPD); got != 0x78 {
+		t.Errorf("OCPD at 3F = %02X, want 78", got)
+	}
+
+	// While drawing, writes are dropped but still increment the index.
+	p.Write8(ppu.BCPS, 0x80)
+	p.Write8(ppu.LCDC, 0x80)
+	p.Tick(80)
+	p.Write8(ppu.BCPD, 0x99)
+	if got := p.Read8(ppu.BCPD); got != 0xFF {
+		t.Errorf("BCPD while drawing = %02X, want FF", got)
+	}
+	p.Tick(172)
+	p.Write8(ppu.BCPS, 0x00)
+	if got := p.Read8(ppu.BCPD); got == 0x99 {
+		t.Errorf("BCPD written while drawing")
+	}
+	if got := p.Read8(ppu.BCPS); got != 0x40 {
+		t.Errorf("BCPS = %02X, want 40", got)
+	}
+}
+
+func TestCGBRender(t *testing.T) {
+	tests := []struct {
+		name  string
+		lcdc  uint8
+		setup func(p *ppu.PPU)
+		want  []pixel
+	}{
+		{"background palette", 0x91, func(p *ppu.PPU) {
+			fillTile(p, 0x8010, 0xFF, 0x00)
+			p.Write8(0x9800, 1)
+			p.Write8(ppu.VBK, 1)
+			p.Write8(0x9800, 0x02)
+			setPalette(p, ppu.BCPS, 2, [4]uint16{cgbWhite, cgbRed, cgbGreen, cgbBlue})
+		}, []pixel{{0, 0, red}, {8, 0, white}}},
+		{"background tile bank", 0x91, func(p *ppu.PPU) {
+			p.Write8(0x9800, 1)
+			p.Write8(ppu.VBK, 1)
+			fillTile(p, 0x8010, 0xFF, 0xFF)
+			p.Write8(0x9800, 0x08)
+		}, []pixel{{0, 0, blue}, {8, 0, white}}},
+		{"background flip", 0x91, func(p *ppu.PPU) {
+			p.Write8(0x8010, 0x80)
+			p.Write8(0x8011, 0x80)
+			p.Write8(0x9800, 1)
+			p.Write8(ppu.VBK, 1)
+			p.Write8(0x9800, 0x60)
+		}, []pixel{{0, 0, white}, {7, 7, blue}}},
+		{"sprite palette and bank", 0x93, func(p *ppu.PPU) {
+			p.Write8(ppu.VBK, 1)
+			fillTile(p, 0x8010, 0x00, 0xFF)
+			setSprite(p, 0, 16, 8, 1, 0x08|0x03)
+			setPalette(p, ppu.OCPS, 3, [4]uint16{0, 0, cgbGreen, 0})
+		}, []pixel{{0, 0, green}}},
+		{"DMG palette bit ignored", 0x93, func(p *ppu.PPU) {
+			fillTile(p, 0x8010, 0x00, 0xFF)
+			setSprite(p, 0, 16, 8, 1, 0x10)
+			setPalette(p, ppu.OCPS, 0, [4]uint16{0, 0, cgbGreen, 0})
+			setPalette(p, ppu.OCPS, 1, [4]uint16{0, 0, cgbRed, 0})
+		}, []pixel{{0, 0, green}}},
+		{"OAM order beats X", 0x93, func(p *ppu.PPU) {
+			fillTile(p, 0x8010, 0xFF, 0xFF)
+			fillTile(p, 0x8020, 0xFF, 0x00)
+			setSprite(p, 0, 16, 8+4, 1, 0x00)
+			setSprite(p, 1, 16, 8, 2, 0x00)
+			setPalette(p, ppu.OCPS, 0, [4]uint16{0, cgbRed, 0, cgbGreen})
+		}, []pixel{{3, 0, red}, {4, 0, green}}},
+		{"background priority attribute", 0x93, func(p *ppu.PPU) {
+			fillTile(p, 0x8010, 0xFF, 0x00)
+			p.Write8(0x9800, 1)
+			p.Write8(ppu.VBK, 1)
+			p.Write8(0x9800, 0x80)
+			setSprite(p, 0, 16, 8, 1, 0x00)
+			setSprite(p, 1, 16, 8+8, 1, 0x00)
+			setPalette(p, ppu.BCPS, 0, [4]uint16{cgbWhite, cgbRed, 0, 0})
+			setPalette(p, ppu.OCPS, 0, [4]uint16{0, cgbGreen, 0, 0})
+		}, []pixel{{0, 0, red}, {8, 0, green}}},
+		{"LCDC bit 0 puts sprites on top", 0x92, func(p *ppu.PPU) {
+			fillTile(p, 0x8010, 0xFF, 0x00)
+			p.Write8(0x9800, 1)
+			p.Write8(ppu.VBK, 1)
+			p.Write8(0x9800, 0x80)
+			setSprite(p, 0, 16, 8, 1, 0x80)
+			setPalette(p, ppu.BCPS, 0, [4]uint16{cgbWhite, cgbRed, 0, 0})
+			setPalette(p, ppu.OCPS, 0, [4]uint16{0, cgbGreen, 0, 0})
+		}, []pixel{{0, 0, green}, {8, 0, white}}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			p := newCGBPPU()
+			// Background palette 0 is white, blue, blue, blue unless the test
+			// changes it.
+			setPalette(p, ppu.BCPS, 0, [4]uint16{cgbWhite, cgbBlue, cgbBlue, cgbBlue})
+			test.setup(p)
+			p.Write8(ppu.VBK, 0)
+			p.Write8(ppu.LCDC, test.lcdc)
+			p.Tick(frameCycles)
+
+			frame := p.Frame()
+			for _, want := range test.want {
+				if got := color.RGBAModel.Convert(frame.At(want.x, want.y)); got != want.c {
+					t.Errorf("pixel %d,%d = %v, want %v", want.x, want.y, got, want.c)
+				}
+			}
+		})
+	}
+}
+
+func TestCompatibilityMode(t *testing.T) {
+	p := ppu.New(interrupts.New(), ppu.WithModel(model.CGB))
+	if got := p.Read8(ppu.VBK); got != 0xFF {
+		t.Errorf("VBK = %02X in compatibility mode, want FF", got)
+	}
+
+	// DMG shades are coloured by the first palettes, after BGP and OBP1.
+	setPalette(p, ppu.BCPS, 0, [4]uint16{cgbWhite, cgbRed, cgbGreen, cgbBlue})
+	setPalette(p, ppu.OCPS, 1, [4]uint16{0, cgbGreen, 0, 0})
+	fillTile(p, 0x8010, 0xFF, 0x00)
+	p.Write8(0x9800, 1)
+	setSprite(p, 0, 16, 8+8, 1, 0x10)
+	p.Write8(ppu.BGP, 0xE8) // Shade 2 for colour 1.
+	p.Write8(ppu.OBP1, 0x04)
+	p.Write8(ppu.LCDC, 0x93)
+	p.Tick(frameCycles)
+
+	frame := p.Frame()
+	for _, want := range []pixel{{0, 0, green}, {8, 0, green}, {16, 0, white}} {
+		if got := color.RGBAModel.Convert(frame.At(want.x, want.y)); got != want.c {
+			t.Errorf("pixel %d,%d = %v, want %v", want.x, want.y, got, want.c)
+		}
+	}
+}
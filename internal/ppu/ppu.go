@@ -0,0 +1,430 @@
+// Package ppu implements the Game Boy's picture processing unit: its video
+// RAM, sprite attribute table and LCD registers, the timing of the modes it
+// steps through on every scanline, and a scanline renderer.
+package ppu
+
+import (
+	"image"
+	"image/color"
+
+	"github.com/vsinha/vm/internal/interrupts"
+	"github.com/vsinha/vm/internal/memory"
+	"github.com/vsinha/vm/internal/model"
+)
+
+// The size of the LCD in pixels.
+const (
+	Width  = 160
+	Height = 144
+)
+
+// Register addresses.
+const (
+	LCDC = 0xFF40 // LCD control.
+	STAT = 0xFF41 // LCD status.
+	SCY  = 0xFF42 // Background scroll Y.
+	SCX  = 0xFF43 // Background scroll X.
+	LY   = 0xFF44 // Line currently being drawn.
+	LYC  = 0xFF45 // LY compare.
+	BGP  = 0xFF47 // Background palette.
+	OBP0 = 0xFF48 // Sprite palette 0.
+	OBP1 = 0xFF49 // Sprite palette 1.
+	WY   = 0xFF4A // Window Y.
+	WX   = 0xFF4B // Window X plus 7.
+
+	// CGB only.
+	VBK  = 0xFF4F // VRAM bank.
+	BCPS = 0xFF68 // Background palette index.
+	BCPD = 0xFF69 // Background palette data.
+	OCPS = 0xFF6A // Sprite palette index.
+	OCPD = 0xFF6B // Sprite palette data.
+)
+
+// LCDC bits.
+const (
+	lcdcBGEnable     = 0x01 // Turns the background and window off on a DMG, and their priority off on a CGB.
+	lcdcOBJEnable    = 0x02
+	lcdcOBJSize      = 0x04 // 8x16 sprites.
+	lcdcBGMap        = 0x08 // Background tile map at 0x9C00 instead of 0x9800.
+	lcdcTileData     = 0x10 // Tiles at 0x8000 with unsigned indexes, instead of 0x9000 signed.
+	lcdcWindowEnable = 0x20
+	lcdcWindowMap    = 0x40 // Window tile map at 0x9C00 instead of 0x9800.
+	lcdcEnable       = 0x80
+)
+
+// STAT bits.
+const (
+	statModeMask  = 0x03
+	statCoincide  = 0x04 // LY == LYC.
+	statHBlankIRQ = 0x08
+	statVBlankIRQ = 0x10
+	statOAMIRQ    = 0x20
+	statLYCIRQ    = 0x40
+)
+
+// Mode is what the PPU is doing, as reported in the bottom two bits of STAT.
+type Mode uint8
+
+// PPU modes.
+const (
+	HBlank  Mode = 0 // Done with the line.
+	VBlank  Mode = 1 // Done with the frame.
+	OAMScan Mode = 2 // Looking for sprites on the line. OAM is locked.
+	Drawing Mode = 3 // Sending pixels to the LCD. OAM and VRAM are locked.
+)
+
+// Timings, in cycles.
+const (
+	oamScanCycles = 80
+	drawingCycles = 172
+	lineCycles    = 456
+	lines         = 154 // Including the 10 lines of VBlank.
+)
+
+// shades are the colours of the four DMG shades, lightest first.
+var shades = [4]color.RGBA{
+	{0xFF, 0xFF, 0xFF, 0xFF},
+	{0xAA, 0xAA, 0xAA, 0xFF},
+	{0x55, 0x55, 0x55, 0xFF},
+	{0x00, 0x00, 0x00, 0xFF},
+}
+
+// vramBankSize is the size of each of the CGB's two VRAM banks.
+const vramBankSize = memory.VRAMEnd - memory.VRAMStart + 1
+
+// PPU is the picture processing unit. It implements memory.Device and should
+// be attached to VRAM, OAM and its registers.
+type PPU struct {
+	ic *interrupts.Controller
+
+	model model.Model
+	// cgb turns on the Game Boy Color's VRAM bank, palettes and attributes.
+	// A CGB running a DMG game without it is in compatibility mode, where
+	// DMG shades are coloured by the first palettes in palette RAM.
+	cgb bool
+	// correctColors runs CGB colours through a curve that approximates how
+	// they looked on the real LCD.
+	correctColors bool
+
+	vram [2][vramBankSize]uint8 // Bank 1 is only used in CGB mode.
+	vbk  uint8
+	oam  [memory.OAMEnd - memory.OAMStart + 1]uint8
+
+	bgPalettes, objPalettes palettes
+
+	lcdc, stat      uint8 // Only the writable bits of STAT.
+	scy, scx        uint8
+	ly, lyc         uint8
+	bgp, obp0, obp1 uint8
+	wy, wx          uint8
+	mode            Mode
+	dot             int // Cycles into the current line.
+	windowLine      int // Lines of the window drawn so far this frame.
+	statLine        bool
+	frames          uint64
+	hblanks         uint64
+	back, front     *image.RGBA
+}
+
+// Option changes how New sets up a PPU.
+type Option func(*PPU)
+
+// WithCGB turns on Game Boy Color mode: VRAM banking, colour palettes and
+// background attributes.
+func WithCGB() Option {
+	return func(p *PPU) {
+		p.cgb = true
+	}
+}
+
+// WithModel sets the hardware the PPU behaves like. The default is a DMG, or a
+// CGB with WithCGB.
+func WithModel(m model.Model) Option {
+	return func(p *PPU) {
+		p.model = m
+	}
+}
+
+// WithColorCorrection makes CGB colours look like they did on the real LCD,
+// which was darker and less saturated than a modern screen.
+func WithColorCorrection() Option {
+	return func(p *PPU) {
+		p.correctColors = true
+	}
+}
+
+// New returns a PPU with the LCD off that requests its interrupts from ic.
+func New(ic *interrupts.Controller, opts ...Option) *PPU {
+	rect := image.Rect(0, 0, Width, Height)
+	p := &PPU{
+		ic:    ic,
+		back:  image.NewRGBA(rect),
+		front: image.NewRGBA(rect),
+	}
+	for _, opt := range opts {
+		opt(p)
+	}
+	if p.cgb && !p.model.CGB() {
+		p.model = model.CGB
+	}
+	return p
+}
+
+// compat reports whether the PPU is a CGB in compatibility mode.
+func (p *PPU) compat() bool {
+	return p.model.CGB() && !p.cgb
+}
+
+// Frame returns the last complete frame. It's overwritten when the next frame
+// completes.
+func (p *PPU) Frame() image.Image {
+	return p.front
+}
+
+// Frames returns the number of frames completed since the PPU was created.
+func (p *PPU) Frames() uint64 {
+	return p.frames
+}
+
+// HBlanks returns the number of times the PPU has entered HBlank on a visible
+// line since it was created. HBlank DMA uses it to know when to copy.
+func (p *PPU) HBlanks() uint64 {
+	return p.hblanks
+}
+
+// WriteOAM sets byte i of OAM. It's used by OAM DMA, which isn't locked out
+// of OAM while the PPU is using it like the CPU is.
+func (p *PPU) WriteOAM(i int, val uint8) {
+	p.oam[i] = val
+}
+
+// WriteVRAM sets addr in the current VRAM bank, whatever mode the PPU is in.
+// It's used by HDMA.
+func (p *PPU) WriteVRAM(addr uint16, val uint8) {
+	p.vram[p.vbk][(addr-memory.VRAMStart)%vramBankSize] = val
+}
+
+// Mode returns the PPU's current mode.
+func (p *PPU) Mode() Mode {
+	return p.mode
+}
+
+func (p *PPU) enabled() bool {
+	return p.lcdc&lcdcEnable != 0
+}
+
+// Read8 implements memory.Device.
+func (p *PPU) Read8(addr uint16) uint8 {
+	switch {
+	case addr >= memory.VRAMStart && addr <= memory.VRAMEnd:
+		if p.enabled() && p.mode == Drawing {
+			return 0xFF
+		}
+		return p.vram[p.vbk][addr-memory.VRAMStart]
+	case addr >= memory.OAMStart && addr <= memory.OAMEnd:
+		if p.enabled() && (p.mode == OAMScan || p.mode == Drawing) {
+			return 0xFF
+		}
+		return p.oam[addr-memory.OAMStart]
+	}
+
+	switch addr {
+	case LCDC:
+		return p.lcdc
+	case STAT:
+		val := 0x80 | p.stat | uint8(p.mode)
+		if p.ly == p.lyc {
+			val |= statCoincide
+		}
+		return val
+	case SCY:
+		return p.scy
+	case SCX:
+		return p.scx
+	case LY:
+		return p.ly
+	case LYC:
+		return p.lyc
+	case BGP:
+		return p.bgp
+	case OBP0:
+		return p.obp0
+	case OBP1:
+		return p.obp1
+	case WY:
+		return p.wy
+	case WX:
+		return p.wx
+	}
+
+	if !p.model.CGB() {
+		return 0xFF
+	}
+	switch addr {
+	case VBK:
+		if !p.cgb {
+			return 0xFF
+		}
+		return 0xFE | p.vbk
+	case BCPS:
+		return p.bgPalettes.readIndex()
+	case BCPD:
+		return p.bgPalettes.read(p.paletteLocked())
+	case OCPS:
+		return p.objPalettes.readIndex()
+	case OCPD:
+		return p.objPalettes.read(p.paletteLocked())
+	default:
+		return 0xFF
+	}
+}
+
+// Write8 implements memory.Device.
+func (p *PPU) Write8(addr uint16, val uint8) {
+	switch {
+	case addr >= memory.VRAMStart && addr <= memory.VRAMEnd:
+		if !p.enabled() || p.mode != Drawing {
+			p.vram[p.vbk][addr-memory.VRAMStart] = val
+		}
+		return
+	case addr >= memory.OAMStart && addr <= memory.OAMEnd:
+		if !p.enabled() || (p.mode != OAMScan && p.mode != Drawing) {
+			p.oam[addr-memory.OAMStart] = val
+		}
+		return
+	}
+
+	switch addr {
+	case LCDC:
+		p.setLCDC(val)
+	case STAT:
+		if !p.model.CGB() {
+			// Before the CGB, writing STAT briefly enables every source but
+			// OAM scan, which can request an interrupt whatever is written.
+			p.stat = statHBlankIRQ | statVBlankIRQ | statLYCIRQ
+			p.updateStatLine()
+		}
+		p.stat = val & (statHBlankIRQ | statVBlankIRQ | statOAMIRQ | statLYCIRQ)
+		p.updateStatLine()
+	case SCY:
+		p.scy = val
+	case SCX:
+		p.scx = val
+	case LY:
+		// Read only.
+	case LYC:
+		p.lyc = val
+		p.updateStatLine()
+	case BGP:
+		p.bgp = val
+	case OBP0:
+		p.obp0 = val
+	case OBP1:
+		p.obp1 = val
+	case WY:
+		p.wy = val
+	case WX:
+		p.wx = val
+	}
+
+	if !p.model.CGB() {
+		return
+	}
+	switch addr {
+	case VBK:
+		if p.cgb {
+			p.vbk = val & 0x01
+		}
+	case BCPS:
+		p.bgPalettes.writeIndex(val)
+	case BCPD:
+		p.bgPalettes.write(val, p.paletteLocked())
+	case OCPS:
+		p.objPalettes.writeIndex(val)
+	case OCPD:
+		p.objPalettes.write(val, p.paletteLocked())
+	}
+}
+
+// paletteLocked reports whether the CPU is locked out of palette RAM, which
+// happens while the PPU is reading it to draw.
+func (p *PPU) paletteLocked() bool {
+	return p.enabled() && p.mode == Drawing
+}
+
+// setLCDC writes LCDC, turning the LCD on or off if bit 7 changed. While it's
+// off LY stays at 0 and the PPU sits in mode 0.
+func (p *PPU) setLCDC(val uint8) {
+	wasEnabled := p.enabled()
+	p.lcdc = val
+	switch {
+	case wasEnabled && !p.enabled():
+		p.ly, p.dot, p.windowLine = 0, 0, 0
+		p.mode = HBlank
+		p.statLine = false
+	case !wasEnabled && p.enabled():
+		p.mode = OAMScan
+		p.updateStatLine()
+	}
+}
+
+// Tick advances the PPU by the given number of cycles.
+func (p *PPU) Tick(cycles uint) {
+	if !p.enabled() {
+		return
+	}
+	for ; cycles > 0; cycles-- {
+		p.dot++
+		switch {
+		case p.mode == OAMScan && p.dot == oamScanCycles:
+			p.setMode(Drawing)
+		case p.mode == Drawing && p.dot == oamScanCycles+drawingCycles:
+			p.renderLine()
+			p.setMode(HBlank)
+			p.hblanks++
+		case p.dot == lineCycles:
+			p.nextLine()
+		}
+	}
+}
+
+// nextLine moves on to the start of the next line, entering or leaving VBlank
+// as needed.
+func (p *PPU) nextLine() {
+	p.dot = 0
+	p.ly++
+	switch {
+	case p.ly == Height:
+		p.setMode(VBlank)
+		p.ic.Request(interrupts.VBlank)
+		p.back, p.front = p.front, p.back
+		p.frames++
+	case int(p.ly) == lines:
+		p.ly = 0
+		p.windowLine = 0
+		p.setMode(OAMScan)
+	case p.ly < Height:
+		p.setMode(OAMScan)
+	default:
+		p.updateStatLine()
+	}
+}
+
+func (p *PPU) setMode(m Mode) {
+	p.mode = m
+	p.updateStatLine()
+}
+
+// updateStatLine recomputes the STAT interrupt line, which is the OR of every
+// enabled STAT interrupt source. The interrupt is only requested when the line
+// goes from low to high, so one source being active blocks the others.
+func (p *PPU) updateStatLine() {
+	line := p.enabled() && (p.stat&statLYCIRQ != 0 && p.ly == p.lyc ||
+		p.stat&statHBlankIRQ != 0 && p.mode == HBlank ||
+		p.stat&statVBlankIRQ != 0 && p.mode == VBlank ||
+		p.stat&statOAMIRQ != 0 && p.mode == OAMScan)
+	if line && !p.statLine {
+		p.ic.Request(interrupts.LCDStat)
+	}
+	p.statLine = line
+}
@@ -0,0 +1,313 @@
+package ppu_test
+
+import (
+	"image/color"
+	"testing"
+
+	"github.com/vsinha/vm/internal/interrupts"
+	"github.com/vsinha/vm/internal/model"
+	"github.com/vsinha/vm/internal/ppu"
+)
+
+const frameCycles = 154 * 456
+
+var (
+	white = color.RGBA{0xFF, 0xFF, 0xFF, 0xFF}
+	light = color.RGBA{0xAA, 0xAA, 0xAA, 0xFF}
+	black = color.RGBA{0x00, 0x00, 0x00, 0xFF}
+)
+
+// newPPU returns a PPU with every interrupt enabled on its controller.
+func newPPU() (*ppu.PPU, *interrupts.Controller) {
+	ic := interrupts.New()
+	ic.Write8(interrupts.EnableAddr, 0xFF)
+	return ppu.New(ic), ic
+}
+
+func TestTiming(t *testing.T) {
+	p, ic := newPPU()
+	p.Write8(ppu.LCDC, 0x80)
+
+	tests := []struct {
+		cycles uint // Cycles to tick before checking.
+		ly     uint8
+		mode   ppu.Mode
+	}{
+		{0, 0, ppu.OAMScan},
+		{79, 0, ppu.OAMScan},
+		{1, 0, ppu.Drawing},
+		{172, 0, ppu.HBlank},
+		{204, 1, ppu.OAMScan},
+		{143 * 456, 144, ppu.VBlank},
+		{9 * 456, 153, ppu.VBlank},
+		{456, 0, ppu.OAMScan},
+	}
+	for _, test := range tests {
+		p.Tick(test.cycles)
+		if got := p.Read8(ppu.LY); got != test.ly {
+			t.Errorf("LY = %d, want %d", got, test.ly)
+		}
+		if got := p.Mode(); got != test.mode {
+			t.Errorf("LY %d: Mode() = %d, want %d", test.ly, got, test.mode)
+		}
+		if got := ppu.Mode(p.Read8(ppu.STAT) & 0x03); got != test.mode {
+			t.Errorf("LY %d: STAT mode = %d, want %d", test.ly, got, test.mode)
+		}
+	}
+
+	if got := p.Frames(); got != 1 {
+		t.Errorf("Frames() = %d, want 1", got)
+	}
+	if got := ic.Read8(interrupts.FlagAddr) & 0x01; got == 0 {
+		t.Errorf("VBlank interrupt not requested")
+	}
+
+	// Turning the LCD off resets LY.
+	p.Tick(10 * 456)
+	p.Write8(ppu.LCDC, 0x00)
+	if got := p.Read8(ppu.LY); got != 0 {
+		t.Errorf("LY = %d with the LCD off, want 0", got)
+	}
+	p.Tick(456)
+	if got := p.Read8(ppu.LY); got != 0 {
+		t.Errorf("LY = %d after ticking with the LCD off, want 0", got)
+	}
+}
+
+func TestStatInterrupts(t *testing.T) {
+	tests := []struct {
+		name   string
+		stat   uint8
+		lyc    uint8
+		cycles uint
+		want   int // Number of STAT interrupts.
+	}{
+		{"none enabled", 0x00, 0, frameCycles, 0},
+		{"LYC", 0x40, 10, frameCycles, 1},
+		{"HBlank every line", 0x08, 0, frameCycles, 144},
+		{"VBlank once a frame", 0x10, 0, frameCycles, 1},
+		{"OAM scan every line", 0x20, 0, frameCycles - 1, 143},
+		// HBlank keeps the line high into the next line's OAM scan, which
+		// blocks the OAM and LYC interrupts, and LYC holding it high through
+		// line 10 then blocks that line's HBlank interrupt.
+		{"blocking", 0x68, 10, 11 * 456, 10},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			p, ic := newPPU()
+			p.Write8(ppu.STAT, test.stat)
+			p.Write8(ppu.LYC, test.lyc)
+			p.Write8(ppu.LCDC, 0x80)
+			ic.Write8(interrupts.FlagAddr, 0x00)
+
+			got := 0
+			for i := uint(0); i < test.cycles; i++ {
+				p.Tick(1)
+				if ic.Read8(interrupts.FlagAddr)&0x02 != 0 {
+					got++
+					ic.Write8(interrupts.FlagAddr, 0x00)
+				}
+			}
+			if got != test.want {
+				t.Errorf("got %d STAT interrupts, want %d", got, test.want)
+			}
+		})
+	}
+}
+
+func TestStatWriteQuirk(t *testing.T) {
+	tests := []struct {
+		model model.Model
+		want  bool
+	}{
+		{model.DMG, true},
+		{model.CGB, false},
+	}
+	for _, test := range tests {
+		t.Run(test.model.String(), func(t *testing.T) {
+			ic := interrupts.New()
+			p := ppu.New(ic, ppu.WithModel(test.model))
+			p.Write8(ppu.LYC, 0x99) // Keep LY=LYC out of it.
+			p.Write8(ppu.LCDC, 0x80)
+			p.Tick(80) // Into mode 3, which doesn't trigger it.
+			p.Write8(ppu.STAT, 0x00)
+			if ic.Read8(interrupts.FlagAddr)&0x02 != 0 {
+				t.Fatalf("STAT interrupt writing STAT in mode 3")
+			}
+
+			p.Tick(456 - 80 - 4) // HBlank.
+			p.Write8(ppu.STAT, 0x00)
+			if got := ic.Read8(interrupts.FlagAddr)&0x02 != 0; got != test.want {
+				t.Errorf("STAT interrupt writing STAT in HBlank = %v, want %v", got, test.want)
+			}
+		})
+	}
+}
+
+func TestCoincidence(t *testing.T) {
+	p, _ := newPPU()
+	p.Write8(ppu.LYC, 2)
+	p.Write8(ppu.LCDC, 0x80)
+
+	if got := p.Read8(ppu.STAT) & 0x04; got != 0 {
+		t.Errorf("STAT coincidence set on line 0 with LYC 2")
+	}
+	p.Tick(2 * 456)
+	if got := p.Read8(ppu.STAT) & 0x04; got == 0 {
+		t.Errorf("STAT coincidence clear on line 2 with LYC 2")
+	}
+}
+
+func TestLockedMemory(t *testing.T) {
+	p, _ := newPPU()
+	p.Write8(0x8000, 0x42)
+	p.Write8(0xFE00, 0x43)
+	p.Write8(ppu.LCDC, 0x80)
+
+	if got := p.Read8(0xFE00); got != 0xFF {
+		t.Errorf("OAM read during OAM scan = %02X, want FF", got)
+	}
+	if got := p.Read8(0x8000); got != 0x42 {
+		t.Errorf("VRAM read during OAM scan = %02X, want 42", got)
+	}
+	p.Tick(80)
+	p.Write8(0x8000, 0x00)
+	if got := p.Read8(0x8000); got != 0xFF {
+		t.Errorf("VRAM read while drawing = %02X, want FF", got)
+	}
+	p.Tick(172)
+	if got := p.Read8(0x8000); got != 0x42 {
+		t.Errorf("VRAM read in HBlank = %02X, want 42 (the write while drawing should be dropped)", got)
+	}
+	if got := p.Read8(0xFE00); got != 0x43 {
+		t.Errorf("OAM read in HBlank = %02X, want 43", got)
+	}
+}
+
+// pixel is an expected colour in the frame.
+type pixel struct {
+	x, y int
+	c    color.RGBA
+}
+
+// fillTile sets every row of the tile at addr to the bit planes lo and hi.
+func fillTile(p *ppu.PPU, addr uint16, lo, hi uint8) {
+	for row := uint16(0); row < 8; row++ {
+		p.Write8(addr+row*2, lo)
+		p.Write8(addr+row*2+1, hi)
+	}
+}
+
+// setSprite writes OAM entry i.
+func setSprite(p *ppu.PPU, i int, y, x, tile, attrs uint8) {
+	addr := uint16(0xFE00 + i*4)
+	p.Write8(addr, y)
+	p.Write8(addr+1, x)
+	p.Write8(addr+2, tile)
+	p.Write8(addr+3, attrs)
+}
+
+func TestRender(t *testing.T) {
+	tests := []struct {
+		name  string
+		lcdc  uint8
+		setup func(p *ppu.PPU)
+		want  []pixel
+	}{
+		{"background", 0x91, func(p *ppu.PPU) {
+			fillTile(p, 0x8010, 0xFF, 0xFF)
+			p.Write8(0x9800, 1)
+		}, []pixel{{0, 0, black}, {7, 7, black}, {8, 0, white}, {0, 8, white}}},
+		{"background off", 0x90, func(p *ppu.PPU) {
+			fillTile(p, 0x8010, 0xFF, 0xFF)
+			p.Write8(0x9800, 1)
+		}, []pixel{{0, 0, white}}},
+		{"background off ignores BGP", 0x90, func(p *ppu.PPU) {
+			p.Write8(ppu.BGP, 0xFF)
+		}, []pixel{{0, 0, white}, {159, 143, white}}},
+		{"palette", 0x91, func(p *ppu.PPU) {
+			fillTile(p, 0x8010, 0xFF, 0x00)
+			p.Write8(0x9800, 1)
+		}, []pixel{{0, 0, light}}},
+		{"scroll", 0x91, func(p *ppu.PPU) {
+			fillTile(p, 0x8010, 0xFF, 0xFF)
+			p.Write8(0x9800, 1)
+			p.Write8(ppu.SCX, 4)
+			p.Write8(ppu.SCY, 2)
+		}, []pixel{{3, 5, black}, {4, 5, white}, {3, 6, white}}},
+		{"signed tile data", 0x81, func(p *ppu.PPU) {
+			fillTile(p, 0x8800, 0xFF, 0xFF)
+			p.Write8(0x9800, 0x80)
+		}, []pixel{{0, 0, black}, {8, 0, white}}},
+		{"window", 0xF1, func(p *ppu.PPU) {
+			fillTile(p, 0x8010, 0xFF, 0xFF)
+			for i := uint16(0); i < 32*32; i++ {
+				p.Write8(0x9C00+i, 1)
+			}
+			p.Write8(ppu.WY, 72)
+			p.Write8(ppu.WX, 80+7)
+		}, []pixel{{80, 72, black}, {159, 143, black}, {79, 72, white}, {80, 71, white}}},
+		{"sprite", 0x93, func(p *ppu.PPU) {
+			fillTile(p, 0x8010, 0xFF, 0xFF)
+			setSprite(p, 0, 16+10, 8+20, 1, 0x00)
+		}, []pixel{{20, 10, black}, {27, 17, black}, {28, 10, white}, {19, 10, white}}},
+		{"sprites off", 0x91, func(p *ppu.PPU) {
+			fillTile(p, 0x8010, 0xFF, 0xFF)
+			setSprite(p, 0, 16, 8, 1, 0x00)
+		}, []pixel{{0, 0, white}}},
+		{"sprite palette 1", 0x93, func(p *ppu.PPU) {
+			fillTile(p, 0x8010, 0xFF, 0xFF)
+			setSprite(p, 0, 16, 8, 1, 0x10)
+			p.Write8(ppu.OBP1, 0x7F)
+		}, []pixel{{0, 0, light}}},
+		{"sprite flip X", 0x93, func(p *ppu.PPU) {
+			fillTile(p, 0x8010, 0x80, 0x80)
+			setSprite(p, 0, 16, 8, 1, 0x20)
+		}, []pixel{{0, 0, white}, {7, 0, black}}},
+		{"sprite behind background", 0x93, func(p *ppu.PPU) {
+			fillTile(p, 0x8010, 0xFF, 0x00)
+			fillTile(p, 0x8020, 0xFF, 0xFF)
+			p.Write8(0x9800, 1)
+			setSprite(p, 0, 16, 8, 2, 0x80)
+			setSprite(p, 1, 16, 8+8, 2, 0x80)
+		}, []pixel{{0, 0, light}, {8, 0, black}}},
+		{"8x16 sprites", 0x97, func(p *ppu.PPU) {
+			fillTile(p, 0x8030, 0xFF, 0xFF)
+			setSprite(p, 0, 16, 8, 2, 0x00)
+		}, []pixel{{0, 7, white}, {0, 8, black}, {0, 15, black}}},
+		{"lower X wins", 0x93, func(p *ppu.PPU) {
+			fillTile(p, 0x8010, 0xFF, 0xFF)
+			fillTile(p, 0x8020, 0xFF, 0x00)
+			setSprite(p, 0, 16, 8+4, 1, 0x00)
+			setSprite(p, 1, 16, 8, 2, 0x00)
+		}, []pixel{{4, 0, light}, {8, 0, black}}},
+		{"ten sprites a line", 0x93, func(p *ppu.PPU) {
+			fillTile(p, 0x8010, 0xFF, 0xFF)
+			for i := 0; i < 11; i++ {
+				setSprite(p, i, 16, uint8(8+i*8), 1, 0x00)
+			}
+		}, []pixel{{72, 0, black}, {80, 0, white}}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			p, _ := newPPU()
+			p.Write8(ppu.BGP, 0xE4)
+			p.Write8(ppu.OBP0, 0xE4)
+			test.setup(p)
+			p.Write8(ppu.LCDC, test.lcdc)
+			p.Tick(frameCycles)
+
+			frame := p.Frame()
+			if got := frame.Bounds().Size(); got.X != ppu.Width || got.Y != ppu.Height {
+				t.Fatalf("frame size = %v, want %dx%d", got, ppu.Width, ppu.Height)
+			}
+			for _, want := range test.want {
+				if got := color.RGBAModel.Convert(frame.At(want.x, want.y)); got != want.c {
+					t.Errorf("pixel %d,%d = %v, want %v", want.x, want.y, got, want.c)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,256 @@
+package ppu
+
+import (
+	"image/color"
+	"sort"
+)
+
+// Sprite attribute bits.
+const (
+	attrCGBPalette = 0x07 // CGB only, the sprite palette.
+	attrBank       = 0x08 // CGB only, take the tile from VRAM bank 1.
+	attrPalette    = 0x10 // DMG only, OBP1 instead of OBP0.
+	attrFlipX      = 0x20
+	attrFlipY      = 0x40
+	attrBehindBG   = 0x80 // Only drawn over background colour 0.
+)
+
+// CGB background map attribute bits, found in VRAM bank 1 at the same address
+// as the tile index in bank 0. The bank and flip bits match the sprite ones.
+const (
+	bgAttrPalette  = 0x07
+	bgAttrPriority = 0x80 // Drawn over sprites.
+)
+
+// maxSprites is how many sprites the PPU can draw on one line.
+const maxSprites = 10
+
+// sprite is an entry from OAM.
+type sprite struct {
+	y, x  int // Screen position of the top left corner.
+	tile  uint8
+	attrs uint8
+}
+
+// renderLine draws line LY into the back buffer.
+func (p *PPU) renderLine() {
+	ly := int(p.ly)
+
+	// bg holds the colour index of the background or window at each pixel,
+	// before the palette is applied, and bgAttrs its CGB attributes. Sprite
+	// priority depends on both.
+	var bg, bgAttrs [Width]uint8
+	// On a CGB the background can't be turned off, LCDC bit 0 changes sprite
+	// priority instead.
+	bgOn := p.cgb || p.lcdc&lcdcBGEnable != 0
+	if bgOn {
+		p.renderBackground(ly, &bg, &bgAttrs)
+		p.renderWindow(ly, &bg, &bgAttrs)
+	}
+	for x := 0; x < Width; x++ {
+		// With the background off the line is blank white, whatever colour
+		// BGP gives colour 0.
+		c := shades[0]
+		if bgOn {
+			c = p.bgColor(bg[x], bgAttrs[x])
+		}
+		p.back.SetRGBA(x, ly, c)
+	}
+
+	if p.lcdc&lcdcOBJEnable != 0 {
+		p.renderSprites(ly, &bg, &bgAttrs)
+	}
+}
+
+func (p *PPU) bgColor(i, attrs uint8) color.RGBA {
+	switch {
+	case p.cgb:
+		return p.bgPalettes.color(attrs&bgAttrPalette, i, p.correctColors)
+	case p.compat():
+		return p.bgPalettes.color(0, palette(p.bgp, i), p.correctColors)
+	}
+	return shades[palette(p.bgp, i)]
+}
+
+func (p *PPU) renderBackground(ly int, bg, bgAttrs *[Width]uint8) {
+	mapBase := uint16(0x9800)
+	if p.lcdc&lcdcBGMap != 0 {
+		mapBase = 0x9C00
+	}
+
+	y := (ly + int(p.scy)) & 0xFF
+	for x := 0; x < Width; x++ {
+		bx := (x + int(p.scx)) & 0xFF
+		bg[x], bgAttrs[x] = p.mapPixel(mapBase, bx, y)
+	}
+}
+
+func (p *PPU) renderWindow(ly int, bg, bgAttrs *[Width]uint8) {
+	if p.lcdc&lcdcWindowEnable == 0 || ly < int(p.wy) || int(p.wx) > Width+6 {
+		return
+	}
+	mapBase := uint16(0x9800)
+	if p.lcdc&lcdcWindowMap != 0 {
+		mapBase = 0x9C00
+	}
+
+	// The window has its own line counter, which only moves on lines where
+	// the window was actually drawn.
+	for x := int(p.wx) - 7; x < Width; x++ {
+		if x < 0 {
+			continue
+		}
+		bg[x], bgAttrs[x] = p.mapPixel(mapBase, x-(int(p.wx)-7), p.windowLine)
+	}
+	p.windowLine++
+}
+
+// mapPixel returns the colour index and CGB attributes at x, y of the 256x256
+// tile map at mapBase.
+func (p *PPU) mapPixel(mapBase uint16, x, y int) (uint8, uint8) {
+	off := int(mapBase) - 0x8000 + (y/8)*32 + x/8
+	tile := p.vram[0][off]
+	var attrs uint8
+	if p.cgb {
+		attrs = p.vram[1][off]
+	}
+
+	tx, ty := x%8, y%8
+	if attrs&attrFlipX != 0 {
+		tx = 7 - tx
+	}
+	if attrs&attrFlipY != 0 {
+		ty = 7 - ty
+	}
+	return p.tilePixel(attrs&attrBank != 0, p.tileAddr(tile), tx, ty), attrs
+}
+
+// tileAddr returns the VRAM offset of a background or window tile, which
+// depends on the addressing mode selected by LCDC.
+func (p *PPU) tileAddr(tile uint8) int {
+	if p.lcdc&lcdcTileData != 0 {
+		return int(tile) * 16
+	}
+	return 0x1000 + int(int8(tile))*16
+}
+
+// tilePixel returns the colour index at x, y of the tile at VRAM offset addr,
+// in bank 1 if bank1 is set. Each row of a tile is two bytes: the low bits of
+// its 8 pixels, then the high bits, leftmost pixel in bit 7.
+func (p *PPU) tilePixel(bank1 bool, addr, x, y int) uint8 {
+	vram := &p.vram[0]
+	if bank1 {
+		vram = &p.vram[1]
+	}
+	lo := vram[addr+y*2]
+	hi := vram[addr+y*2+1]
+	bit := uint(7 - x)
+	return (lo>>bit)&1 | ((hi>>bit)&1)<<1
+}
+
+// palette maps colour index i through the DMG palette register pal.
+func palette(pal, i uint8) uint8 {
+	return (pal >> (i * 2)) & 0x03
+}
+
+// lineSprites returns the sprites on line ly, at most 10 of them, in order of
+// priority. On a DMG that's lowest X first, then lowest OAM index. A CGB only
+// goes by OAM index.
+func (p *PPU) lineSprites(ly int) []sprite {
+	height := 8
+	if p.lcdc&lcdcOBJSize != 0 {
+		height = 16
+	}
+
+	var sprites []sprite
+	for i := 0; i < len(p.oam) && len(sprites) < maxSprites; i += 4 {
+		s := sprite{
+			y:     int(p.oam[i]) - 16,
+			x:     int(p.oam[i+1]) - 8,
+			tile:  p.oam[i+2],
+			attrs: p.oam[i+3],
+		}
+		// Sprites count towards the limit even when they're off screen
+		// horizontally.
+		if ly >= s.y && ly < s.y+height {
+			sprites = append(sprites, s)
+		}
+	}
+	if !p.cgb {
+		sort.SliceStable(sprites, func(i, j int) bool {
+			return sprites[i].x < sprites[j].x
+		})
+	}
+	return sprites
+}
+
+func (p *PPU) renderSprites(ly int, bg, bgAttrs *[Width]uint8) {
+	tall := p.lcdc&lcdcOBJSize != 0
+	sprites := p.lineSprites(ly)
+
+	for x := 0; x < Width; x++ {
+		for _, s := range sprites {
+			if x < s.x || x >= s.x+8 {
+				continue
+			}
+			i := p.spritePixel(s, x-s.x, ly-s.y, tall)
+			if i == 0 {
+				// Transparent, a lower priority sprite may show through.
+				continue
+			}
+			if !p.spriteOnTop(s, bg[x], bgAttrs[x]) {
+				break
+			}
+			p.back.SetRGBA(x, ly, p.spriteColor(s, i))
+			break
+		}
+	}
+}
+
+// spriteOnTop reports whether sprite s is drawn over a background pixel with
+// colour index i and CGB attributes attrs. Background colour 0 is always
+// behind sprites.
+func (p *PPU) spriteOnTop(s sprite, i, attrs uint8) bool {
+	if i == 0 {
+		return true
+	}
+	if p.cgb && p.lcdc&lcdcBGEnable == 0 {
+		// LCDC bit 0 clear puts every sprite on top on a CGB.
+		return true
+	}
+	return s.attrs&attrBehindBG == 0 && attrs&bgAttrPriority == 0
+}
+
+func (p *PPU) spriteColor(s sprite, i uint8) color.RGBA {
+	if p.cgb {
+		return p.objPalettes.color(s.attrs&attrCGBPalette, i, p.correctColors)
+	}
+	pal, n := p.obp0, uint8(0)
+	if s.attrs&attrPalette != 0 {
+		pal, n = p.obp1, 1
+	}
+	if p.compat() {
+		return p.objPalettes.color(n, palette(pal, i), p.correctColors)
+	}
+	return shades[palette(pal, i)]
+}
+
+// spritePixel returns the colour index at x, y within sprite s.
+func (p *PPU) spritePixel(s sprite, x, y int, tall bool) uint8 {
+	height := 8
+	tile := s.tile
+	if tall {
+		height = 16
+		tile &^= 0x01
+	}
+	if s.attrs&attrFlipX != 0 {
+		x = 7 - x
+	}
+	if s.attrs&attrFlipY != 0 {
+		y = height - 1 - y
+	}
+	// Sprites always use 0x8000 addressing. For 8x16 sprites the lower tile
+	// follows straight on from the upper one.
+	bank1 := p.cgb && s.attrs&attrBank != 0
+	return p.tilePixel(bank1, int(tile)*16+(y/8)*16, x, y%8)
+}
@@ -0,0 +1,312 @@
+// Package printer emulates the Game Boy Printer, which games print to over
+// the serial port.
+package printer
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"image/png"
+	"os"
+	"path/filepath"
+)
+
+// Commands.
+const (
+	cmdInit   = 0x01 // Clear the image buffer.
+	cmdPrint  = 0x02 // Print the image buffer.
+	cmdData   = 0x04 // Add tile data to the image buffer.
+	cmdStatus = 0x0F // Just return the status.
+)
+
+// Status bits.
+const (
+	statusChecksum = 0x01 // The last packet's checksum was wrong.
+	statusPrinting = 0x02
+	statusFull     = 0x04 // The image buffer is full.
+	statusData     = 0x08 // The image buffer holds data not yet printed.
+	statusPacket   = 0x10 // The last packet was malformed.
+)
+
+// The printer's replies to the two bytes after each packet's checksum.
+const aliveReply = 0x81
+
+const (
+	// maxPacket is the most data a packet can carry: two rows of 20 tiles.
+	maxPacket = 0x280
+	// maxData is the size of the image buffer, a screen of 18 rows of tiles.
+	maxData = 9 * maxPacket
+
+	// Width is the width of printed images in pixels.
+	Width = 160
+	// marginRows is the height in pixels of a unit of margin.
+	marginRows = 8
+	// printingPolls is how many status replies report printing after a print
+	// command.
+	printingPolls = 2
+)
+
+// state is where the printer is in receiving a packet.
+type state int
+
+const (
+	magic1 state = iota
+	magic2
+	command
+	compression
+	lengthLow
+	lengthHigh
+	data
+	checksumLow
+	checksumHigh
+	alive
+	status
+)
+
+// shades are the printed grays of the four shades in a palette.
+var shades = [4]color.Gray{{0xFF}, {0xAA}, {0x55}, {0x00}}
+
+// Printer is a Game Boy Printer. It implements serial.Link and should be
+// connected to a VM's serial port.
+//
+// Each print command with a non-zero number of sheets prints the image buffer
+// once, as a page with its margins added as blank rows. Exposure is ignored.
+type Printer struct {
+	dir string
+
+	state      state
+	cmd        uint8
+	compressed bool
+	length     int
+	packet     []byte
+	sum        uint16
+	checksum   uint16
+
+	buf    []byte
+	status uint8
+	// printing counts down the status replies that report printing.
+	printing int
+
+	pages []*image.Gray
+	err   error
+}
+
+// Option changes how New sets up a printer.
+type Option func(*Printer)
+
+// WithOutputDir makes the printer write each page to dir as a PNG, named
+// print001.png, print002.png and so on.
+func WithOutputDir(dir string) Option {
+	return func(p *Printer) {
+		p.dir = dir
+	}
+}
+
+// New returns a printer with an empty image buffer.
+func New(opts ...Option) *Printer {
+	p := &Printer{}
+	for _, opt := range opts {
+		opt(p)
+	}
+	return p
+}
+
+// Pages returns the pages printed so far.
+func (p *Printer) Pages() []*image.Gray {
+	return p.pages
+}
+
+// Err returns the first error writing a page to the output directory.
+func (p *Printer) Err() error {
+	return p.err
+}
+
+// Exchange implements serial.Link, taking a byte of a packet from the Game
+// Boy and returning the printer's reply.
+func (p *Printer) Exchange(b uint8) uint8 {
+	switch p.state {
+	case magic1:
+		if b == 0x88 {
+			p.state = magic2
+		}
+	case magic2:
+		switch b {
+		case 0x33:
+			p.state = command
+		case 0x88:
+		default:
+			p.state = magic1
+		}
+	case command:
+		p.cmd = b
+		p.sum = uint16(b)
+		p.state = compression
+	case compression:
+		p.compressed = b&0x01 != 0
+		p.sum += uint16(b)
+		p.state = lengthLow
+	case lengthLow:
+		p.length = int(b)
+		p.sum += uint16(b)
+		p.state = lengthHigh
+	case lengthHigh:
+		p.length |= int(b) << 8
+		p.sum += uint16(b)
+		p.packet = p.packet[:0]
+		p.state = data
+		if p.length == 0 {
+			p.state = checksumLow
+		}
+	case data:
+		p.packet = append(p.packet, b)
+		p.sum += uint16(b)
+		if len(p.packet) == p.length {
+			p.state = checksumLow
+		}
+	case checksumLow:
+		p.checksum = uint16(b)
+		p.state = checksumHigh
+	case checksumHigh:
+		p.checksum |= uint16(b) << 8
+		p.state = alive
+	case alive:
+		p.state = status
+		p.handle()
+		return aliveReply
+	case status:
+		p.state = magic1
+		s := p.status
+		if p.printing > 0 {
+			s |= statusPrinting
+			p.printing--
+		}
+		return s
+	}
+	return 0x00
+}
+
+// handle runs a packet that has been received in full.
+func (p *Printer) handle() {
+	p.status &^= statusChecksum | statusPacket
+	if p.sum != p.checksum {
+		p.status |= statusChecksum
+		return
+	}
+
+	switch p.cmd {
+	case cmdInit:
+		p.buf = p.buf[:0]
+		p.status = 0
+		p.printing = 0
+	case cmdData:
+		d := p.packet
+		if p.compressed {
+			var ok bool
+			if d, ok = decompress(d); !ok {
+				p.status |= statusPacket
+				return
+			}
+		}
+		if len(d) > maxPacket {
+			p.status |= statusPacket
+			return
+		}
+		if room := maxData - len(p.buf); len(d) > room {
+			d = d[:room]
+		}
+		p.buf = append(p.buf, d...)
+		if len(p.buf) > 0 {
+			p.status |= statusData
+		}
+		if len(p.buf) == maxData {
+			p.status |= statusFull
+		}
+	case cmdPrint:
+		if len(p.packet) != 4 {
+			p.status |= statusPacket
+			return
+		}
+		sheets, margins, palette := p.packet[0], p.packet[1], p.packet[2]
+		if sheets > 0 {
+			p.print(margins>>4, margins&0x0F, palette)
+		}
+		p.buf = p.buf[:0]
+		p.status &^= statusData | statusFull
+		p.printing = printingPolls
+	case cmdStatus:
+	default:
+		p.status |= statusPacket
+	}
+}
+
+// decompress expands run-length encoded data. Each run starts with a control
+// byte: with bit 7 set, the next byte is repeated (control&0x7F)+2 times;
+// otherwise the next control+1 bytes are copied as they are.
+func decompress(d []byte) ([]byte, bool) {
+	var out []byte
+	for i := 0; i < len(d); {
+		ctrl := d[i]
+		i++
+		if ctrl&0x80 != 0 {
+			if i >= len(d) {
+				return nil, false
+			}
+			for n := int(ctrl&0x7F) + 2; n > 0; n-- {
+				out = append(out, d[i])
+			}
+			i++
+			continue
+		}
+		n := int(ctrl) + 1
+		if i+n > len(d) {
+			return nil, false
+		}
+		out = append(out, d[i:i+n]...)
+		i += n
+	}
+	return out, true
+}
+
+// print turns the image buffer into a page with the given margins, in units of
+// marginRows, and palette, which maps each colour to a shade two bits at a
+// time like BGP.
+func (p *Printer) print(top, bottom, palette uint8) {
+	// Most games send 0, which the printer treats as the usual palette.
+	if palette == 0 {
+		palette = 0xE4
+	}
+
+	const tilesPerRow = Width / 8
+	rows := len(p.buf) / (tilesPerRow * 16) * 8
+	y0 := int(top) * marginRows
+	img := image.NewGray(image.Rect(0, 0, Width, y0+rows+int(bottom)*marginRows))
+	for i := range img.Pix {
+		img.Pix[i] = shades[0].Y
+	}
+	for y := 0; y < rows; y++ {
+		for x := 0; x < Width; x++ {
+			tile := (y/8)*tilesPerRow + x/8
+			line := p.buf[tile*16+y%8*2:]
+			bit := uint(7 - x%8)
+			c := line[0]>>bit&1 | line[1]>>bit&1<<1
+			img.SetGray(x, y0+y, shades[palette>>(2*c)&0x03])
+		}
+	}
+
+	p.pages = append(p.pages, img)
+	if p.dir != "" && p.err == nil {
+		p.err = writePNG(filepath.Join(p.dir, fmt.Sprintf("print%03d.png", len(p.pages))), img)
+	}
+}
+
+func writePNG(path string, img image.Image) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if err := png.Encode(f, img); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
@@ -0,0 +1,189 @@
+package printer_test
+
+import (
+	"image"
+	"image/png"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/vsinha/vm/internal/interrupts"
+	"github.com/vsinha/vm/internal/printer"
+	"github.com/vsinha/vm/internal/serial"
+)
+
+// packet encodes a printer packet, including the magic bytes, checksum and
+// the two bytes sent for the replies.
+func packet(cmd uint8, compressed bool, data []byte) []byte {
+	comp := uint8(0)
+	if compressed {
+		comp = 1
+	}
+	body := append([]byte{cmd, comp, uint8(len(data)), uint8(len(data) >> 8)}, data...)
+	var sum uint16
+	for _, b := range body {
+		sum += uint16(b)
+	}
+	p := append([]byte{0x88, 0x33}, body...)
+	return append(p, uint8(sum), uint8(sum>>8), 0x00, 0x00)
+}
+
+// send sends a packet to p and returns the alive and status replies.
+func send(t *testing.T, p *printer.Printer, pkt []byte) (uint8, uint8) {
+	t.Helper()
+	var replies []uint8
+	for _, b := range pkt {
+		replies = append(replies, p.Exchange(b))
+	}
+	for i, r := range replies[:len(replies)-2] {
+		if r != 0 {
+			t.Fatalf("reply to byte %d of packet = %02X, want 00", i, r)
+		}
+	}
+	return replies[len(replies)-2], replies[len(replies)-1]
+}
+
+// stripes returns two rows of tiles whose pixels use colour x%4 in column x.
+func stripes() []byte {
+	var d []byte
+	for tile := 0; tile < 40; tile++ {
+		for row := 0; row < 8; row++ {
+			d = append(d, 0x55, 0x33) // 0 1 2 3 0 1 2 3, low bit first.
+		}
+	}
+	return d
+}
+
+func TestStatus(t *testing.T) {
+	p := printer.New()
+	if alive, status := send(t, p, packet(0x0F, false, nil)); alive != 0x81 || status != 0x00 {
+		t.Errorf("status packet replies = %02X %02X, want 81 00", alive, status)
+	}
+
+	bad := packet(0x0F, false, nil)
+	bad[6]++
+	if _, status := send(t, p, bad); status != 0x01 {
+		t.Errorf("status after a bad checksum = %02X, want 01", status)
+	}
+	if _, status := send(t, p, packet(0x0F, false, nil)); status != 0x00 {
+		t.Errorf("status after a good checksum = %02X, want 00", status)
+	}
+	if _, status := send(t, p, packet(0x07, false, nil)); status != 0x10 {
+		t.Errorf("status after an unknown command = %02X, want 10", status)
+	}
+}
+
+func TestPrint(t *testing.T) {
+	p := printer.New()
+	steps := []struct {
+		name   string
+		pkt    []byte
+		status uint8
+	}{
+		{"init", packet(0x01, false, nil), 0x00},
+		{"data", packet(0x04, false, stripes()), 0x08},
+		{"end of data", packet(0x04, false, nil), 0x08},
+		// One unit of margin before, two after, and the colours reversed.
+		{"print", packet(0x02, false, []byte{0x01, 0x12, 0x1B, 0x40}), 0x02},
+		{"busy", packet(0x0F, false, nil), 0x02},
+		{"done", packet(0x0F, false, nil), 0x00},
+	}
+	for _, s := range steps {
+		if _, status := send(t, p, s.pkt); status != s.status {
+			t.Errorf("%s: status = %02X, want %02X", s.name, status, s.status)
+		}
+	}
+
+	pages := p.Pages()
+	if len(pages) != 1 {
+		t.Fatalf("printed %d pages, want 1", len(pages))
+	}
+	page := pages[0]
+	if got, want := page.Bounds(), image.Rect(0, 0, 160, 8+16+16); got != want {
+		t.Fatalf("page bounds = %v, want %v", got, want)
+	}
+	for _, tc := range []struct {
+		x, y int
+		want uint8
+	}{
+		{0, 0, 0xFF},  // Top margin.
+		{0, 8, 0x00},  // Colour 0, printed as black.
+		{1, 8, 0x55},  // Colour 1.
+		{2, 15, 0xAA}, // Colour 2.
+		{159, 23, 0xFF},
+		{5, 24, 0xFF}, // Bottom margin.
+		{5, 39, 0xFF},
+	} {
+		if got := page.GrayAt(tc.x, tc.y).Y; got != tc.want {
+			t.Errorf("pixel (%d, %d) = %02X, want %02X", tc.x, tc.y, got, tc.want)
+		}
+	}
+}
+
+func TestCompressed(t *testing.T) {
+	// A run of 0x200 zero bytes, then 0x80 bytes copied as they are.
+	var compressed, raw []byte
+	for i := 0; i < 4; i++ {
+		compressed = append(compressed, 0xFE, 0x00)
+		raw = append(raw, make([]byte, 0x80)...)
+	}
+	compressed = append(compressed, 0x7F)
+	for i := 0; i < 0x80; i++ {
+		compressed = append(compressed, uint8(i))
+		raw = append(raw, uint8(i))
+	}
+
+	var pages [2]*image.Gray
+	for i, pkt := range [][]byte{packet(0x04, false, raw), packet(0x04, true, compressed)} {
+		p := printer.New()
+		send(t, p, pkt)
+		send(t, p, packet(0x02, false, []byte{0x01, 0x00, 0xE4, 0x40}))
+		pages[i] = p.Pages()[0]
+	}
+	if diff := cmp.Diff(pages[0].Pix, pages[1].Pix); diff != "" {
+		t.Errorf("compressed print mismatch (-raw,+compressed):\n%s", diff)
+	}
+}
+
+func TestOverSerial(t *testing.T) {
+	dir, err := ioutil.TempDir("", "printer")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.RemoveAll(dir) })
+
+	p := printer.New(printer.WithOutputDir(dir))
+	s := serial.New(interrupts.New())
+	s.Connect(p)
+	var pkts []byte
+	pkts = append(pkts, packet(0x04, false, stripes())...)
+	pkts = append(pkts, packet(0x02, false, []byte{0x01, 0x00, 0xE4, 0x40})...)
+	var replies []uint8
+	for _, b := range pkts {
+		s.Write8(serial.SB, b)
+		s.Write8(serial.SC, 0x81)
+		s.Tick(4096)
+		replies = append(replies, s.Read8(serial.SB))
+	}
+	if got := replies[len(replies)-2:]; got[0] != 0x81 || got[1] != 0x02 {
+		t.Errorf("print packet replies = % X, want 81 02", got)
+	}
+
+	if err := p.Err(); err != nil {
+		t.Fatalf("Err() = %v", err)
+	}
+	f, err := os.Open(filepath.Join(dir, "print001.png"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	img, err := png.Decode(f)
+	if err != nil {
+		t.Fatalf("decoding PNG: %v", err)
+	}
+	if got, want := img.Bounds(), image.Rect(0, 0, 160, 16); got != want {
+		t.Errorf("PNG bounds = %v, want %v", got, want)
+	}
+}
@@ -1,5 +1,7 @@
 package registers
 
+import "fmt"
+
 // Reg is an enum alias of the named registers
 type Reg uint8
 
@@ -17,9 +19,21 @@ type Registers struct {
 	PC uint16
 }
 
-// BC returns the B register cat'd to the C register as an int16.
+// AF returns the A register cat'd to the F register as a uint16.
+func (reg Registers) AF() uint16 {
+	return uint16(reg.A)<<8 | uint16(reg.F)
+}
+
+// SetAF sets the A register and the F register individually. The lower
+// nibble of F doesn't exist in hardware and always reads back as zero.
+func (reg *Registers) SetAF(val uint16) {
+	reg.A = Reg(val >> 8 & 0xFF)
+	reg.F = FlagRegister(val & 0xF0)
+}
+
+// BC returns the B register cat'd to the C register as a uint16.
 func (reg Registers) BC() uint16 {
-	return uint16(reg.B) << 8 & uint16(reg.C)
+	return uint16(reg.B)<<8 | uint16(reg.C)
 }
 
 // SetBC sets the B register and the C register individually as int8.
@@ -28,6 +42,38 @@ func (reg *Registers) SetBC(val uint16) {
 	reg.C = Reg(val & 0xFF)
 }
 
+// DE returns the D register cat'd to the E register as a uint16.
+func (reg Registers) DE() uint16 {
+	return uint16(reg.D)<<8 | uint16(reg.E)
+}
+
+// SetDE sets the D register and the E register individually.
+func (reg *Registers) SetDE(val uint16) {
+	reg.D = Reg(val >> 8 & 0xFF)
+	reg.E = Reg(val & 0xFF)
+}
+
+// HL returns the H register cat'd to the L register as a uint16.
+func (reg Registers) HL() uint16 {
+	return uint16(reg.H)<<8 | uint16(reg.L)
+}
+
+// SetHL sets the H register and the L register individually.
+func (reg *Registers) SetHL(val uint16) {
+	reg.H = Reg(val >> 8 & 0xFF)
+	reg.L = Reg(val & 0xFF)
+}
+
+// String dumps the registers on one line in the layout most emulators and
+// trace diffing tools use, e.g.
+//
+//	A:01 F:B0 B:00 C:13 D:00 E:D8 H:01 L:4D SP:FFFE PC:0100 (Z-HC)
+func (reg Registers) String() string {
+	return fmt.Sprintf("A:%02X F:%02X B:%02X C:%02X D:%02X E:%02X H:%02X L:%02X SP:%04X PC:%04X (%v)",
+		uint8(reg.A), uint8(reg.F), uint8(reg.B), uint8(reg.C), uint8(reg.D),
+		uint8(reg.E), uint8(reg.H), uint8(reg.L), reg.SP, reg.PC, reg.F)
+}
+
 // FlagRegister provides easier encapsulation for the Accumulator and Flag registers
 type FlagRegister uint8
 
@@ -37,16 +83,70 @@ type FlagRegisterFlag uint8
 // These are the accessors for the flags in the F flag register
 const (
 	FlagZf FlagRegisterFlag = 1 << 7 // Zero Flag
-	FlagN                   = 1 << 6 // Add/Sub Flag (BCD)
-	FlagH                   = 1 << 5 // Half Carry Flag
-	FlagCy                  = 1 << 4 // Carry Flag
+	FlagN  FlagRegisterFlag = 1 << 6 // Add/Sub Flag (BCD)
+	FlagH  FlagRegisterFlag = 1 << 5 // Half Carry Flag
+	FlagCy FlagRegisterFlag = 1 << 4 // Carry Flag
 )
 
-func hasBit(n uint8, pos uint8) bool {
-	val := n & (1 << pos)
-	return (val > 0)
+func (af FlagRegister) isFlagSet(which FlagRegisterFlag) bool {
+	return uint8(af)&uint8(which) != 0
 }
 
-func (af FlagRegister) isFlagSet(which FlagRegisterFlag) bool {
-	return hasBit(uint8(af), uint8(which))
+func (af *FlagRegister) setFlag(which FlagRegisterFlag, on bool) {
+	if on {
+		*af |= FlagRegister(which)
+	} else {
+		*af &^= FlagRegister(which)
+	}
+}
+
+// Z reports whether the zero flag is set.
+func (af FlagRegister) Z() bool {
+	return af.isFlagSet(FlagZf)
+}
+
+// N reports whether the subtract flag is set.
+func (af FlagRegister) N() bool {
+	return af.isFlagSet(FlagN)
+}
+
+// H reports whether the half carry flag is set.
+func (af FlagRegister) H() bool {
+	return af.isFlagSet(FlagH)
+}
+
+// C reports whether the carry flag is set.
+func (af FlagRegister) C() bool {
+	return af.isFlagSet(FlagCy)
+}
+
+// SetZ sets or clears the zero flag.
+func (af *FlagRegister) SetZ(on bool) {
+	af.setFlag(FlagZf, on)
+}
+
+// SetN sets or clears the subtract flag.
+func (af *FlagRegister) SetN(on bool) {
+	af.setFlag(FlagN, on)
+}
+
+// SetH sets or clears the half carry flag.
+func (af *FlagRegister) SetH(on bool) {
+	af.setFlag(FlagH, on)
+}
+
+// SetC sets or clears the carry flag.
+func (af *FlagRegister) SetC(on bool) {
+	af.setFlag(FlagCy, on)
+}
+
+// String prints the flags as ZNHC, with a dash in place of each clear flag.
+func (af FlagRegister) String() string {
+	b := []byte("----")
+	for i, f := range []FlagRegisterFlag{FlagZf, FlagN, FlagH, FlagCy} {
+		if af.isFlagSet(f) {
+			b[i] = "ZNHC"[i]
+		}
+	}
+	return string(b)
 }
@@ -0,0 +1,67 @@
+package registers_test
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/vsinha/vm/internal/registers"
+)
+
+func TestPairs(t *testing.T) {
+	tests := []struct {
+		name string
+		set  func(*registers.Registers, uint16)
+		get  func(registers.Registers) uint16
+		in   uint16
+		want registers.Registers
+		out  uint16 // What the getter should return after setting in.
+	}{
+		{"AF", (*registers.Registers).SetAF, registers.Registers.AF, 0x12FF, registers.Registers{A: 0x12, F: 0xF0}, 0x12F0},
+		{"BC", (*registers.Registers).SetBC, registers.Registers.BC, 0x1234, registers.Registers{B: 0x12, C: 0x34}, 0x1234},
+		{"DE", (*registers.Registers).SetDE, registers.Registers.DE, 0xABCD, registers.Registers{D: 0xAB, E: 0xCD}, 0xABCD},
+		{"HL", (*registers.Registers).SetHL, registers.Registers.HL, 0x00FF, registers.Registers{H: 0x00, L: 0xFF}, 0x00FF},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var r registers.Registers
+			test.set(&r, test.in)
+			if diff := cmp.Diff(test.want, r); diff != "" {
+				t.Errorf("Register difference (-want,+got): %v", diff)
+			}
+			if got := test.get(r); got != test.out {
+				t.Errorf("%s() = %04X, want %04X", test.name, got, test.out)
+			}
+		})
+	}
+}
+
+func TestFlags(t *testing.T) {
+	var f registers.FlagRegister
+	f.SetZ(true)
+	f.SetC(true)
+	if got, want := f, registers.FlagRegister(0x90); got != want {
+		t.Errorf("F = %02X, want %02X", uint8(got), uint8(want))
+	}
+	if !f.Z() || f.N() || f.H() || !f.C() {
+		t.Errorf("Z, N, H, C = %v, %v, %v, %v, want true, false, false, true", f.Z(), f.N(), f.H(), f.C())
+	}
+
+	f.SetZ(false)
+	f.SetH(true)
+	f.SetN(true)
+	if got, want := f, registers.FlagRegister(0x70); got != want {
+		t.Errorf("F = %02X, want %02X", uint8(got), uint8(want))
+	}
+}
+
+func TestString(t *testing.T) {
+	r := registers.Registers{
+		A: 0x01, F: 0xB0, B: 0x00, C: 0x13, D: 0x00, E: 0xD8, H: 0x01, L: 0x4D,
+		SP: 0xFFFE, PC: 0x0100,
+	}
+	want := "A:01 F:B0 B:00 C:13 D:00 E:D8 H:01 L:4D SP:FFFE PC:0100 (Z-HC)"
+	if got := r.String(); got != want {
+		t.Errorf("String() = %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,133 @@
+package serial
+
+import (
+	"io"
+	"net"
+	"sync"
+	"time"
+)
+
+// Pair connects a and b with a cable, as when linking two VMs in the same
+// process. The VMs may run on different goroutines.
+func Pair(a, b *Serial) {
+	a.Connect(wire{b})
+	b.Connect(wire{a})
+}
+
+// wire is a Link straight to the serial port at the other end.
+type wire struct {
+	other *Serial
+}
+
+func (w wire) Exchange(b uint8) uint8 {
+	return w.other.Receive(b)
+}
+
+// Messages sent over a TCP link are two bytes: one of these kinds, then the
+// byte being transferred.
+const (
+	msgTransfer = 0x01 // A byte clocked out by the sender.
+	msgReply    = 0x02 // The byte shifted out in return.
+)
+
+// ReplyTimeout is how long Exchange waits for the other end of a TCP link to
+// answer before treating it as disconnected.
+const ReplyTimeout = time.Second
+
+// TCPLink is a Link to a Game Boy in another process, over a network
+// connection.
+type TCPLink struct {
+	conn    net.Conn
+	s       *Serial
+	replies chan uint8
+
+	// mu serializes writes to conn, which are made both by Exchange and by
+	// the goroutine answering the other end's transfers.
+	mu sync.Mutex
+}
+
+// NewTCPLink returns a link that trades bytes for s over conn. It answers
+// transfers clocked by the other end until conn is closed. It doesn't connect
+// s to the link; use s.Connect for that.
+func NewTCPLink(conn net.Conn, s *Serial) *TCPLink {
+	l := &TCPLink{conn: conn, s: s, replies: make(chan uint8, 1)}
+	go l.serve()
+	return l
+}
+
+// Dial connects s to a Game Boy waiting for a link at addr, such as
+// "localhost:5432".
+func Dial(addr string, s *Serial) (*TCPLink, error) {
+	conn, err := net.Dial("tcp", addr)
+	if err != nil {
+		return nil, err
+	}
+	l := NewTCPLink(conn, s)
+	s.Connect(l)
+	return l, nil
+}
+
+// Accept waits for a Game Boy to dial ln and connects s to it.
+func Accept(ln net.Listener, s *Serial) (*TCPLink, error) {
+	conn, err := ln.Accept()
+	if err != nil {
+		return nil, err
+	}
+	l := NewTCPLink(conn, s)
+	s.Connect(l)
+	return l, nil
+}
+
+// Close disconnects the link and closes the connection.
+func (l *TCPLink) Close() error {
+	l.s.Connect(nil)
+	return l.conn.Close()
+}
+
+func (l *TCPLink) send(kind, b uint8) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	_, err := l.conn.Write([]byte{kind, b})
+	return err
+}
+
+// serve reads messages from the other end until the connection is closed.
+func (l *TCPLink) serve() {
+	var msg [2]byte
+	for {
+		if _, err := io.ReadFull(l.conn, msg[:]); err != nil {
+			return
+		}
+		switch msg[0] {
+		case msgTransfer:
+			if l.send(msgReply, l.s.Receive(msg[1])) != nil {
+				return
+			}
+		case msgReply:
+			select {
+			case l.replies <- msg[1]:
+			default:
+				// Nobody is waiting: the reply came too late.
+			}
+		}
+	}
+}
+
+// Exchange implements Link. It blocks until the other end replies, for up to
+// ReplyTimeout.
+func (l *TCPLink) Exchange(b uint8) uint8 {
+	// Drop any reply that came after an earlier Exchange gave up.
+	select {
+	case <-l.replies:
+	default:
+	}
+	if l.send(msgTransfer, b) != nil {
+		return 0xFF
+	}
+	select {
+	case in := <-l.replies:
+		return in
+	case <-time.After(ReplyTimeout):
+		return 0xFF
+	}
+}
@@ -0,0 +1,180 @@
+// Package serial implements the Game Boy's serial port and the link cables
+// that connect it to another Game Boy.
+package serial
+
+import (
+	"sync"
+
+	"github.com/vsinha/vm/internal/interrupts"
+)
+
+// Register addresses.
+const (
+	SB = 0xFF01 // Serial transfer data.
+	SC = 0xFF02 // Serial transfer control.
+)
+
+// SC bits.
+const (
+	scStart         = 0x80 // Set to start a transfer, cleared when it ends.
+	scFast          = 0x02 // CGB only: clock at 262144 Hz instead of 8192 Hz.
+	scInternalClock = 0x01 // Set when this end drives the clock.
+)
+
+// Cycles taken to shift each bit out with the internal clock.
+const (
+	bitCycles     = 512
+	fastBitCycles = 16
+)
+
+// Link is a link cable to another Game Boy.
+type Link interface {
+	// Exchange is called by the end driving the clock once it has shifted a
+	// whole byte out. It hands the byte to the other end and returns the byte
+	// the other end shifted out in return. When nothing is listening at the
+	// other end, it returns 0xFF, as the line is pulled high.
+	Exchange(b uint8) uint8
+}
+
+// Disconnected is a Link with nothing plugged in.
+type Disconnected struct{}
+
+// Exchange implements Link.
+func (Disconnected) Exchange(uint8) uint8 {
+	return 0xFF
+}
+
+// Serial is the serial port. It implements memory.Device and should be
+// attached to SB through SC.
+//
+// Transfers are modelled a byte at a time rather than bit by bit: the whole
+// byte is exchanged over the Link when the transfer ends.
+type Serial struct {
+	ic   *interrupts.Controller
+	cgb  bool
+	link Link
+
+	// mu guards the registers, which links may deliver transfers to from
+	// other goroutines.
+	mu     sync.Mutex
+	sb, sc uint8
+	// cycles counts down to the end of a transfer with the internal clock.
+	cycles uint
+	// received is set when a transfer clocked by the other end has finished,
+	// so the interrupt is requested by the next Tick.
+	received bool
+}
+
+// Option changes how New sets up a serial port.
+type Option func(*Serial)
+
+// WithCGB enables the Game Boy Color's fast clock.
+func WithCGB() Option {
+	return func(s *Serial) {
+		s.cgb = true
+	}
+}
+
+// New returns a serial port with no cable plugged in, that requests its
+// interrupt from ic.
+func New(ic *interrupts.Controller, opts ...Option) *Serial {
+	s := &Serial{ic: ic, link: Disconnected{}}
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s
+}
+
+// Connect plugs a cable into the port. A nil Link unplugs it.
+func (s *Serial) Connect(l Link) {
+	if l == nil {
+		l = Disconnected{}
+	}
+	s.mu.Lock()
+	s.link = l
+	s.mu.Unlock()
+}
+
+// Read8 implements memory.Device.
+func (s *Serial) Read8(addr uint16) uint8 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if addr == SB {
+		return s.sb
+	}
+	if s.cgb {
+		return s.sc | 0x7C
+	}
+	return s.sc | 0x7E
+}
+
+// Write8 implements memory.Device.
+func (s *Serial) Write8(addr uint16, val uint8) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if addr == SB {
+		s.sb = val
+		return
+	}
+
+	s.sc = val & (scStart | scFast | scInternalClock)
+	if !s.cgb {
+		s.sc &^= scFast
+	}
+	s.cycles = 0
+	if s.sc&(scStart|scInternalClock) == scStart|scInternalClock {
+		s.cycles = 8 * bitCycles
+		if s.sc&scFast != 0 {
+			s.cycles = 8 * fastBitCycles
+		}
+	}
+}
+
+// Tick advances transfers driven by the internal clock by the given number of
+// cycles.
+func (s *Serial) Tick(cycles uint) {
+	s.mu.Lock()
+	if s.received {
+		s.received = false
+		s.ic.Request(interrupts.Serial)
+	}
+	if s.cycles == 0 {
+		s.mu.Unlock()
+		return
+	}
+	if cycles < s.cycles {
+		s.cycles -= cycles
+		s.mu.Unlock()
+		return
+	}
+	s.cycles = 0
+	out, link := s.sb, s.link
+	// The other end may answer from another goroutine, which can be sending
+	// to us at the same time.
+	s.mu.Unlock()
+
+	in := link.Exchange(out)
+
+	s.mu.Lock()
+	s.sb = in
+	s.sc &^= scStart
+	s.mu.Unlock()
+	s.ic.Request(interrupts.Serial)
+}
+
+// Receive is called by a Link when the other end, driving the clock, has
+// shifted b out. If a transfer is waiting for an external clock, b replaces
+// SB, the transfer ends and the old SB is returned. Otherwise nothing is
+// shifted and Receive returns 0xFF. It's safe to call from any goroutine.
+func (s *Serial) Receive(b uint8) uint8 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.sc&(scStart|scInternalClock) != scStart {
+		return 0xFF
+	}
+	out := s.sb
+	s.sb = b
+	s.sc &^= scStart
+	s.received = true
+	return out
+}
@@ -0,0 +1,153 @@
+package serial_test
+
+import (
+	"net"
+	"testing"
+
+	"github.com/vsinha/vm/internal/interrupts"
+	"github.com/vsinha/vm/internal/serial"
+)
+
+// port is a serial port with its own interrupt controller.
+type port struct {
+	*serial.Serial
+	ic *interrupts.Controller
+}
+
+func newPort(opts ...serial.Option) port {
+	ic := interrupts.New()
+	return port{serial.New(ic, opts...), ic}
+}
+
+// interrupted reports whether the serial interrupt was requested, and clears
+// it.
+func (p port) interrupted() bool {
+	defer p.ic.Write8(interrupts.FlagAddr, 0)
+	return p.ic.Read8(interrupts.FlagAddr)&uint8(interrupts.Serial) != 0
+}
+
+func TestDisconnected(t *testing.T) {
+	tests := []struct {
+		name   string
+		opts   []serial.Option
+		sc     uint8
+		cycles uint
+	}{
+		{"8192 Hz", nil, 0x81, 4096},
+		{"fast clock ignored on DMG", nil, 0x83, 4096},
+		{"fast clock", []serial.Option{serial.WithCGB()}, 0x83, 128},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			p := newPort(tc.opts...)
+			p.Write8(serial.SB, 0x42)
+			p.Write8(serial.SC, tc.sc)
+
+			p.Tick(tc.cycles - 1)
+			if p.interrupted() || p.Read8(serial.SB) != 0x42 {
+				t.Fatalf("transfer ended after %d cycles", tc.cycles-1)
+			}
+			p.Tick(1)
+			if !p.interrupted() {
+				t.Errorf("no interrupt after %d cycles", tc.cycles)
+			}
+			if got := p.Read8(serial.SB); got != 0xFF {
+				t.Errorf("SB = %02X with no cable, want FF", got)
+			}
+			if got := p.Read8(serial.SC); got&0x80 != 0 {
+				t.Errorf("SC = %02X, want the transfer bit clear", got)
+			}
+		})
+	}
+}
+
+func TestExternalClockWaits(t *testing.T) {
+	p := newPort()
+	p.Write8(serial.SB, 0x42)
+	p.Write8(serial.SC, 0x80)
+	p.Tick(1 << 20)
+	if p.interrupted() || p.Read8(serial.SB) != 0x42 || p.Read8(serial.SC) != 0xFE {
+		t.Errorf("transfer with an external clock and no cable ended")
+	}
+}
+
+func TestPair(t *testing.T) {
+	master, slave := newPort(), newPort()
+	serial.Pair(master.Serial, slave.Serial)
+
+	// The slave isn't ready, so nothing is shifted in.
+	master.Write8(serial.SB, 0x12)
+	master.Write8(serial.SC, 0x81)
+	master.Tick(4096)
+	if got := master.Read8(serial.SB); got != 0xFF {
+		t.Errorf("master SB = %02X with the slave not ready, want FF", got)
+	}
+	master.interrupted()
+
+	slave.Write8(serial.SB, 0x34)
+	slave.Write8(serial.SC, 0x80)
+	master.Write8(serial.SB, 0x12)
+	master.Write8(serial.SC, 0x81)
+	master.Tick(4096)
+	slave.Tick(4)
+	for _, tc := range []struct {
+		name string
+		p    port
+		want uint8
+	}{
+		{"master", master, 0x34},
+		{"slave", slave, 0x12},
+	} {
+		if got := tc.p.Read8(serial.SB); got != tc.want {
+			t.Errorf("%s SB = %02X, want %02X", tc.name, got, tc.want)
+		}
+		if got := tc.p.Read8(serial.SC); got&0x80 != 0 {
+			t.Errorf("%s SC = %02X, want the transfer bit clear", tc.name, got)
+		}
+		if !tc.p.interrupted() {
+			t.Errorf("%s: no serial interrupt", tc.name)
+		}
+	}
+}
+
+func TestTCPLink(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ln.Close()
+
+	master, slave := newPort(), newPort()
+	accepted := make(chan error)
+	go func() {
+		l, err := serial.Accept(ln, slave.Serial)
+		if err == nil {
+			t.Cleanup(func() { l.Close() })
+		}
+		accepted <- err
+	}()
+	l, err := serial.Dial(ln.Addr().String(), master.Serial)
+	if err != nil {
+		t.Fatalf("Dial() error: %v", err)
+	}
+	defer l.Close()
+	if err := <-accepted; err != nil {
+		t.Fatalf("Accept() error: %v", err)
+	}
+
+	slave.Write8(serial.SB, 0x34)
+	slave.Write8(serial.SC, 0x80)
+	master.Write8(serial.SB, 0x12)
+	master.Write8(serial.SC, 0x81)
+	master.Tick(4096)
+	slave.Tick(4)
+	if got := master.Read8(serial.SB); got != 0x34 {
+		t.Errorf("master SB = %02X, want 34", got)
+	}
+	if got := slave.Read8(serial.SB); got != 0x12 {
+		t.Errorf("slave SB = %02X, want 12", got)
+	}
+	if !slave.interrupted() {
+		t.Errorf("slave: no serial interrupt")
+	}
+}
@@ -0,0 +1,139 @@
+// Package timer implements the Game Boy's divider and timer registers.
+package timer
+
+import "github.com/vsinha/vm/internal/interrupts"
+
+// Register addresses.
+const (
+	DIV  = 0xFF04 // Upper 8 bits of the internal divider.
+	TIMA = 0xFF05 // Timer counter.
+	TMA  = 0xFF06 // Timer modulo, reloaded into TIMA when it overflows.
+	TAC  = 0xFF07 // Timer control.
+)
+
+// tacEnable is the bit of TAC that turns TIMA on.
+const tacEnable = 0x04
+
+// tacBits maps the clock select bits of TAC to the divider bit whose falling
+// edge increments TIMA: 4096, 262144, 65536 and 16384 Hz.
+var tacBits = [4]uint16{1 << 9, 1 << 3, 1 << 5, 1 << 7}
+
+// Timer is the divider and timer. It implements memory.Device and should be
+// attached to DIV through TAC.
+//
+// TIMA doesn't count cycles itself. It increments whenever the divider bit
+// selected by TAC, ANDed with the enable bit, goes from 1 to 0. That's why
+// writing DIV or TAC can increment TIMA too.
+type Timer struct {
+	ic *interrupts.Controller
+
+	div            uint16
+	tima, tma, tac uint8
+
+	// overflow is set when TIMA overflows. TIMA reads 0x00 for one M-cycle
+	// before TMA is loaded into it and the interrupt requested.
+	overflow bool
+	// reloaded is set for the M-cycle in which TIMA was reloaded. Writes to
+	// TIMA in that cycle are ignored, and writes to TMA go to TIMA as well.
+	reloaded bool
+
+	// cycles holds cycles passed to Tick that don't make up a whole M-cycle.
+	cycles uint
+}
+
+// New returns a timer that requests its interrupt from ic.
+func New(ic *interrupts.Controller) *Timer {
+	return &Timer{ic: ic}
+}
+
+// Read8 implements memory.Device.
+func (t *Timer) Read8(addr uint16) uint8 {
+	switch addr {
+	case DIV:
+		return uint8(t.div >> 8)
+	case TIMA:
+		return t.tima
+	case TMA:
+		return t.tma
+	default:
+		return t.tac | 0xF8
+	}
+}
+
+// Write8 implements memory.Device.
+func (t *Timer) Write8(addr uint16, val uint8) {
+	switch addr {
+	case DIV:
+		t.ResetDivider()
+	case TIMA:
+		if t.reloaded {
+			return
+		}
+		t.tima = val
+		// Writing TIMA in the cycle after it overflowed cancels the reload.
+		t.overflow = false
+	case TMA:
+		t.tma = val
+		if t.reloaded {
+			t.tima = val
+		}
+	default:
+		old := t.signal()
+		t.tac = val & 0x07
+		if old && !t.signal() {
+			t.increment()
+		}
+	}
+}
+
+// ResetDivider sets the divider to 0, as writing DIV or running STOP does. If
+// that makes the selected divider bit fall, TIMA increments.
+func (t *Timer) ResetDivider() {
+	t.setDiv(0)
+}
+
+// SetDivider sets the whole 16-bit divider, as the boot ROM leaves it, without
+// incrementing TIMA.
+func (t *Timer) SetDivider(div uint16) {
+	t.div = div
+}
+
+// Tick advances the timer by the given number of CPU cycles.
+func (t *Timer) Tick(cycles uint) {
+	t.cycles += cycles
+	for ; t.cycles >= 4; t.cycles -= 4 {
+		t.step()
+	}
+}
+
+// step advances the timer by one M-cycle.
+func (t *Timer) step() {
+	t.reloaded = false
+	if t.overflow {
+		t.overflow = false
+		t.tima = t.tma
+		t.ic.Request(interrupts.Timer)
+		t.reloaded = true
+	}
+	t.setDiv(t.div + 4)
+}
+
+func (t *Timer) setDiv(div uint16) {
+	old := t.signal()
+	t.div = div
+	if old && !t.signal() {
+		t.increment()
+	}
+}
+
+// signal is the input to the falling edge detector in front of TIMA.
+func (t *Timer) signal() bool {
+	return t.tac&tacEnable != 0 && t.div&tacBits[t.tac&0x03] != 0
+}
+
+func (t *Timer) increment() {
+	t.tima++
+	if t.tima == 0 {
+		t.overflow = true
+	}
+}
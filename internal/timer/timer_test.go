@@ -0,0 +1,114 @@
+package timer_test
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/vsinha/vm/internal/interrupts"
+	"github.com/vsinha/vm/internal/timer"
+)
+
+// state is what the CPU can see of the timer.
+type state struct {
+	DIV, TIMA uint8
+	Interrupt bool
+}
+
+func TestTimer(t *testing.T) {
+	// write returns a step that writes val to addr.
+	write := func(addr uint16, val uint8) func(*timer.Timer) {
+		return func(tm *timer.Timer) { tm.Write8(addr, val) }
+	}
+	// tick returns a step that ticks the timer.
+	tick := func(cycles uint) func(*timer.Timer) {
+		return func(tm *timer.Timer) { tm.Tick(cycles) }
+	}
+
+	tests := []struct {
+		name  string
+		steps []func(*timer.Timer)
+		want  state
+	}{
+		{"DIV counts at 16384 Hz", []func(*timer.Timer){tick(256 * 3)},
+			state{DIV: 3}},
+		{"partial M-cycles add up", []func(*timer.Timer){tick(254), tick(1), tick(1)},
+			state{DIV: 1}},
+		{"writing DIV resets it", []func(*timer.Timer){tick(256 * 3), write(timer.DIV, 0x42), tick(255)},
+			state{DIV: 0}},
+		{"TIMA disabled", []func(*timer.Timer){write(timer.TAC, 0x01), tick(1024)},
+			state{DIV: 4}},
+		{"TIMA at 4096 Hz", []func(*timer.Timer){write(timer.TAC, 0x04), tick(1024 * 2)},
+			state{DIV: 8, TIMA: 2}},
+		{"TIMA at 262144 Hz", []func(*timer.Timer){write(timer.TAC, 0x05), tick(16 * 10)},
+			state{TIMA: 10}},
+		{"TIMA at 65536 Hz", []func(*timer.Timer){write(timer.TAC, 0x06), tick(64 * 3)},
+			state{TIMA: 3}},
+		{"TIMA at 16384 Hz", []func(*timer.Timer){write(timer.TAC, 0x07), tick(256 * 2)},
+			state{DIV: 2, TIMA: 2}},
+		{"overflow reads 0 for a cycle", []func(*timer.Timer){
+			write(timer.TMA, 0x42), write(timer.TIMA, 0xFF), write(timer.TAC, 0x05), tick(16)},
+			state{TIMA: 0x00}},
+		{"overflow reloads TMA and interrupts", []func(*timer.Timer){
+			write(timer.TMA, 0x42), write(timer.TIMA, 0xFF), write(timer.TAC, 0x05), tick(16 + 4)},
+			state{TIMA: 0x42, Interrupt: true}},
+		{"writing TIMA cancels the reload", []func(*timer.Timer){
+			write(timer.TMA, 0x42), write(timer.TIMA, 0xFF), write(timer.TAC, 0x05), tick(16),
+			write(timer.TIMA, 0x10), tick(4)},
+			state{TIMA: 0x10}},
+		{"writing TIMA while reloading is ignored", []func(*timer.Timer){
+			write(timer.TMA, 0x42), write(timer.TIMA, 0xFF), write(timer.TAC, 0x05), tick(16 + 4),
+			write(timer.TIMA, 0x10)},
+			state{TIMA: 0x42, Interrupt: true}},
+		{"writing TMA while reloading goes to TIMA", []func(*timer.Timer){
+			write(timer.TMA, 0x42), write(timer.TIMA, 0xFF), write(timer.TAC, 0x05), tick(16 + 4),
+			write(timer.TMA, 0x10)},
+			state{TIMA: 0x10, Interrupt: true}},
+		{"writing DIV with the bit set increments TIMA", []func(*timer.Timer){
+			write(timer.TAC, 0x05), tick(8), write(timer.DIV, 0x00)},
+			state{TIMA: 1}},
+		{"writing DIV with the bit clear doesn't", []func(*timer.Timer){
+			write(timer.TAC, 0x05), tick(4), write(timer.DIV, 0x00)},
+			state{TIMA: 0}},
+		{"disabling TIMA with the bit set increments it", []func(*timer.Timer){
+			write(timer.TAC, 0x05), tick(8), write(timer.TAC, 0x01)},
+			state{TIMA: 1}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			ic := interrupts.New()
+			ic.Write8(interrupts.EnableAddr, 0xFF)
+			tm := timer.New(ic)
+			for _, step := range test.steps {
+				step(tm)
+			}
+
+			_, pending := ic.Pending()
+			got := state{
+				DIV:       tm.Read8(timer.DIV),
+				TIMA:      tm.Read8(timer.TIMA),
+				Interrupt: pending,
+			}
+			if diff := cmp.Diff(test.want, got); diff != "" {
+				t.Errorf("timer state differs (-want,+got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestRegisters(t *testing.T) {
+	tm := timer.New(interrupts.New())
+	tm.Write8(timer.TMA, 0x42)
+	tm.Write8(timer.TAC, 0xFF)
+
+	if got := tm.Read8(timer.TMA); got != 0x42 {
+		t.Errorf("TMA = %02X, want 42", got)
+	}
+	if got := tm.Read8(timer.TAC); got != 0xFF {
+		t.Errorf("TAC = %02X after writing FF, want FF", got)
+	}
+	tm.Write8(timer.TAC, 0x00)
+	if got := tm.Read8(timer.TAC); got != 0xF8 {
+		t.Errorf("TAC = %02X after writing 00, want F8", got)
+	}
+}
@@ -0,0 +1,225 @@
+package vm
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+
+	"github.com/vsinha/vm/internal/apu"
+	"github.com/vsinha/vm/internal/cartridge"
+	"github.com/vsinha/vm/internal/interrupts"
+	"github.com/vsinha/vm/internal/joypad"
+	"github.com/vsinha/vm/internal/memory"
+	"github.com/vsinha/vm/internal/model"
+	"github.com/vsinha/vm/internal/ppu"
+	"github.com/vsinha/vm/internal/serial"
+)
+
+// Boot ROM sizes. The CGB boot ROM skips 0x0100-0x01FF, where the cartridge
+// header shows through.
+const (
+	DMGBootROMSize = 0x100
+	CGBBootROMSize = 0x900
+)
+
+// bootOff is the register that unmaps the boot ROM when written.
+const bootOff = 0xFF50
+
+// ErrBootROMSize is returned by LoadBootROM for files that aren't the size of
+// a DMG or CGB boot ROM.
+var ErrBootROMSize = errors.New("boot ROM is neither 256 nor 2304 bytes")
+
+// LoadBootROM reads a DMG or CGB boot ROM image from path.
+func LoadBootROM(path string) ([]byte, error) {
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	if len(b) != DMGBootROMSize && len(b) != CGBBootROMSize {
+		return nil, fmt.Errorf("loading %q: %d bytes: %w", path, len(b), ErrBootROMSize)
+	}
+	return b, nil
+}
+
+// bootROM overlays the boot ROM on the cartridge until bootOff is written. It
+// is attached to the ranges it covers and to bootOff.
+type bootROM struct {
+	rom    []byte
+	cart   memory.Device
+	mmu    *memory.MMU
+	mapped bool
+}
+
+func (b *bootROM) attach() {
+	b.mapped = true
+	b.mmu.Attach(0x0000, 0x00FF, b)
+	if len(b.rom) > 0x200 {
+		b.mmu.Attach(0x0200, uint16(len(b.rom)-1), b)
+	}
+	b.mmu.Attach(bootOff, bootOff, b)
+}
+
+// Read8 implements memory.Device.
+func (b *bootROM) Read8(addr uint16) uint8 {
+	if addr == bootOff || int(addr) >= len(b.rom) {
+		return 0xFF
+	}
+	return b.rom[addr]
+}
+
+// Write8 implements memory.Device. Writes to the ROM region still reach the
+// cartridge's mapper.
+func (b *bootROM) Write8(addr uint16, val uint8) {
+	if addr != bootOff {
+		if b.cart != nil {
+			b.cart.Write8(addr, val)
+		}
+		return
+	}
+	if !b.mapped || val == 0 {
+		return
+	}
+	b.mapped = false
+	// Until then, nothing but the boot ROM can own its ranges, so handing
+	// them back to the cartridge, or the empty slot without one, restores
+	// the map.
+	b.handBack(0x0000, 0x00FF)
+	if len(b.rom) > 0x200 {
+		b.handBack(0x0200, uint16(len(b.rom)-1))
+	}
+}
+
+func (b *bootROM) handBack(lo, hi uint16) {
+	if b.cart == nil {
+		b.mmu.Detach(lo, hi)
+		return
+	}
+	b.mmu.Attach(lo, hi, b.cart)
+}
+
+// cpuState is the CPU registers, and the divider, the boot ROM leaves behind.
+type cpuState struct {
+	a, f, b, c, d, e, h, l uint8
+	div                    uint16
+}
+
+// postBootState returns the registers left by m's boot ROM on cartridge c.
+// cgb is set when the game runs in CGB mode. Where the divider isn't known, it
+// is left at 0.
+func postBootState(m model.Model, c *cartridge.Cartridge, cgb bool) cpuState {
+	// The DMG and MGB boot ROMs finish by checking the header checksum, which
+	// leaves H and C set unless the checksum is 0.
+	dmgFlags := uint8(0xB0)
+	if c.HeaderChecksum == 0 {
+		dmgFlags = 0x80
+	}
+
+	switch m {
+	case model.DMG0:
+		return cpuState{0x01, 0x00, 0xFF, 0x13, 0x00, 0xC1, 0x84, 0x03, 0x1800}
+	case model.DMG:
+		return cpuState{0x01, dmgFlags, 0x00, 0x13, 0x00, 0xD8, 0x01, 0x4D, 0xABCC}
+	case model.MGB:
+		return cpuState{0xFF, dmgFlags, 0x00, 0x13, 0x00, 0xD8, 0x01, 0x4D, 0xABCC}
+	case model.SGB:
+		return cpuState{0x01, 0x00, 0x00, 0x14, 0x00, 0x00, 0xC0, 0x60, 0}
+	}
+
+	s := cpuState{a: 0x11, f: 0x80, d: 0xFF, e: 0x56, l: 0x0D}
+	if !cgb {
+		// Running a DMG game, the boot ROM picks a palette from a checksum
+		// of the title of Nintendo's games, which it leaves in B.
+		s.d, s.e, s.h, s.l = 0x00, 0x08, 0x00, 0x7C
+		if c.Licensee == "01" {
+			for addr := uint16(0x0134); addr <= 0x0143; addr++ {
+				s.b += c.Read8(addr)
+			}
+		}
+		if s.b == 0x43 || s.b == 0x58 {
+			s.h, s.l = 0x99, 0x1A
+		}
+	}
+	if m == model.AGB {
+		// The AGB boot ROM ends with an extra INC B.
+		s.b++
+		s.f = 0x00
+		if s.b == 0 {
+			s.f |= 0x80
+		}
+		if s.b&0x0F == 0 {
+			s.f |= 0x20
+		}
+	}
+	return s
+}
+
+// postBoot puts the VM in the state the boot ROM for v.model would have left
+// it in, ready to run cartridge c from its entry point.
+func (v *VM) postBoot(c *cartridge.Cartridge) {
+	s := postBootState(v.model, c, v.cgb)
+	v.r.SetAF(uint16(s.a)<<8 | uint16(s.f))
+	v.r.SetBC(uint16(s.b)<<8 | uint16(s.c))
+	v.r.SetDE(uint16(s.d)<<8 | uint16(s.e))
+	v.r.SetHL(uint16(s.h)<<8 | uint16(s.l))
+	v.r.SP = 0xFFFE
+	v.r.PC = 0x0100
+	v.timer.SetDivider(s.div)
+
+	writes := []struct {
+		addr uint16
+		val  uint8
+	}{
+		{joypad.P1, 0x00},
+		{interrupts.FlagAddr, uint8(interrupts.VBlank)},
+		{ppu.LCDC, 0x91},
+		{ppu.BGP, 0xFC},
+		// The APU is left on after playing the boot sound. Channel 1 is
+		// triggered silently, then given the envelope the sound ended with.
+		{apu.NR52, 0x80},
+		{apu.NR10, 0x80},
+		{apu.NR11, 0x80},
+		{apu.NR12, 0x08},
+		{apu.NR50, 0x77},
+		{apu.NR51, 0xF3},
+	}
+	for _, w := range writes {
+		v.mmu.Write8(w.addr, w.val)
+	}
+	if v.model != model.SGB {
+		// The SGB boot ROM doesn't play the sound, so channel 1 was never
+		// turned on.
+		v.mmu.Write8(apu.NR13, 0xC1)
+		v.mmu.Write8(apu.NR14, 0x87)
+	}
+	v.mmu.Write8(apu.NR12, 0xF3)
+
+	if v.model.CGB() {
+		// The internal clock and fast clock bits are left set.
+		v.mmu.Write8(serial.SC, 0x03)
+	}
+	switch {
+	case v.cgb:
+		// Every background colour starts out white.
+		v.writePalettes(ppu.BCPS, [4]uint16{0x7FFF, 0x7FFF, 0x7FFF, 0x7FFF}, 8)
+	case v.model.CGB():
+		// In compatibility mode the boot ROM colours the DMG shades, with
+		// palettes it picks by the title of Nintendo's games. That choice
+		// isn't modelled: every game gets the DMG's greys.
+		greys := [4]uint16{0x7FFF, 0x56B5, 0x294A, 0x0000}
+		v.writePalettes(ppu.BCPS, greys, 1)
+		v.writePalettes(ppu.OCPS, greys, 2)
+	}
+}
+
+// writePalettes writes colours to the first n palettes through the palette
+// index register at indexReg and the data register after it.
+func (v *VM) writePalettes(indexReg uint16, colors [4]uint16, n int) {
+	v.mmu.Write8(indexReg, 0x80)
+	for i := 0; i < n; i++ {
+		for _, c := range colors {
+			v.mmu.Write8(indexReg+1, uint8(c))
+			v.mmu.Write8(indexReg+1, uint8(c>>8))
+		}
+	}
+	v.mmu.Write8(indexReg, 0x00)
+}
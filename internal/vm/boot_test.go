@@ -0,0 +1,223 @@
+package vm_test
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/vsinha/vm/internal/apu"
+	"github.com/vsinha/vm/internal/cartridge"
+	"github.com/vsinha/vm/internal/interrupts"
+	"github.com/vsinha/vm/internal/joypad"
+	"github.com/vsinha/vm/internal/memory"
+	"github.com/vsinha/vm/internal/model"
+	"github.com/vsinha/vm/internal/opcodes"
+	"github.com/vsinha/vm/internal/ppu"
+	"github.com/vsinha/vm/internal/serial"
+	"github.com/vsinha/vm/internal/timer"
+	"github.com/vsinha/vm/internal/vm"
+)
+
+// cart returns a 32 KiB ROM-only cartridge licensed to Nintendo, with the
+// given title and CGB flag. edit, if not nil, is run over the ROM before the
+// checksums are computed.
+func cart(t *testing.T, title string, flag cartridge.CGBFlag, edit func(rom []byte)) *cartridge.Cartridge {
+	t.Helper()
+	rom := make([]byte, 0x8000)
+	copy(rom[0x0100:], []byte{0x18, 0xFE}) // JR -2
+	copy(rom[0x0134:0x0143], title)
+	rom[0x0143] = byte(flag)
+	copy(rom[0x0144:], "01")
+	rom[0x014B] = 0x33
+	if edit != nil {
+		edit(rom)
+	}
+
+	var x uint8
+	for _, b := range rom[0x0134:0x014D] {
+		x = x - b - 1
+	}
+	rom[0x014D] = x
+	var sum uint16
+	for _, b := range rom {
+		sum += uint16(b)
+	}
+	rom[0x014E], rom[0x014F] = byte(sum>>8), byte(sum)
+
+	c, err := cartridge.New(rom)
+	if err != nil {
+		t.Fatalf("cartridge.New() error: %v", err)
+	}
+	return c
+}
+
+func TestBootROM(t *testing.T) {
+	for _, size := range []int{vm.DMGBootROMSize, vm.CGBBootROMSize} {
+		boot := make([]byte, size)
+		for i := range boot {
+			boot[i] = 0xB0
+		}
+		c := cart(t, "BOOT", cartridge.CGBNone, func(rom []byte) {
+			for _, addr := range []int{0x0000, 0x00FF, 0x0200, 0x08FF} {
+				rom[addr] = 0xCA
+			}
+		})
+		v := vm.New(c, vm.WithBootROM(boot))
+		if v.Reg().PC != 0x0000 {
+			t.Errorf("%d byte boot ROM: PC = %04X, want 0000", size, v.Reg().PC)
+		}
+
+		overlaid := func(addr uint16) bool {
+			return addr <= 0x00FF || size == vm.CGBBootROMSize && addr >= 0x0200 && addr <= 0x08FF
+		}
+		for _, addr := range []uint16{0x0000, 0x00FF, 0x0134, 0x0200, 0x08FF} {
+			want := c.Read8(addr)
+			if overlaid(addr) {
+				want = 0xB0
+			}
+			if got := v.Mem().Read8(addr); got != want {
+				t.Errorf("%d byte boot ROM: %04X = %02X, want %02X", size, addr, got, want)
+			}
+		}
+
+		v.Mem().Write8(0xFF50, 0x01)
+		for _, addr := range []uint16{0x0000, 0x00FF, 0x0200, 0x08FF} {
+			if got := v.Mem().Read8(addr); got != 0xCA {
+				t.Errorf("%d byte boot ROM: %04X = %02X after unmapping, want CA", size, addr, got)
+			}
+		}
+	}
+}
+
+func TestBootROMWithoutCartridge(t *testing.T) {
+	for _, tc := range []struct {
+		name string
+		rom  memory.Device
+	}{
+		{"nil", nil},
+		{"empty Memory", memory.Memory{}},
+	} {
+		v := vm.New(tc.rom, vm.WithBootROM(make([]byte, vm.DMGBootROMSize)))
+		v.Mem().Write8(0xFF50, 0x01)
+		for _, addr := range []uint16{0x0000, 0x00FF, 0x0100} {
+			if got := v.Mem().Read8(addr); got != 0xFF {
+				t.Errorf("%s: %04X = %02X after unmapping the boot ROM, want FF", tc.name, addr, got)
+			}
+		}
+	}
+}
+
+func TestBootROMRunsToCartridge(t *testing.T) {
+	boot := make([]byte, vm.DMGBootROMSize)
+	copy(boot, []byte{
+		0x31, 0xFC, 0x00, // LD SP, 00FC
+		0xC1,       // POP BC
+		0xC6, 0x01, // ADD A, 01
+		0x20, 0xFC, // JR NZ, -4
+		0xCB, 0x00, // RLC B
+		0xCB, 0x40, // BIT 0, B
+		0x28, 0xF6, // JR Z, -10
+		0xCB, 0x01, // RLC C
+		0xCB, 0x41, // BIT 0, C
+		0x28, 0xF0, // JR Z, -16
+		0x31, 0x52, 0xFF, // LD SP, FF52
+		0xC3, 0xFF, 0x00, // JP 00FF
+	})
+	boot[0xFC], boot[0xFD] = 0x01, 0x01 // Popped into BC.
+	// Pushing BC writes 01 to FF50, unmapping the boot ROM as the PC moves
+	// on to the cartridge's entry point.
+	boot[0xFF] = 0xC5 // PUSH BC
+
+	c := cart(t, "BOOT", cartridge.CGBNone, func(rom []byte) {
+		rom[0x0100] = 0x76 // HALT
+	})
+	v := vm.New(c, vm.WithBootROM(boot), vm.WithTerminateOnHalt())
+	if err := v.Run(nil); err != opcodes.ErrHalt {
+		t.Fatalf("Run() error = %v, want %v", err, opcodes.ErrHalt)
+	}
+	if v.Reg().PC != 0x0100 {
+		t.Errorf("PC = %04X, want 0100", v.Reg().PC)
+	}
+	if got, want := v.Mem().Read8(0x0000), c.Read8(0x0000); got != want {
+		t.Errorf("0000 = %02X after booting, want the cartridge's %02X", got, want)
+	}
+	// Like the real boot ROMs, the loops run tens of thousands of
+	// instructions, taking several frames.
+	if got := v.Cycles(); got < vm.CyclesPerFrame {
+		t.Errorf("booting took %d cycles, want at least %d", got, vm.CyclesPerFrame)
+	}
+}
+
+// state is the registers that differ between models after booting.
+type state struct {
+	AF, BC, DE, HL, SP, PC uint16
+	DIV, NR52, SC          uint8
+}
+
+func TestPostBootState(t *testing.T) {
+	dmgCart := cart(t, "TEST", cartridge.CGBNone, nil)
+	cgbCart := cart(t, "TEST", cartridge.CGBSupported, nil)
+	tests := []struct {
+		name  string
+		c     *cartridge.Cartridge
+		model model.Model
+		want  state
+	}{
+		{"DMG0", dmgCart, model.DMG0, state{0x0100, 0xFF13, 0x00C1, 0x8403, 0xFFFE, 0x0100, 0x18, 0xF1, 0x7E}},
+		{"DMG", dmgCart, model.DMG, state{0x01B0, 0x0013, 0x00D8, 0x014D, 0xFFFE, 0x0100, 0xAB, 0xF1, 0x7E}},
+		{"MGB", dmgCart, model.MGB, state{0xFFB0, 0x0013, 0x00D8, 0x014D, 0xFFFE, 0x0100, 0xAB, 0xF1, 0x7E}},
+		{"SGB", dmgCart, model.SGB, state{0x0100, 0x0014, 0x0000, 0xC060, 0xFFFE, 0x0100, 0x00, 0xF0, 0x7E}},
+		{"CGB", cgbCart, model.CGB, state{0x1180, 0x0000, 0xFF56, 0x000D, 0xFFFE, 0x0100, 0x00, 0xF1, 0x7F}},
+		{"AGB", cgbCart, model.AGB, state{0x1100, 0x0100, 0xFF56, 0x000D, 0xFFFE, 0x0100, 0x00, 0xF1, 0x7F}},
+		// "TEST" adds up to 0x40.
+		{"CGB running a DMG game", dmgCart, model.CGB, state{0x1180, 0x4000, 0x0008, 0x007C, 0xFFFE, 0x0100, 0x00, 0xF1, 0x7F}},
+		{"AGB running a DMG game", dmgCart, model.AGB, state{0x1100, 0x4100, 0x0008, 0x007C, 0xFFFE, 0x0100, 0x00, 0xF1, 0x7F}},
+		{"CGB running a DMG game with a special title", cart(t, "C", cartridge.CGBNone, nil), model.CGB,
+			state{0x1180, 0x4300, 0x0008, 0x991A, 0xFFFE, 0x0100, 0x00, 0xF1, 0x7F}},
+		{"CGB running a third party DMG game", cart(t, "TEST", cartridge.CGBNone, func(rom []byte) { rom[0x014B] = 0x08 }), model.CGB,
+			state{0x1180, 0x0000, 0x0008, 0x007C, 0xFFFE, 0x0100, 0x00, 0xF1, 0x7F}},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			v := vm.New(tc.c, vm.WithModel(tc.model))
+			r := v.Reg()
+			got := state{
+				r.AF(), r.BC(), r.DE(), r.HL(), r.SP, r.PC,
+				v.Mem().Read8(timer.DIV), v.Mem().Read8(apu.NR52), v.Mem().Read8(serial.SC),
+			}
+			if diff := cmp.Diff(tc.want, got); diff != "" {
+				t.Errorf("post-boot state mismatch (-want,+got):\n%s", diff)
+			}
+
+			for _, io := range []struct {
+				addr uint16
+				want uint8
+			}{
+				{joypad.P1, 0xCF},
+				{interrupts.FlagAddr, 0xE1},
+				{ppu.LCDC, 0x91},
+				{ppu.BGP, 0xFC},
+				{apu.NR50, 0x77},
+				{apu.NR51, 0xF3},
+			} {
+				if got := v.Mem().Read8(io.addr); got != io.want {
+					t.Errorf("%04X = %02X, want %02X", io.addr, got, io.want)
+				}
+			}
+		})
+	}
+}
+
+func TestDefaultModel(t *testing.T) {
+	for _, tc := range []struct {
+		flag cartridge.CGBFlag
+		af   uint16
+	}{
+		{cartridge.CGBNone, 0x01B0},
+		{cartridge.CGBOnly, 0x1180},
+	} {
+		v := vm.New(cart(t, "TEST", tc.flag, nil))
+		if got := v.Reg().AF(); got != tc.af {
+			t.Errorf("CGB flag %02X: AF = %04X, want %04X", tc.flag, got, tc.af)
+		}
+	}
+}
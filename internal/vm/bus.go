@@ -0,0 +1,48 @@
+package vm
+
+import "github.com/vsinha/vm/internal/memory"
+
+// cpuBus is the memory bus as the CPU sees it. While OAM DMA is copying, the
+// CPU is cut off from everything but the IO registers and HRAM, which sit on
+// its own internal bus. Reads from anywhere else return 0xFF and writes are
+// dropped.
+type cpuBus struct {
+	v *VM
+}
+
+func (b cpuBus) blocked(addr uint16) bool {
+	return addr < memory.IOStart && b.v.dma.OAMActive()
+}
+
+func (b cpuBus) Read8(addr uint16) uint8 {
+	if b.blocked(addr) {
+		return 0xFF
+	}
+	return b.v.mmu.Read8(addr)
+}
+
+func (b cpuBus) Write8(addr uint16, val uint8) {
+	if b.blocked(addr) {
+		return
+	}
+	b.v.mmu.Write8(addr, val)
+}
+
+func (b cpuBus) Read16(addr uint16) uint16 {
+	return uint16(b.Read8(addr)) | uint16(b.Read8(addr+1))<<8
+}
+
+func (b cpuBus) Write16(addr uint16, val uint16) {
+	b.Write8(addr, uint8(val))
+	b.Write8(addr+1, uint8(val>>8))
+}
+
+// openBus is attached to registers the model doesn't have. Reads return 0xFF
+// and writes are dropped.
+type openBus struct{}
+
+func (openBus) Read8(addr uint16) uint8 {
+	return 0xFF
+}
+
+func (openBus) Write8(addr uint16, val uint8) {}
@@ -0,0 +1,29 @@
+package vm
+
+// key1Addr is the CGB speed switch register.
+const key1Addr = 0xFF4D
+
+// speedSwitchCycles is how long the CPU is stopped for while it changes speed.
+const speedSwitchCycles = 2050 * 4
+
+// key1 is the CGB KEY1 register. Bit 0 arms a speed switch, which the next
+// STOP carries out, and bit 7 reads the current speed.
+type key1 struct {
+	armed       bool
+	doubleSpeed bool
+}
+
+func (k *key1) Read8(addr uint16) uint8 {
+	val := uint8(0x7E) // Unused bits read as 1s.
+	if k.doubleSpeed {
+		val |= 0x80
+	}
+	if k.armed {
+		val |= 0x01
+	}
+	return val
+}
+
+func (k *key1) Write8(addr uint16, val uint8) {
+	k.armed = val&0x01 != 0
+}
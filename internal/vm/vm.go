@@ -4,17 +4,129 @@ import (
 	"fmt"
 	"io"
 
+	"github.com/vsinha/vm/internal/apu"
+	"github.com/vsinha/vm/internal/cartridge"
+	"github.com/vsinha/vm/internal/dma"
+	"github.com/vsinha/vm/internal/interrupts"
+	"github.com/vsinha/vm/internal/joypad"
 	"github.com/vsinha/vm/internal/memory"
+	"github.com/vsinha/vm/internal/model"
 	"github.com/vsinha/vm/internal/opcodes"
+	"github.com/vsinha/vm/internal/ppu"
 	"github.com/vsinha/vm/internal/registers"
+	"github.com/vsinha/vm/internal/serial"
+	"github.com/vsinha/vm/internal/timer"
 )
 
 // VM is the in-memory virtual machine!
 type VM struct {
-	r   registers.Registers
-	mem memory.Memory
+	r      registers.Registers
+	mmu    *memory.MMU
+	cart   *cartridge.Cartridge
+	ic     *interrupts.Controller
+	timer  *timer.Timer
+	ppu    *ppu.PPU
+	dma    *dma.Controller
+	apu    *apu.APU
+	pad    *joypad.Joypad
+	serial *serial.Serial
+	key1   key1
 
-	trace bool
+	// halted is set by HALT until an interrupt is pending, and stopped by
+	// STOP until a button is pressed.
+	halted  bool
+	stopped bool
+	// haltBug is set when HALT is run with IME clear and an interrupt already
+	// pending. The CPU doesn't halt, but fails to increment the PC after
+	// fetching the next opcode.
+	haltBug bool
+
+	// cycles counts the cycles of the system clock run since the VM was
+	// created.
+	cycles uint64
+
+	// cgb is set when running a Game Boy Color game in CGB mode.
+	cgb bool
+	// model is the hardware being emulated. Unless the VM runs bootROM, it
+	// starts in the state the model's boot ROM leaves behind.
+	model    model.Model
+	modelSet bool
+	bootROM  []byte
+
+	terminateOnHalt bool
+	correctColors   bool
+	sampleRate      int
+	channelOutputs  bool
+	trace           bool
+}
+
+// CyclesPerFrame is the number of cycles the PPU takes to draw a frame, about
+// 1/60th of a second.
+const CyclesPerFrame = 70224
+
+// Option changes how New sets up a VM.
+type Option func(*VM)
+
+// WithTerminateOnHalt makes Run and Step return opcodes.ErrHalt when the CPU
+// runs HALT, instead of idling until an interrupt. It's meant for unit tests,
+// which use HALT to mark the end of a program.
+func WithTerminateOnHalt() Option {
+	return func(v *VM) {
+		v.terminateOnHalt = true
+	}
+}
+
+// WithTrace makes the VM print every instruction it runs, which is far too
+// slow and noisy for anything but debugging.
+func WithTrace() Option {
+	return func(v *VM) {
+		v.trace = true
+	}
+}
+
+// WithColorCorrection makes the PPU adjust Game Boy Color colours to look like
+// they did on the real LCD.
+func WithColorCorrection() Option {
+	return func(v *VM) {
+		v.correctColors = true
+	}
+}
+
+// WithSampleRate sets the rate of the audio read from the APU, in samples per
+// second.
+func WithSampleRate(rate int) Option {
+	return func(v *VM) {
+		v.sampleRate = rate
+	}
+}
+
+// WithModel sets the hardware the VM emulates, from the state it starts in to
+// the registers it has and its quirks. By default it's a CGB for cartridges
+// with Game Boy Color support and a DMG otherwise. Only a CGB or AGB runs
+// games in CGB mode; they run other games in compatibility mode.
+func WithModel(m model.Model) Option {
+	return func(v *VM) {
+		v.model = m
+		v.modelSet = true
+	}
+}
+
+// WithBootROM overlays a DMG or CGB boot ROM on the cartridge, and starts
+// running it from 0x0000 instead of starting in the post-boot state. It is
+// unmapped when the boot ROM writes to 0xFF50, just before jumping to the
+// cartridge's entry point.
+func WithBootROM(rom []byte) Option {
+	return func(v *VM) {
+		v.bootROM = rom
+	}
+}
+
+// WithChannelOutputs makes the APU keep the output of each channel on its own,
+// to be read with APU().Channel.
+func WithChannelOutputs() Option {
+	return func(v *VM) {
+		v.channelOutputs = true
+	}
 }
 
 // Reg returns the registers of the vm.
@@ -22,71 +134,380 @@ func (v *VM) Reg() *registers.Registers {
 	return &v.r
 }
 
-// Mem returns the memory of the vm.
-func (v *VM) Mem() memory.Memory {
-	return v.mem
+// Mem returns the memory bus of the vm as the CPU sees it.
+func (v *VM) Mem() memory.Bus {
+	return cpuBus{v}
+}
+
+// MMU returns the memory bus of the vm, for attaching devices to it.
+func (v *VM) MMU() *memory.MMU {
+	return v.mmu
+}
+
+// Interrupts returns the interrupt controller of the vm.
+func (v *VM) Interrupts() *interrupts.Controller {
+	return v.ic
+}
+
+// PPU returns the picture processing unit of the vm, which holds the frames it
+// draws.
+func (v *VM) PPU() *ppu.PPU {
+	return v.ppu
+}
+
+// APU returns the audio processing unit of the vm, which the audio it plays
+// can be read from.
+func (v *VM) APU() *apu.APU {
+	return v.apu
+}
+
+// Joypad returns the buttons of the vm, for pressing and releasing them.
+func (v *VM) Joypad() *joypad.Joypad {
+	return v.pad
+}
+
+// Serial returns the serial port of the vm, for plugging in a link cable.
+func (v *VM) Serial() *serial.Serial {
+	return v.serial
+}
+
+// Cartridge returns the cartridge the vm was created with, or nil if it was
+// created with plain memory.
+func (v *VM) Cartridge() *cartridge.Cartridge {
+	return v.cart
+}
+
+// New creates anew Virtual Machine with rom mapped in as ROM and the VM ready
+// to run. rom is usually either a memory.Memory holding a raw program, in
+// which case the PC will be set to 0, or a *cartridge.Cartridge, in which case
+// the cartridge's RAM is mapped in as well and the VM starts at the
+// cartridge's entry point at 0x0100, in the state the boot ROM leaves behind.
+// With WithBootROM, the boot ROM runs from 0x0000 instead. Cartridges with
+// Game Boy Color support run in CGB mode on models that have it, as do raw
+// programs.
+func New(rom memory.Device, opts ...Option) *VM {
+	if mem, ok := rom.(memory.Memory); ok && len(mem) == 0 {
+		// An empty program is an empty cartridge slot.
+		rom = nil
+	}
+	v := &VM{
+		mmu:        memory.NewMMU(rom),
+		ic:         interrupts.New(),
+		sampleRate: apu.DefaultSampleRate,
+	}
+	for _, opt := range opts {
+		opt(v)
+	}
+
+	c, isCart := rom.(*cartridge.Cartridge)
+	cgbGame := !isCart || c.CGBFlag&0x80 != 0
+	if !v.modelSet {
+		v.model = model.DMG
+		if isCart && cgbGame {
+			v.model = model.CGB
+		}
+	}
+	// A CGB runs games without colour support in compatibility mode.
+	v.cgb = v.model.CGB() && cgbGame
+
+	ppuOpts := []ppu.Option{ppu.WithModel(v.model)}
+	var dmaOpts []dma.Option
+	var serialOpts []serial.Option
+	if v.cgb {
+		ppuOpts = append(ppuOpts, ppu.WithCGB())
+		dmaOpts = append(dmaOpts, dma.WithCGB())
+		serialOpts = append(serialOpts, serial.WithCGB())
+	}
+	if v.correctColors {
+		ppuOpts = append(ppuOpts, ppu.WithColorCorrection())
+	}
+	apuOpts := []apu.Option{apu.WithSampleRate(v.sampleRate), apu.WithModel(v.model)}
+	if v.channelOutputs {
+		apuOpts = append(apuOpts, apu.WithChannelOutputs())
+	}
+
+	v.timer = timer.New(v.ic)
+	v.ppu = ppu.New(v.ic, ppuOpts...)
+	v.dma = dma.New(v.mmu, v.ppu, dmaOpts...)
+	v.apu = apu.New(apuOpts...)
+	v.pad = joypad.New(v.ic)
+	v.serial = serial.New(v.ic, serialOpts...)
+	v.mmu.Attach(joypad.P1, joypad.P1, v.pad)
+	v.mmu.Attach(serial.SB, serial.SC, v.serial)
+	v.mmu.Attach(interrupts.FlagAddr, interrupts.FlagAddr, v.ic)
+	v.mmu.Attach(interrupts.EnableAddr, interrupts.EnableAddr, v.ic)
+	v.mmu.Attach(timer.DIV, timer.TAC, v.timer)
+	v.mmu.Attach(apu.NR10, apu.WaveRAMEnd, v.apu)
+	v.mmu.Attach(memory.VRAMStart, memory.VRAMEnd, v.ppu)
+	v.mmu.Attach(memory.OAMStart, memory.OAMEnd, v.ppu)
+	v.mmu.Attach(ppu.LCDC, ppu.LYC, v.ppu)
+	v.mmu.Attach(dma.DMA, dma.DMA, v.dma)
+	v.mmu.Attach(ppu.BGP, ppu.WX, v.ppu)
+	v.attachCGB(ppu.VBK, ppu.VBK, v.ppu)
+	v.attachCGB(dma.HDMA1, dma.HDMA5, v.dma)
+	v.attachCGB(ppu.BCPS, ppu.OCPD, v.ppu)
+	v.attachCGB(apu.PCM12, apu.PCM34, v.apu)
+	// The speed switch and WRAM banking only work in CGB mode.
+	if v.cgb {
+		w := &wram{}
+		v.mmu.Attach(key1Addr, key1Addr, &v.key1)
+		v.mmu.Attach(memory.WRAMStart, memory.WRAMEnd, w)
+		v.mmu.Attach(memory.EchoStart, memory.EchoEnd, w)
+		v.mmu.Attach(svbkAddr, svbkAddr, w)
+	} else {
+		v.mmu.Attach(key1Addr, key1Addr, openBus{})
+		v.mmu.Attach(svbkAddr, svbkAddr, openBus{})
+	}
+
+	if isCart {
+		v.cart = c
+		v.mmu.Attach(memory.ExtRAMStart, memory.ExtRAMEnd, c)
+	}
+	switch {
+	case v.bootROM != nil:
+		b := &bootROM{rom: v.bootROM, cart: rom, mmu: v.mmu}
+		b.attach()
+	case isCart:
+		v.postBoot(c)
+	}
+
+	return v
 }
 
-// New creates anew Virtual Machine with the provided memory initialized. The PC
-// will be set to 0 and the VM will be ready to run.
-func New(mem memory.Memory) *VM {
-	return &VM{
-		mem: mem,
+// attachCGB attaches d to registers only a CGB has. Other models get an open
+// bus there.
+func (v *VM) attachCGB(lo, hi uint16, d memory.Device) {
+	if !v.model.CGB() {
+		d = openBus{}
 	}
+	v.mmu.Attach(lo, hi, d)
 }
 
-// Run executes the virtual machine.
-func (v *VM) Run() error {
+// Close shuts the virtual machine down, flushing battery-backed cartridge RAM
+// to disk.
+func (v *VM) Close() error {
+	if v.cart == nil {
+		return nil
+	}
+	return v.cart.Flush()
+}
 
-	v.r.PC = 0
-	instructionCount := 1
-	cycles := uint(0)
-	max := 100
+// Run executes the virtual machine a frame at a time until an instruction
+// fails or stop is closed, which it checks between frames. With a nil stop it
+// runs until an error.
+func (v *VM) Run(stop <-chan struct{}) error {
 	for {
-		instructionCount++
-		if instructionCount > max {
-			return fmt.Errorf("Ran %d instructions, bailing", max)
+		select {
+		case <-stop:
+			return nil
+		default:
+		}
+		if err := v.RunUntil((v.cycles/CyclesPerFrame + 1) * CyclesPerFrame); err != nil {
+			return err
 		}
+	}
+}
 
-		// Instructions are parseable in a direct way where the CPU can run an
-		// opcode and move on to the next. However, there are times when you
-		// will jump not to the beginning of an opcode, but instead to the
-		// argument of an opcode.
-		// For example:
-		// 0x30, 0x01, 0x00, 0x00
-		// This can be interpreted in two ways based on where you start parsing.
-		// If you start parsing at byte 0, this is:
-		// JR NC, 01
-		// NOP
-		// NOP
-		// If you start parsing at byte 1, this is:
-		// LD BC, 0000
-		// There is no guaruntee that we are going to jump to the correct byte
-		// alignment and sometimes this is used as a trick in obfuscated code.
-		i, err := opcodes.ReadInstruction(io.NewSectionReader(v.mem, int64(v.r.PC), 4))
-		if err != nil {
+// RunUntil steps the VM until it has run for at least the given number of
+// cycles since it was created, so it can drive a headless run for a set time.
+func (v *VM) RunUntil(cycles uint64) error {
+	for v.cycles < cycles {
+		if _, err := v.Step(); err != nil {
 			return err
 		}
+	}
+	return nil
+}
 
-		fmt.Printf("Running PC = %d: %v\n", v.r.PC, i)
+// Cycles returns the number of cycles run since the VM was created. They are
+// cycles of the 4194304 Hz system clock that the PPU and APU run on, so in
+// double speed the CPU runs two cycles for each.
+func (v *VM) Cycles() uint64 {
+	return v.cycles
+}
 
-		// execute
-		executionResult, err := i.Execute(v)
-		if err != nil {
-			return err
+// Model returns the hardware the vm emulates.
+func (v *VM) Model() model.Model {
+	return v.model
+}
+
+// DoubleSpeed reports whether a CGB has been switched to double speed mode.
+func (v *VM) DoubleSpeed() bool {
+	return v.key1.doubleSpeed
+}
+
+// Halted reports whether the CPU is idle after a HALT.
+func (v *VM) Halted() bool {
+	return v.halted
+}
+
+// Stopped reports whether the CPU is stopped after a STOP.
+func (v *VM) Stopped() bool {
+	return v.stopped
+}
+
+// idleCycles is how long Step takes while the CPU is halted or stopped.
+const idleCycles = 4
+
+// Step dispatches a pending interrupt if IME is set, then executes a single
+// instruction. It returns the number of cycles both took. While the CPU is
+// halted or stopped it executes nothing, but still returns the cycles that
+// passed. The rest of the hardware is advanced by the same number of cycles.
+func (v *VM) Step() (uint, error) {
+	cycles, err := v.step()
+	v.cycles += uint64(v.clockCycles(cycles))
+	v.tick(cycles)
+	return cycles, err
+}
+
+// clockCycles converts CPU cycles to cycles of the system clock. In double
+// speed the CPU, and the timer, serial port and OAM DMA with it, run twice as
+// fast as the PPU and APU.
+func (v *VM) clockCycles(cycles uint) uint {
+	if v.key1.doubleSpeed {
+		return cycles / 2
+	}
+	return cycles
+}
+
+// tick advances the hardware outside the CPU by the given number of cycles.
+func (v *VM) tick(cycles uint) {
+	if v.stopped {
+		// STOP halts the system clock as well as the CPU.
+		return
+	}
+	clock := v.clockCycles(cycles)
+	v.timer.Tick(cycles)
+	v.serial.Tick(cycles)
+	v.ppu.Tick(clock)
+	v.dma.Tick(cycles)
+	v.apu.Tick(clock)
+}
+
+func (v *VM) step() (uint, error) {
+	// The CPU does nothing while HDMA copies. The copy takes as long in
+	// either speed, which is twice the CPU cycles in double speed.
+	if stall := v.dma.Stall(); stall > 0 {
+		if v.key1.doubleSpeed {
+			stall *= 2
 		}
+		return stall, nil
+	}
 
-		// Increment PC by the length of the instruction.
-		if !executionResult.DidSetPC {
-			v.Reg().PC += uint16(i.Length())
+	if v.stopped {
+		// Pressing a button always ends STOP, whatever IE says.
+		if v.ic.Read8(interrupts.FlagAddr)&uint8(interrupts.Joypad) == 0 {
+			return idleCycles, nil
 		}
+		v.stopped = false
+	}
+	if v.halted {
+		if _, ok := v.ic.Pending(); !ok {
+			return idleCycles, nil
+		}
+		v.halted = false
+	}
+
+	cycles := v.dispatchInterrupt()
+	if cycles != 0 {
+		v.haltBug = false
+	}
+
+	// Instructions are parseable in a direct way where the CPU can run an
+	// opcode and move on to the next. However, there are times when you
+	// will jump not to the beginning of an opcode, but instead to the
+	// argument of an opcode.
+	// For example:
+	// 0x30, 0x01, 0x00, 0x00
+	// This can be interpreted in two ways based on where you start parsing.
+	// If you start parsing at byte 0, this is:
+	// JR NC, 01
+	// NOP
+	// NOP
+	// If you start parsing at byte 1, this is:
+	// LD BC, 0000
+	// There is no guaruntee that we are going to jump to the correct byte
+	// alignment and sometimes this is used as a trick in obfuscated code.
+	r := memory.NewReader(v.Mem(), v.r.PC)
+	if v.haltBug {
+		// The byte after HALT is read twice. Backing the PC up by one leaves
+		// it, and anything computed from it like return addresses, where the
+		// CPU would have it.
+		v.haltBug = false
+		r = io.MultiReader(io.LimitReader(memory.NewReader(v.Mem(), v.r.PC), 1), r)
+		v.r.PC--
+	}
+	i, err := opcodes.ReadInstruction(r)
+	if err != nil {
+		return cycles, err
+	}
 
-		// increment cycles
-		cycles += uint(executionResult.Cycles)
+	v.log("Running PC = %d: %v\n", v.r.PC, i)
+
+	// execute
+	executionResult, err := i.Execute(v)
+	switch {
+	case err == opcodes.ErrHalt:
+		if v.terminateOnHalt {
+			return cycles + uint(executionResult.Cycles), err
+		}
+		v.halt()
+	case err == opcodes.ErrStop:
+		cycles += v.stop()
+	case err != nil:
+		return cycles, err
+	}
 
-		// if cycles > cycles_between_interrupts
-		// then handle interrupts
+	// Increment PC by the length of the instruction.
+	if !executionResult.DidSetPC {
+		v.Reg().PC += uint16(i.Length())
 	}
+
+	// EI takes effect only once the instruction after it has run.
+	v.ic.Step()
+
+	return cycles + uint(executionResult.Cycles), nil
+}
+
+// halt idles the CPU until an interrupt is pending, unless one already is with
+// IME clear, which triggers the HALT bug instead.
+func (v *VM) halt() {
+	if _, ok := v.ic.Pending(); ok && !v.ic.IME() {
+		v.haltBug = true
+		return
+	}
+	v.halted = true
+}
+
+// stop carries out an armed CGB speed switch, or otherwise stops the CPU. It
+// returns the extra cycles a speed switch takes.
+func (v *VM) stop() uint {
+	v.timer.ResetDivider()
+	if v.key1.armed {
+		v.key1.armed = false
+		v.key1.doubleSpeed = !v.key1.doubleSpeed
+		return speedSwitchCycles
+	}
+	v.stopped = true
+	return 0
+}
+
+// dispatchInterrupt jumps to the vector of the highest priority pending
+// interrupt, if IME allows it, and returns the cycles that took.
+func (v *VM) dispatchInterrupt() uint {
+	if !v.ic.IME() {
+		return 0
+	}
+	i, ok := v.ic.Pending()
+	if !ok {
+		return 0
+	}
+
+	v.ic.Acknowledge(i)
+	v.r.SP -= 2
+	v.Mem().Write16(v.r.SP, v.r.PC)
+	v.r.PC = i.Vector()
+	return interrupts.DispatchCycles
 }
 
 func (v *VM) log(msg string, args ...interface{}) {
@@ -1,5 +1,495 @@
 package vm_test
 
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/vsinha/vm/internal/cartridge"
+	"github.com/vsinha/vm/internal/interrupts"
+	"github.com/vsinha/vm/internal/joypad"
+	"github.com/vsinha/vm/internal/memory"
+	"github.com/vsinha/vm/internal/model"
+	"github.com/vsinha/vm/internal/opcodes"
+	"github.com/vsinha/vm/internal/ppu"
+	"github.com/vsinha/vm/internal/serial"
+	"github.com/vsinha/vm/internal/timer"
+	"github.com/vsinha/vm/internal/vm"
+)
+
+// program returns a memory.Memory big enough to hold the interrupt vectors,
+// with code at 0x0000 and the given handlers at their vectors.
+func program(code []byte, handlers map[uint16][]byte) memory.Memory {
+	mem := make(memory.Memory, 0x100)
+	copy(mem, code)
+	for addr, h := range handlers {
+		copy(mem[addr:], h)
+	}
+	return mem
+}
+
+func TestEmptyROM(t *testing.T) {
+	for _, tc := range []struct {
+		name string
+		rom  memory.Device
+	}{
+		{"nil", nil},
+		{"nil Memory", memory.Memory(nil)},
+	} {
+		v := vm.New(tc.rom)
+		if got := v.Mem().Read8(0x0100); got != 0xFF {
+			t.Errorf("%s: Read8(0100) = %02X, want FF", tc.name, got)
+		}
+	}
+}
+
+func TestInterruptDispatch(t *testing.T) {
+	v := vm.New(program(
+		[]byte{0xFB, 0x00, 0x00}, // EI; NOP; NOP
+		map[uint16][]byte{
+			0x40: {0xF3}, // DI
+			0x50: {0xF3}, // DI
+		}))
+	v.Reg().SP = 0xD000
+	v.Mem().Write8(interrupts.EnableAddr, 0x05)
+	v.Mem().Write8(interrupts.FlagAddr, 0x05) // VBlank and Timer.
+
+	var pcs []uint16
+	var cycles []uint
+	for i := 0; i < 3; i++ {
+		c, err := v.Step()
+		if err != nil {
+			t.Fatalf("Step() error: %v", err)
+		}
+		pcs = append(pcs, v.Reg().PC)
+		cycles = append(cycles, c)
+	}
+
+	// Nothing is dispatched until the NOP after EI has run, then VBlank wins
+	// over Timer and its handler's DI keeps Timer waiting.
+	if diff := cmp.Diff([]uint16{0x01, 0x02, 0x41}, pcs); diff != "" {
+		t.Errorf("PC after each step differs (-want,+got):\n%s", diff)
+	}
+	if got, want := cycles[2], uint(interrupts.DispatchCycles+4); got != want {
+		t.Errorf("Step() dispatching took %d cycles, want %d", got, want)
+	}
+	if got := v.Mem().Read16(v.Reg().SP); got != 0x0002 {
+		t.Errorf("pushed return address = %04X, want 0002", got)
+	}
+	if got := v.Mem().Read8(interrupts.FlagAddr); got != 0xE4 {
+		t.Errorf("IF = %02X after dispatching VBlank, want E4", got)
+	}
+}
+
+func TestRETI(t *testing.T) {
+	v := vm.New(program(
+		[]byte{0xD9}, // RETI
+		map[uint16][]byte{
+			0x48: {0x00}, // NOP
+		}))
+	v.Reg().SP = 0xCFFE
+	v.Mem().Write16(0xCFFE, 0x0080)
+	v.Mem().Write8(interrupts.EnableAddr, 0x02)
+	v.Mem().Write8(interrupts.FlagAddr, 0x02) // LCD STAT.
+
+	if _, err := v.Step(); err != nil {
+		t.Fatalf("Step() error: %v", err)
+	}
+	if got := v.Reg().PC; got != 0x0080 {
+		t.Fatalf("PC after RETI = %04X, want 0080", got)
+	}
+
+	// RETI enables interrupts with no delay.
+	if _, err := v.Step(); err != nil {
+		t.Fatalf("Step() error: %v", err)
+	}
+	if got := v.Reg().PC; got != 0x0049 {
+		t.Errorf("PC after the step following RETI = %04X, want 0049", got)
+	}
+}
+
+// step runs v.Step n times, failing the test on error, and returns the cycles
+// the last step took.
+func step(t *testing.T, v *vm.VM, n int) uint {
+	t.Helper()
+	var cycles uint
+	for i := 0; i < n; i++ {
+		var err error
+		if cycles, err = v.Step(); err != nil {
+			t.Fatalf("Step() error: %v", err)
+		}
+	}
+	return cycles
+}
+
+func TestHalt(t *testing.T) {
+	v := vm.New(program(
+		[]byte{0xFB, 0x76, 0x00}, // EI; HALT; NOP
+		map[uint16][]byte{
+			0x50: {0xF3}, // DI
+		}))
+	v.Reg().SP = 0xD000
+	v.Mem().Write8(interrupts.EnableAddr, 0x04)
+
+	step(t, v, 2)
+	for i := 0; i < 10; i++ {
+		if got := step(t, v, 1); got != 4 {
+			t.Errorf("Step() while halted took %d cycles, want 4", got)
+		}
+	}
+	if !v.Halted() || v.Reg().PC != 0x0002 {
+		t.Fatalf("Halted(), PC = %v, %04X, want true, 0002", v.Halted(), v.Reg().PC)
+	}
+
+	// The timer interrupt wakes the CPU and is dispatched, returning to the
+	// instruction after HALT.
+	v.Mem().Write8(interrupts.FlagAddr, 0x04)
+	step(t, v, 1)
+	if v.Halted() || v.Reg().PC != 0x0051 {
+		t.Errorf("Halted(), PC = %v, %04X, want false, 0051", v.Halted(), v.Reg().PC)
+	}
+	if got := v.Mem().Read16(v.Reg().SP); got != 0x0002 {
+		t.Errorf("pushed return address = %04X, want 0002", got)
+	}
+}
+
+func TestTimerWakesHalt(t *testing.T) {
+	v := vm.New(program(
+		[]byte{0xFB, 0x76, 0x00}, // EI; HALT; NOP
+		map[uint16][]byte{
+			0x50: {0xF3}, // DI
+		}))
+	v.Reg().SP = 0xD000
+	v.Mem().Write8(interrupts.EnableAddr, 0x04)
+	v.Mem().Write8(timer.TIMA, 0xF0)
+	v.Mem().Write8(timer.TAC, 0x05)
+
+	// TIMA overflows after 16 increments of 16 cycles, and the reload takes
+	// another 4.
+	var cycles uint
+	for v.Reg().PC != 0x0051 && cycles < 1000 {
+		cycles += step(t, v, 1)
+	}
+	if v.Reg().PC != 0x0051 {
+		t.Fatalf("timer interrupt not dispatched after %d cycles", cycles)
+	}
+	if cycles < 16*16+4 {
+		t.Errorf("timer interrupt dispatched after %d cycles, want at least %d", cycles, 16*16+4)
+	}
+}
+
+func TestHaltWithoutIME(t *testing.T) {
+	v := vm.New(program([]byte{0x76, 0x00}, nil)) // HALT; NOP
+	v.Mem().Write8(interrupts.EnableAddr, 0x01)
+
+	step(t, v, 2)
+	if !v.Halted() {
+		t.Fatalf("Halted() = false after HALT")
+	}
+
+	// With IME clear the CPU wakes up but carries on after HALT.
+	v.Mem().Write8(interrupts.FlagAddr, 0x01)
+	step(t, v, 1)
+	if v.Halted() || v.Reg().PC != 0x0002 {
+		t.Errorf("Halted(), PC = %v, %04X, want false, 0002", v.Halted(), v.Reg().PC)
+	}
+}
+
+func TestHaltBug(t *testing.T) {
+	v := vm.New(program([]byte{0x76, 0x80, 0x00}, nil)) // HALT; ADD A,B; NOP
+	v.Reg().B = 1
+	v.Mem().Write8(interrupts.EnableAddr, 0x01)
+	v.Mem().Write8(interrupts.FlagAddr, 0x01)
+
+	// HALT with IME clear and VBlank pending doesn't halt, and ADD A,B runs
+	// twice.
+	step(t, v, 3)
+	if v.Halted() {
+		t.Errorf("Halted() = true, want false")
+	}
+	if v.Reg().A != 2 || v.Reg().PC != 0x0002 {
+		t.Errorf("A, PC = %02X, %04X, want 02, 0002", v.Reg().A, v.Reg().PC)
+	}
+}
+
+func TestTerminateOnHalt(t *testing.T) {
+	v := vm.New(program([]byte{0x76}, nil), vm.WithTerminateOnHalt())
+	if _, err := v.Step(); err != opcodes.ErrHalt {
+		t.Errorf("Step() error = %v, want %v", err, opcodes.ErrHalt)
+	}
+}
+
+func TestStop(t *testing.T) {
+	v := vm.New(program([]byte{0x10, 0x00, 0x00}, nil)) // STOP 0; NOP
+
+	step(t, v, 1)
+	step(t, v, 5)
+	if !v.Stopped() || v.Reg().PC != 0x0001 {
+		t.Fatalf("Stopped(), PC = %v, %04X, want true, 0001", v.Stopped(), v.Reg().PC)
+	}
+
+	// A button press ends STOP even with the joypad interrupt disabled.
+	v.Mem().Write8(joypad.P1, 0x10)
+	v.Joypad().Press(joypad.A)
+	step(t, v, 1)
+	if v.Stopped() || v.Reg().PC != 0x0002 {
+		t.Errorf("Stopped(), PC = %v, %04X, want false, 0002", v.Stopped(), v.Reg().PC)
+	}
+}
+
+func TestSpeedSwitch(t *testing.T) {
+	v := vm.New(program([]byte{0x10, 0x00, 0x10, 0x00}, nil), vm.WithModel(model.CGB)) // STOP 0; NOP; STOP 0; NOP
+
+	v.Mem().Write8(0xFF4D, 0x01)
+	if got := step(t, v, 1); got != 4+2050*4 {
+		t.Errorf("STOP switching speed took %d cycles, want %d", got, 4+2050*4)
+	}
+	if !v.DoubleSpeed() || v.Stopped() {
+		t.Errorf("DoubleSpeed(), Stopped() = %v, %v, want true, false", v.DoubleSpeed(), v.Stopped())
+	}
+	if got := v.Mem().Read8(0xFF4D); got != 0xFE {
+		t.Errorf("KEY1 = %02X after switching, want FE", got)
+	}
+
+	v.Mem().Write8(0xFF4D, 0x01)
+	step(t, v, 2)
+	if v.DoubleSpeed() {
+		t.Errorf("DoubleSpeed() = true after switching back")
+	}
+}
+
+func TestDoubleSpeed(t *testing.T) {
+	code := []byte{0x10, 0x00, 0x18, 0xFE} // STOP 0; NOP; JR -2
+	normal := vm.New(program(code, nil), vm.WithModel(model.CGB))
+	normal.Reg().PC = 0x0002
+	double := vm.New(program(code, nil), vm.WithModel(model.CGB))
+	double.Mem().Write8(0xFF4D, 0x01)
+	step(t, double, 2)
+	if !double.DoubleSpeed() {
+		t.Fatal("DoubleSpeed() = false after STOP, want true")
+	}
+
+	// 75 JRs of 12 CPU cycles each outlast the first line of the LCD, which
+	// is 452 cycles, at normal speed but not at double speed. DIV follows
+	// the CPU either way.
+	for _, tc := range []struct {
+		name    string
+		v       *vm.VM
+		ly, div uint8
+		cycles  uint64
+	}{
+		{"normal speed", normal, 1, 3, 900},
+		{"double speed", double, 0, 3, 450},
+	} {
+		tc.v.Mem().Write8(ppu.LCDC, 0x00)
+		tc.v.Mem().Write8(ppu.LCDC, 0x80)
+		tc.v.Mem().Write8(timer.DIV, 0)
+		start := tc.v.Cycles()
+		step(t, tc.v, 75)
+		if got := tc.v.Mem().Read8(ppu.LY); got != tc.ly {
+			t.Errorf("%s: LY = %d, want %d", tc.name, got, tc.ly)
+		}
+		if got := tc.v.Mem().Read8(timer.DIV); got != tc.div {
+			t.Errorf("%s: DIV = %d, want %d", tc.name, got, tc.div)
+		}
+		if got := tc.v.Cycles() - start; got != tc.cycles {
+			t.Errorf("%s: ran for %d cycles, want %d", tc.name, got, tc.cycles)
+		}
+	}
+}
+
+func TestWRAMBanks(t *testing.T) {
+	v := vm.New(program(nil, nil), vm.WithModel(model.CGB))
+	for bank := uint8(0); bank < 8; bank++ {
+		v.Mem().Write8(0xFF70, bank)
+		v.Mem().Write8(0xD000, 0x10+bank)
+	}
+	v.Mem().Write8(0xC000, 0x42)
+
+	for _, tc := range []struct {
+		svbk uint8
+		want uint8
+	}{
+		{0, 0x11}, // Bank 0 selects bank 1.
+		{1, 0x11},
+		{2, 0x12},
+		{7, 0x17},
+		{0x0B, 0x13}, // Only the bottom three bits count.
+	} {
+		v.Mem().Write8(0xFF70, tc.svbk)
+		if got := v.Mem().Read8(0xD000); got != tc.want {
+			t.Errorf("SVBK %02X: D000 = %02X, want %02X", tc.svbk, got, tc.want)
+		}
+		if got := v.Mem().Read8(0xF000); got != tc.want {
+			t.Errorf("SVBK %02X: echo F000 = %02X, want %02X", tc.svbk, got, tc.want)
+		}
+		if got := v.Mem().Read8(0xC000); got != 0x42 {
+			t.Errorf("SVBK %02X: C000 = %02X, want 42", tc.svbk, got)
+		}
+	}
+	if got := v.Mem().Read8(0xFF70); got != 0xFB {
+		t.Errorf("SVBK = %02X, want FB", got)
+	}
+
+	dmg := vm.New(program(nil, nil))
+	dmg.Mem().Write8(0xFF70, 0x02)
+	dmg.Mem().Write8(0xD000, 0x22)
+	dmg.Mem().Write8(0xFF70, 0x03)
+	if got := dmg.Mem().Read8(0xD000); got != 0x22 {
+		t.Errorf("DMG: D000 = %02X after writing SVBK, want 22", got)
+	}
+	if got := dmg.Mem().Read8(0xFF70); got != 0xFF {
+		t.Errorf("DMG: SVBK = %02X, want FF", got)
+	}
+}
+
+func TestOAMDMABlocksCPU(t *testing.T) {
+	v := vm.New(program(nil, nil))
+	v.Mem().Write8(0xC000, 0x42)
+
+	// Spin in HRAM while DMA runs, as games do.
+	v.Mem().Write8(0xFF80, 0x18) // JR -2
+	v.Mem().Write8(0xFF81, 0xFE)
+	v.Reg().PC = 0xFF80
+	v.Mem().Write8(0xFF46, 0xC0)
+	step(t, v, 1)
+	if got := v.Mem().Read8(0xC000); got != 0xFF {
+		t.Errorf("WRAM read during OAM DMA = %02X, want FF", got)
+	}
+	v.Mem().Write8(0xC001, 0x42)
+	v.Mem().Write8(0xFFF0, 0x42)
+	if got := v.Mem().Read8(0xFFF0); got != 0x42 {
+		t.Errorf("HRAM read during OAM DMA = %02X, want 42", got)
+	}
+
+	// 160 bytes at 4 cycles each, 12 cycles a JR.
+	step(t, v, 160*4/12+1)
+	if got := v.Mem().Read8(0xC000); got != 0x42 {
+		t.Errorf("WRAM read after OAM DMA = %02X, want 42", got)
+	}
+	if got := v.Mem().Read8(0xC001); got != 0x00 {
+		t.Errorf("WRAM write during OAM DMA went through, read %02X", got)
+	}
+	if got := v.Mem().Read8(0xFE00); got != 0x42 {
+		t.Errorf("OAM after DMA = %02X, want 42", got)
+	}
+}
+
+func TestRunUntil(t *testing.T) {
+	v := vm.New(program([]byte{0x18, 0xFE}, nil)) // JR -2
+	for _, until := range []uint64{vm.CyclesPerFrame, 2 * vm.CyclesPerFrame} {
+		if err := v.RunUntil(until); err != nil {
+			t.Fatalf("RunUntil(%d) error: %v", until, err)
+		}
+		// JR takes 12 cycles, so it can overshoot by up to 11.
+		if got := v.Cycles(); got < until || got >= until+12 {
+			t.Errorf("Cycles() after RunUntil(%d) = %d", until, got)
+		}
+	}
+}
+
+func TestRun(t *testing.T) {
+	// Counting A up from 0 to 256 runs 512 instructions before reaching the
+	// HALT.
+	code := []byte{
+		0xC6, 0x01, // ADD A, 01
+		0x20, 0xFC, // JR NZ, -4
+		0x76, // HALT
+	}
+	v := vm.New(program(code, nil), vm.WithTerminateOnHalt())
+	if err := v.Run(nil); err != opcodes.ErrHalt {
+		t.Fatalf("Run() error = %v, want %v", err, opcodes.ErrHalt)
+	}
+	if v.Reg().PC != 0x0004 {
+		t.Errorf("PC = %04X after Run(), want 0004", v.Reg().PC)
+	}
+
+	stop := make(chan struct{})
+	close(stop)
+	v = vm.New(program([]byte{0x18, 0xFE}, nil)) // JR -2
+	if err := v.Run(stop); err != nil {
+		t.Errorf("Run() with stop closed error: %v", err)
+	}
+	if got := v.Cycles(); got != 0 {
+		t.Errorf("Cycles() = %d after Run() with stop closed, want 0", got)
+	}
+}
+
+func TestLinkedVMs(t *testing.T) {
+	a := vm.New(program([]byte{0x18, 0xFE}, nil)) // JR -2
+	b := vm.New(program([]byte{0x18, 0xFE}, nil))
+	serial.Pair(a.Serial(), b.Serial())
+
+	b.Mem().Write8(serial.SB, 0x34)
+	b.Mem().Write8(serial.SC, 0x80)
+	a.Mem().Write8(serial.SB, 0x12)
+	a.Mem().Write8(serial.SC, 0x81)
+	if err := a.RunUntil(4096); err != nil {
+		t.Fatal(err)
+	}
+	if err := b.RunUntil(4096); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, tc := range []struct {
+		name string
+		v    *vm.VM
+		want uint8
+	}{
+		{"a", a, 0x34},
+		{"b", b, 0x12},
+	} {
+		if got := tc.v.Mem().Read8(serial.SB); got != tc.want {
+			t.Errorf("%s: SB = %02X, want %02X", tc.name, got, tc.want)
+		}
+		if got := tc.v.Mem().Read8(interrupts.FlagAddr); got&0x08 == 0 {
+			t.Errorf("%s: IF = %02X, want the serial interrupt requested", tc.name, got)
+		}
+	}
+}
+
+func TestModels(t *testing.T) {
+	dmgCart := cart(t, "TEST", cartridge.CGBNone, nil)
+	cgbCart := cart(t, "TEST", cartridge.CGBSupported, nil)
+	tests := []struct {
+		name      string
+		c         *cartridge.Cartridge
+		opts      []vm.Option
+		model     model.Model
+		vbk, key1 uint8
+	}{
+		{"DMG game", dmgCart, nil, model.DMG, 0xFF, 0xFF},
+		{"CGB game", cgbCart, nil, model.CGB, 0xFE, 0x7E},
+		{"CGB game on a DMG", cgbCart, []vm.Option{vm.WithModel(model.DMG)}, model.DMG, 0xFF, 0xFF},
+		{"CGB game on an AGB", cgbCart, []vm.Option{vm.WithModel(model.AGB)}, model.AGB, 0xFE, 0x7E},
+		{"DMG game on a CGB", dmgCart, []vm.Option{vm.WithModel(model.CGB)}, model.CGB, 0xFF, 0xFF},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			v := vm.New(tc.c, tc.opts...)
+			if got := v.Model(); got != tc.model {
+				t.Errorf("Model() = %v, want %v", got, tc.model)
+			}
+			if got := v.Mem().Read8(ppu.VBK); got != tc.vbk {
+				t.Errorf("VBK = %02X, want %02X", got, tc.vbk)
+			}
+			if got := v.Mem().Read8(0xFF4D); got != tc.key1 {
+				t.Errorf("KEY1 = %02X, want %02X", got, tc.key1)
+			}
+			// Only a CGB's palette RAM holds what's written to it, in CGB
+			// and compatibility mode alike.
+			v.Mem().Write8(ppu.OCPS, 0x00)
+			v.Mem().Write8(ppu.OCPD, 0x12)
+			want := uint8(0xFF)
+			if tc.model.CGB() {
+				want = 0x12
+			}
+			if got := v.Mem().Read8(ppu.OCPD); got != want {
+				t.Errorf("OCPD = %02X after writing 12, want %02X", got, want)
+			}
+		})
+	}
+}
+
 // func Example() {
 // 	mem, err := assembler.Assemble([]interface{}{
 // 		vm.Loadi, // r1 = 5
@@ -0,0 +1,51 @@
+package vm
+
+import "github.com/vsinha/vm/internal/memory"
+
+// svbkAddr is the CGB WRAM bank register.
+const svbkAddr = 0xFF70
+
+// wramBankSize is the size of each of the CGB's eight 4 KiB WRAM banks.
+const wramBankSize = 0x1000
+
+// wram is the CGB's work RAM. Bank 0 is always at 0xC000-0xCFFF, and SVBK
+// picks which of banks 1 to 7 is at 0xD000-0xDFFF. It should be attached to
+// WRAM, echo RAM and SVBK.
+type wram struct {
+	banks [8][wramBankSize]uint8
+	svbk  uint8
+}
+
+// locate returns the bank and offset that addr in WRAM or echo RAM maps to.
+func (w *wram) locate(addr uint16) (*[wramBankSize]uint8, uint16) {
+	if addr >= memory.EchoStart {
+		addr -= memory.EchoStart - memory.WRAMStart
+	}
+	off := addr - memory.WRAMStart
+	if off < wramBankSize {
+		return &w.banks[0], off
+	}
+	bank := w.svbk
+	if bank == 0 {
+		// Bank 0 can't be mapped twice; selecting it selects bank 1.
+		bank = 1
+	}
+	return &w.banks[bank], off - wramBankSize
+}
+
+func (w *wram) Read8(addr uint16) uint8 {
+	if addr == svbkAddr {
+		return 0xF8 | w.svbk
+	}
+	bank, off := w.locate(addr)
+	return bank[off]
+}
+
+func (w *wram) Write8(addr uint16, val uint8) {
+	if addr == svbkAddr {
+		w.svbk = val & 0x07
+		return
+	}
+	bank, off := w.locate(addr)
+	bank[off] = val
+}
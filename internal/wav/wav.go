@@ -0,0 +1,80 @@
+// Package wav writes 16-bit PCM audio, like the APU produces, to WAV files.
+package wav
+
+import (
+	"encoding/binary"
+	"errors"
+	"io"
+)
+
+// headerSize is the size of the RIFF header, fmt chunk and data chunk header
+// that come before the samples.
+const headerSize = 44
+
+// ErrTooLong is returned by Write when the audio no longer fits in a WAV file,
+// whose sizes are 32 bits.
+var ErrTooLong = errors.New("wav: audio too long")
+
+// Writer writes 16-bit signed little-endian PCM samples, interleaved by
+// channel, to a WAV file. The file's sizes are filled in by Close, so it must
+// be called once all the audio has been written.
+type Writer struct {
+	w    io.WriteSeeker
+	size uint32 // Bytes of samples written.
+}
+
+// NewWriter writes the header of a WAV file holding the given number of
+// channels at sampleRate samples per second to w, and returns a Writer for
+// the samples that follow.
+func NewWriter(w io.WriteSeeker, sampleRate, channels int) (*Writer, error) {
+	blockAlign := channels * 2
+	var h [headerSize]byte
+	copy(h[0:], "RIFF")
+	copy(h[8:], "WAVE")
+	copy(h[12:], "fmt ")
+	binary.LittleEndian.PutUint32(h[16:], 16)
+	binary.LittleEndian.PutUint16(h[20:], 1) // PCM.
+	binary.LittleEndian.PutUint16(h[22:], uint16(channels))
+	binary.LittleEndian.PutUint32(h[24:], uint32(sampleRate))
+	binary.LittleEndian.PutUint32(h[28:], uint32(sampleRate*blockAlign))
+	binary.LittleEndian.PutUint16(h[32:], uint16(blockAlign))
+	binary.LittleEndian.PutUint16(h[34:], 16)
+	copy(h[36:], "data")
+	if _, err := w.Write(h[:]); err != nil {
+		return nil, err
+	}
+	return &Writer{w: w}, nil
+}
+
+// Write writes PCM samples to the file.
+func (w *Writer) Write(p []byte) (int, error) {
+	if uint64(w.size)+uint64(len(p)) > 1<<32-1-(headerSize-8) {
+		return 0, ErrTooLong
+	}
+	n, err := w.w.Write(p)
+	w.size += uint32(n)
+	return n, err
+}
+
+// Close fills in the sizes in the header. It doesn't close the underlying
+// writer.
+func (w *Writer) Close() error {
+	var b [4]byte
+	for _, f := range []struct {
+		offset int64
+		size   uint32
+	}{
+		{4, headerSize - 8 + w.size},
+		{40, w.size},
+	} {
+		if _, err := w.w.Seek(f.offset, io.SeekStart); err != nil {
+			return err
+		}
+		binary.LittleEndian.PutUint32(b[:], f.size)
+		if _, err := w.w.Write(b[:]); err != nil {
+			return err
+		}
+	}
+	_, err := w.w.Seek(0, io.SeekEnd)
+	return err
+}
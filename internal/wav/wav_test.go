@@ -0,0 +1,52 @@
+package wav_test
+
+import (
+	"io/ioutil"
+	"os"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/vsinha/vm/internal/wav"
+)
+
+func TestWriter(t *testing.T) {
+	f, err := ioutil.TempFile("", "wav")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.Remove(f.Name()) })
+	defer f.Close()
+
+	w, err := wav.NewWriter(f, 44100, 2)
+	if err != nil {
+		t.Fatalf("NewWriter() error: %v", err)
+	}
+	for _, samples := range [][]byte{{0x01, 0x02, 0x03, 0x04}, {0xFF, 0x7F, 0x00, 0x80}} {
+		if _, err := w.Write(samples); err != nil {
+			t.Fatalf("Write() error: %v", err)
+		}
+	}
+	if err := w.Close(); err != nil {
+		t.Fatalf("Close() error: %v", err)
+	}
+
+	got, err := ioutil.ReadFile(f.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []byte{
+		'R', 'I', 'F', 'F', 44, 0, 0, 0, 'W', 'A', 'V', 'E',
+		'f', 'm', 't', ' ', 16, 0, 0, 0,
+		1, 0, // PCM.
+		2, 0, // Channels.
+		0x44, 0xAC, 0, 0, // 44100 Hz.
+		0x10, 0xB1, 0x02, 0, // 176400 bytes per second.
+		4, 0, // Bytes per frame.
+		16, 0, // Bits per sample.
+		'd', 'a', 't', 'a', 8, 0, 0, 0,
+		0x01, 0x02, 0x03, 0x04, 0xFF, 0x7F, 0x00, 0x80,
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("WAV file mismatch (-want,+got):\n%s", diff)
+	}
+}